@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-telegram/bot"
+	channelDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/domain"
+	channelService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/service"
+	appErrors "github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/samber/do/v2"
+	"github.com/samber/oops"
+	"github.com/urfave/cli/v2"
+)
+
+var channelsCommand = &cli.Command{
+	Name:  "channels",
+	Usage: "manage monitored channels",
+	Subcommands: []*cli.Command{
+		{
+			Name:   "list",
+			Usage:  "list stored channels",
+			Action: listChannels,
+		},
+		{
+			Name:      "add",
+			Usage:     "add a channel; without --id the channel is resolved through the Bot API",
+			ArgsUsage: "<@username>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "id", Usage: "channel ID, e.g. -1001234567890"},
+				&cli.StringFlag{Name: "title", Usage: "channel title"},
+				&cli.Int64Flag{Name: "owner", Usage: "Telegram user ID of the channel owner"},
+				&cli.BoolFlag{Name: "inactive", Usage: "store the channel without monitoring it"},
+			},
+			Action: addChannel,
+		},
+		{
+			Name:      "remove",
+			Usage:     "remove a channel",
+			ArgsUsage: "<channelID>",
+			Action:    removeChannel,
+		},
+	},
+}
+
+func listChannels(c *cli.Context) error {
+	return withServices(c, func(injector do.Injector) error {
+		channels, err := do.MustInvoke[*channelService.Service](injector).GetAllChannels()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tUSERNAME\tTITLE\tACTIVE\tOWNER\tSOURCE")
+		for _, channel := range channels {
+			fmt.Fprintf(w, "%s\t@%s\t%s\t%t\t%d\t%s\n",
+				channel.ID, channel.Username, channel.Title, channel.IsActive, channel.AddedBy, channel.Source)
+		}
+		return w.Flush()
+	})
+}
+
+func addChannel(c *cli.Context) error {
+	username := strings.TrimPrefix(c.Args().First(), "@")
+	if username == "" {
+		return oops.Wrapf(appErrors.ErrInvalidRequest, "usage: channels add <@username> [--id ID]")
+	}
+
+	return withServices(c, func(injector do.Injector) error {
+		service := do.MustInvoke[*channelService.Service](injector)
+
+		var channel *channelDomain.Channel
+		if id := c.String("id"); id != "" {
+			channel = &channelDomain.Channel{
+				ID:         id,
+				Username:   username,
+				Title:      username,
+				AddedAt:    time.Now(),
+				Filters:    []channelDomain.Filter{},
+				LastUpdate: time.Now(),
+				IsActive:   true,
+			}
+		} else {
+			// Resolving needs the Bot API, so only now connect the bot
+			if _, err := do.Invoke[*bot.Bot](injector); err != nil {
+				return err
+			}
+			resolved, err := service.ResolveChannel(c.Context, username, 0)
+			if err != nil {
+				return err
+			}
+			channel = resolved
+		}
+
+		if _, err := service.GetChannel(channel.ID); err == nil {
+			return oops.With("channel_id", channel.ID).Wrap(appErrors.ErrChannelExists)
+		}
+
+		channel.AddedBy = c.Int64("owner")
+		if title := c.String("title"); title != "" {
+			channel.Title = title
+		}
+		channel.IsActive = !c.Bool("inactive")
+
+		if err := service.SaveChannel(channel); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(c.App.Writer, "✅ Channel added: %s (@%s)\n", channel.ID, channel.Username)
+		return nil
+	})
+}
+
+func removeChannel(c *cli.Context) error {
+	channelID := c.Args().First()
+	if channelID == "" {
+		return oops.Wrapf(appErrors.ErrInvalidRequest, "usage: channels remove <channelID>")
+	}
+
+	return withServices(c, func(injector do.Injector) error {
+		service := do.MustInvoke[*channelService.Service](injector)
+		if _, err := service.GetChannel(channelID); err != nil {
+			return err
+		}
+		if err := service.DeleteChannel(channelID); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(c.App.Writer, "✅ Channel removed: %s\n", channelID)
+		return nil
+	})
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/config"
+	"github.com/urfave/cli/v2"
+)
+
+var configCommand = &cli.Command{
+	Name:  "config",
+	Usage: "inspect the configuration",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "validate",
+			Usage: "load and validate the config file and environment without starting anything",
+			Action: func(c *cli.Context) error {
+				configFile := c.String("config")
+				if configFile == "" {
+					configFile = config.FindConfigFile()
+				}
+
+				cfg, err := config.LoadFile(configFile)
+				if err != nil {
+					return err
+				}
+
+				if configFile == "" {
+					configFile = "(environment only)"
+				}
+				fmt.Fprintf(c.App.Writer, "✅ Config is valid: %s\n", configFile)
+				fmt.Fprintf(c.App.Writer, "Declared channels: %d, collections: %d, reconcile: %s\n",
+					len(cfg.Channels), len(cfg.Collections), cfg.ChannelsReconcile)
+				return nil
+			},
+		},
+	},
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+
+	feedDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/feed/domain"
+	feedService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/feed/service"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/config"
+	appErrors "github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/samber/do/v2"
+	"github.com/samber/oops"
+	"github.com/urfave/cli/v2"
+)
+
+var feedCommand = &cli.Command{
+	Name:  "feed",
+	Usage: "work with generated feeds",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "render",
+			Usage:     "print the RSS XML of a channel feed to stdout",
+			ArgsUsage: "<channelID>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "feed", Usage: "render a named feed of the channel"},
+				&cli.BoolFlag{Name: "collection", Usage: "treat the argument as a collection name"},
+			},
+			Action: renderFeed,
+		},
+	},
+}
+
+func renderFeed(c *cli.Context) error {
+	target := c.Args().First()
+	if target == "" {
+		return oops.Wrapf(appErrors.ErrInvalidRequest, "usage: feed render <channelID> [--feed name] [--collection]")
+	}
+
+	return withServices(c, func(injector do.Injector) error {
+		service := do.MustInvoke[*feedService.Service](injector)
+		baseURL := do.MustInvoke[*config.Store](injector).Get().BaseURL()
+
+		var feed *feedDomain.Feed
+		var err error
+		switch {
+		case c.Bool("collection"):
+			feed, err = service.GenerateCollectionFeed(target, baseURL)
+		case c.String("feed") != "":
+			feed, err = service.GenerateNamedFeed(target, c.String("feed"), baseURL)
+		default:
+			feed, err = service.GenerateFeed(target, baseURL)
+		}
+		if err != nil {
+			return err
+		}
+
+		rss, err := feed.ToRss()
+		if err != nil {
+			return oops.With("context", "failed to convert feed to RSS").Wrap(err)
+		}
+		_, err = fmt.Fprintln(c.App.Writer, rss)
+		return err
+	})
+}
@@ -1,71 +1,101 @@
 package main
 
 import (
-	"context"
+	"io"
 	"log/slog"
 	"os"
-	"os/signal"
-	"syscall"
 
-	"github.com/go-telegram/bot"
 	"github.com/reshetovitsme/rss-telegram-feed/internal/di"
-	channelService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/service"
-	httpServer "github.com/reshetovitsme/rss-telegram-feed/internal/transport/http"
 	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/config"
 	"github.com/samber/do/v2"
 	slogmulti "github.com/samber/slog-multi"
+	"github.com/urfave/cli/v2"
 )
 
+// logLevel is the level of the text log handler and follows log_level
+var logLevel slog.LevelVar
+
 func main() {
-	// Setup structured logging with multiple handlers using slog-multi
-	textHandler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
+	// Offline commands log to stderr so their output on stdout stays clean
+	setupLogging(os.Stderr)
+
+	app := &cli.App{
+		Name:  "rss-telegram-feed",
+		Usage: "Telegram channels as RSS feeds",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c"},
+				Usage:   "path to the config file (default: config.{yaml,yml,json,toml} in the working directory)",
+			},
+		},
+		// Running without a subcommand starts the server
+		Action: serve,
+		Commands: []*cli.Command{
+			serveCommand,
+			configCommand,
+			channelsCommand,
+			messagesCommand,
+			feedCommand,
+			usersCommand,
+			storageCommand,
+		},
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		slog.Error("Command failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+// setupLogging sends logs to out as text at the configured level and errors
+// to stderr as JSON, using slog-multi
+func setupLogging(out io.Writer) {
+	textHandler := slog.NewTextHandler(out, &slog.HandlerOptions{
+		Level: &logLevel,
 	})
+	if out == os.Stderr {
+		slog.SetDefault(slog.New(textHandler))
+		return
+	}
+
 	jsonHandler := slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
 		Level: slog.LevelError,
 	})
 
 	// Use Fanout to send logs to both handlers
-	multiHandler := slogmulti.Fanout(textHandler, jsonHandler)
-	logger := slog.New(multiHandler)
-	slog.SetDefault(logger)
+	slog.SetDefault(slog.New(slogmulti.Fanout(textHandler, jsonHandler)))
+}
 
-	// Setup dependency injection
-	injector, err := di.Setup()
+// setup builds the DI container for the --config file and applies the
+// configured log level. Only the config is loaded; services are created when
+// a command first uses them.
+func setup(c *cli.Context) (do.Injector, error) {
+	injector, err := di.Setup(c.String("config"))
 	if err != nil {
-		slog.Error("Failed to setup dependency injection", "error", err)
-		os.Exit(1)
+		return nil, err
 	}
-	defer func() {
-		if err := di.Shutdown(injector); err != nil {
-			slog.Error("Error during shutdown", "error", err)
-		}
-	}()
 
-	// Get services from DI container
-	cfg := do.MustInvoke[*config.Config](injector)
-	channelService := do.MustInvoke[*channelService.Service](injector)
-	httpServer := do.MustInvoke[*httpServer.Server](injector)
-	_ = do.MustInvoke[*bot.Bot](injector) // Initialize bot (already done in Setup)
+	cfgStore, err := do.Invoke[*config.Store](injector)
+	if err != nil {
+		return nil, err
+	}
+	logLevel.Set(cfgStore.Get().SlogLevel())
 
-	// Start channel monitoring
-	go channelService.Start(context.Background())
+	return injector, nil
+}
 
-	// Start HTTP server
-	go func() {
-		if err := httpServer.Start(); err != nil {
-			slog.Error("Failed to start HTTP server", "error", err)
-			os.Exit(1)
+// withServices runs fn with a DI container and shuts it down afterwards
+func withServices(c *cli.Context, fn func(injector do.Injector) error) error {
+	injector, err := setup(c)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := di.Shutdown(injector); err != nil {
+			slog.Error("Error during shutdown", "error", err)
 		}
 	}()
 
-	slog.Info("Application started", "port", cfg.HTTPPort)
-	slog.Info("Press Ctrl+C to stop")
-
-	// Graceful shutdown
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer cancel()
-
-	<-ctx.Done()
-	slog.Info("Shutting down...")
+	return fn(injector)
 }
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	channelService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/service"
+	messageDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/domain"
+	messageService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/service"
+	appErrors "github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/samber/do/v2"
+	"github.com/samber/oops"
+	"github.com/urfave/cli/v2"
+)
+
+var messagesCommand = &cli.Command{
+	Name:  "messages",
+	Usage: "work with stored posts",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "export",
+			Usage:     "export posts as JSON Lines, newest first per channel; all channels when none are given",
+			ArgsUsage: "[channelID...]",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Usage: "write to a file instead of stdout"},
+				&cli.StringFlag{Name: "since", Usage: "only posts after this date (YYYY-MM-DD or RFC 3339)"},
+				&cli.IntFlag{Name: "limit", Usage: "maximum posts per channel, 0 for all"},
+			},
+			Action: exportMessages,
+		},
+	},
+}
+
+func exportMessages(c *cli.Context) error {
+	var since time.Time
+	if value := c.String("since"); value != "" {
+		parsed, err := parseDate(value)
+		if err != nil {
+			return err
+		}
+		since = parsed
+	}
+	limit := c.Int("limit")
+
+	return withServices(c, func(injector do.Injector) error {
+		channelIDs := c.Args().Slice()
+		if len(channelIDs) == 0 {
+			channels, err := do.MustInvoke[*channelService.Service](injector).GetAllChannels()
+			if err != nil {
+				return err
+			}
+			for _, channel := range channels {
+				channelIDs = append(channelIDs, channel.ID)
+			}
+		}
+
+		var out io.Writer = c.App.Writer
+		if path := c.String("output"); path != "" {
+			file, err := os.Create(path)
+			if err != nil {
+				return oops.With("output", path, "context", "failed to create export file").Wrap(err)
+			}
+			defer file.Close()
+			out = file
+		}
+
+		messages := do.MustInvoke[*messageService.Service](injector)
+		encoder := json.NewEncoder(out)
+		exported := 0
+		for _, channelID := range channelIDs {
+			count := 0
+			var encodeErr error
+			err := messages.IterateMessages(channelID, func(message *messageDomain.Message) bool {
+				if limit > 0 && count >= limit {
+					return false
+				}
+				if !since.IsZero() && !message.Date.After(since) {
+					return true
+				}
+				if encodeErr = encoder.Encode(message); encodeErr != nil {
+					return false
+				}
+				count++
+				return true
+			})
+			if err != nil {
+				return err
+			}
+			if encodeErr != nil {
+				return oops.With("channel_id", channelID, "context", "failed to write export").Wrap(encodeErr)
+			}
+			exported += count
+		}
+
+		fmt.Fprintf(c.App.ErrWriter, "Exported %d messages from %d channels\n", exported, len(channelIDs))
+		return nil
+	})
+}
+
+// parseDate accepts a calendar date or an RFC 3339 timestamp
+func parseDate(value string) (time.Time, error) {
+	if date, err := time.Parse(time.DateOnly, value); err == nil {
+		return date, nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, oops.With("value", value).Wrapf(appErrors.ErrInvalidRequest, "invalid date, use YYYY-MM-DD or RFC 3339")
+	}
+	return date, nil
+}
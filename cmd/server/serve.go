@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/go-telegram/bot"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/di"
+	channelService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/service"
+	mediaService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/media/service"
+	searchService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/search/service"
+	storageDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/storage/domain"
+	storageService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/storage/service"
+	userService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/user/service"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/config"
+	httpServer "github.com/reshetovitsme/rss-telegram-feed/internal/transport/http"
+	"github.com/samber/do/v2"
+	"github.com/samber/oops"
+	"github.com/urfave/cli/v2"
+)
+
+var serveCommand = &cli.Command{
+	Name:   "serve",
+	Usage:  "run the bot, channel monitoring and the HTTP server",
+	Action: serve,
+}
+
+func serve(c *cli.Context) error {
+	// The server logs to stdout and additionally reports errors as JSON on stderr
+	setupLogging(os.Stdout)
+
+	// Setup dependency injection
+	injector, err := setup(c)
+	if err != nil {
+		return oops.With("context", "failed to setup dependency injection").Wrap(err)
+	}
+	defer func() {
+		if err := di.Shutdown(injector); err != nil {
+			slog.Error("Error during shutdown", "error", err)
+		}
+	}()
+
+	// Get services from DI container
+	cfgStore := do.MustInvoke[*config.Store](injector)
+	channelService := do.MustInvoke[*channelService.Service](injector)
+	httpServer := do.MustInvoke[*httpServer.Server](injector)
+	userService := do.MustInvoke[*userService.Service](injector)
+	_ = do.MustInvoke[*bot.Bot](injector) // Initialize bot (already done in Setup)
+
+	// Check stored records before anything reads them
+	storageService := do.MustInvoke[*storageService.Service](injector)
+	quarantine := cfgStore.Get().CorruptRecords == storageDomain.IntegrityActionQuarantine
+	if _, err := storageService.CheckIntegrity(quarantine); err != nil {
+		slog.Error("Storage integrity scan failed", "error", err)
+	}
+
+	// Build the search index in the background; the first search waits for it
+	searchService := do.MustInvoke[*searchService.Service](injector)
+	go func() {
+		if err := searchService.Build(); err != nil {
+			slog.Error("Failed to build search index", "error", err)
+		}
+	}()
+
+	// Log a one-time claim code until the bot has an owner
+	if err := userService.Bootstrap(); err != nil {
+		return oops.With("context", "failed to bootstrap users").Wrap(err)
+	}
+
+	// Reconcile channels and collections declared in the config file
+	channelService.ReconcileDeclared(context.Background())
+
+	// Start channel monitoring
+	go channelService.Start(context.Background())
+
+	// Apply config file changes and SIGHUP reloads at runtime
+	cfgStore.Subscribe(func(previous, current *config.Config) {
+		logLevel.Set(current.SlogLevel())
+	})
+	cfgStore.Subscribe(channelService.ApplyConfig)
+
+	// Start HTTP server
+	go func() {
+		if err := httpServer.Start(); err != nil {
+			slog.Error("Failed to start HTTP server", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	slog.Info("Application started", "port", cfgStore.Get().HTTPPort)
+	slog.Info("Press Ctrl+C to stop")
+
+	// Graceful shutdown
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	go func() {
+		if err := cfgStore.Watch(ctx); err != nil {
+			slog.Error("Config hot reload disabled", "error", err)
+		}
+	}()
+
+	// Run scheduled backups
+	go storageService.Start(ctx)
+
+	// Archive the media of new posts
+	go do.MustInvoke[*mediaService.Service](injector).Start(ctx)
+
+	<-ctx.Done()
+	slog.Info("Shutting down...")
+	return nil
+}
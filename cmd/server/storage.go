@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/storage/domain"
+	appErrors "github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/samber/oops"
+
+	storageService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/storage/service"
+	"github.com/samber/do/v2"
+	"github.com/urfave/cli/v2"
+)
+
+var storageCommand = &cli.Command{
+	Name:  "storage",
+	Usage: "maintain the data directory",
+	Subcommands: []*cli.Command{
+		{
+			Name:   "migrate",
+			Usage:  "rewrite all stored records in the current format",
+			Action: migrateStorage,
+		},
+		{
+			Name:  "transfer",
+			Usage: "copy all data to another storage backend; stop the server first",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "to", Required: true, Usage: "destination backend as <backend>:<path>, e.g. file:./data-new"},
+				&cli.StringFlag{Name: "from", Usage: "source backend as <backend>:<path> (default: the configured storage)"},
+				&cli.StringFlag{Name: "state", Usage: "checkpoint file used to resume (default: .transfer-state.json in the destination path)"},
+				&cli.BoolFlag{Name: "dry-run", Usage: "only count and checksum the source"},
+			},
+			Action: transferStorage,
+		},
+		{
+			Name:  "check",
+			Usage: "decode every stored record and report corrupt ones",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "quarantine", Usage: "move corrupt records to the quarantine directory"},
+			},
+			Action: checkStorage,
+		},
+		{
+			Name:  "backup",
+			Usage: "write a compressed snapshot of channels, messages, users and media",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Usage: "archive path (default: a timestamped file in backup_path)"},
+			},
+			Action: backupStorage,
+		},
+		{
+			Name:      "verify",
+			Usage:     "check a backup archive against its manifest",
+			ArgsUsage: "<archive>",
+			Action:    verifyBackup,
+		},
+		{
+			Name:      "restore",
+			Usage:     "replace all stored data with a backup; stop the server first",
+			ArgsUsage: "<archive>",
+			Action:    restoreStorage,
+		},
+	},
+}
+
+func migrateStorage(c *cli.Context) error {
+	return withServices(c, func(injector do.Injector) error {
+		report, err := do.MustInvoke[*storageService.Service](injector).Migrate()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(c.App.Writer, "✅ Migrated %d channels, %d collections, %d users and %d messages\n",
+			report.Channels, report.Collections, report.Users, report.Messages)
+		return nil
+	})
+}
+
+func transferStorage(c *cli.Context) error {
+	to, err := domain.ParseBackendSpec(c.String("to"))
+	if err != nil {
+		return oops.With("to", c.String("to")).Wrap(err)
+	}
+	statePath := c.String("state")
+	if statePath == "" {
+		statePath = filepath.Join(to.Path, ".transfer-state.json")
+	}
+
+	return withServices(c, func(injector do.Injector) error {
+		var src *storageService.Backend
+		if from := c.String("from"); from != "" {
+			spec, err := domain.ParseBackendSpec(from)
+			if err != nil {
+				return oops.With("from", from).Wrap(err)
+			}
+			if src, err = storageService.OpenBackend(spec); err != nil {
+				return err
+			}
+		} else {
+			src = do.MustInvoke[*storageService.Backend](injector)
+		}
+
+		var dst *storageService.Backend
+		if !c.Bool("dry-run") {
+			if dst, err = storageService.OpenBackend(to); err != nil {
+				return err
+			}
+		} else {
+			dst = &storageService.Backend{Spec: to}
+		}
+
+		report, err := storageService.Transfer(src, dst, statePath, c.Bool("dry-run"))
+		if err != nil {
+			if _, statErr := os.Stat(statePath); statErr == nil {
+				fmt.Fprintf(c.App.ErrWriter, "Transfer stopped; run the same command again to resume from %s\n", statePath)
+			}
+			return err
+		}
+
+		w := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KIND\tSOURCE\tDESTINATION\tCHECKSUM")
+		for _, kind := range []string{domain.KindUsers, domain.KindCollections, domain.KindChannels, domain.KindMessages} {
+			destination := "-"
+			if summary, ok := report.Destination[kind]; ok {
+				destination = fmt.Sprint(summary.Count)
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", kind, report.Source[kind].Count, destination, report.Source[kind].Checksum[:12])
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		switch {
+		case report.DryRun:
+			fmt.Fprintf(c.App.Writer, "Dry run: nothing was written to %s\n", to)
+		case report.Resumed:
+			fmt.Fprintf(c.App.Writer, "✅ Resumed transfer to %s (%d channels already done) and verified it\n", to, report.Skipped)
+		default:
+			fmt.Fprintf(c.App.Writer, "✅ Transferred to %s and verified it\n", to)
+		}
+		return nil
+	})
+}
+
+func backupStorage(c *cli.Context) error {
+	return withServices(c, func(injector do.Injector) error {
+		service := do.MustInvoke[*storageService.Service](injector)
+
+		path := c.String("output")
+		var manifest *domain.BackupManifest
+		var err error
+		if path == "" {
+			path, manifest, err = service.CreateBackup()
+		} else {
+			manifest, err = service.BackupToFile(path)
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(c.App.Writer, "✅ Backup written to %s\n", path)
+		printManifest(c, manifest)
+		return nil
+	})
+}
+
+func verifyBackup(c *cli.Context) error {
+	path := c.Args().First()
+	if path == "" {
+		return oops.Wrapf(appErrors.ErrInvalidRequest, "usage: storage verify <archive>")
+	}
+
+	manifest, err := storageService.VerifyBackup(path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.App.Writer, "✅ Backup is intact: %s\n", path)
+	printManifest(c, manifest)
+	return nil
+}
+
+func restoreStorage(c *cli.Context) error {
+	path := c.Args().First()
+	if path == "" {
+		return oops.Wrapf(appErrors.ErrInvalidRequest, "usage: storage restore <archive>")
+	}
+
+	return withServices(c, func(injector do.Injector) error {
+		manifest, err := do.MustInvoke[*storageService.Service](injector).Restore(path)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(c.App.Writer, "✅ Restored %s\n", path)
+		printManifest(c, manifest)
+		return nil
+	})
+}
+
+func printManifest(c *cli.Context, manifest *domain.BackupManifest) {
+	fmt.Fprintf(c.App.Writer, "Created %s, format version %d: %d channels, %d collections, %d users, %d messages, %d media files\n",
+		manifest.CreatedAt.Format("2006-01-02 15:04:05 MST"), manifest.FormatVersion,
+		manifest.Channels, manifest.Collections, manifest.Users, manifest.Messages, manifest.Media)
+}
+
+func checkStorage(c *cli.Context) error {
+	return withServices(c, func(injector do.Injector) error {
+		report, err := do.MustInvoke[*storageService.Service](injector).CheckIntegrity(c.Bool("quarantine"))
+		if err != nil {
+			return err
+		}
+
+		for _, record := range report.Corrupt {
+			fmt.Fprintf(c.App.Writer, "❌ %s: %s\n", record.Path, record.Error)
+		}
+		fmt.Fprintf(c.App.Writer, "Scanned %d records: %d corrupt, %d quarantined, %d temporary files removed\n",
+			report.Scanned, len(report.Corrupt), report.Quarantined, report.TempFilesRemoved)
+		if len(report.Corrupt) > report.Quarantined {
+			return oops.With("corrupt", len(report.Corrupt)).Wrap(appErrors.ErrCorruptRecord)
+		}
+		return nil
+	})
+}
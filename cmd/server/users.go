@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+
+	userDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/user/domain"
+	userService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/user/service"
+	appErrors "github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/samber/do/v2"
+	"github.com/samber/oops"
+	"github.com/urfave/cli/v2"
+)
+
+// cliActor is the identity used for user management from the command line.
+// Shell access to the server is equivalent to owner rights.
+var cliActor = &userDomain.User{Role: userDomain.RoleOwner}
+
+var usersCommand = &cli.Command{
+	Name:  "users",
+	Usage: "manage bot users",
+	Subcommands: []*cli.Command{
+		{
+			Name:   "list",
+			Usage:  "list stored users",
+			Action: listUsers,
+		},
+		{
+			Name:      "add",
+			Usage:     "add a user or change the role of an existing one",
+			ArgsUsage: "<userID>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "username", Usage: "Telegram username"},
+				&cli.StringFlag{Name: "role", Value: userDomain.RoleViewer.String(), Usage: "owner, admin, editor or viewer"},
+			},
+			Action: addUser,
+		},
+	},
+}
+
+func listUsers(c *cli.Context) error {
+	return withServices(c, func(injector do.Injector) error {
+		users, err := do.MustInvoke[*userService.Service](injector).GetAllUsers()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tUSERNAME\tROLE\tADDED")
+		for _, user := range users {
+			fmt.Fprintf(w, "%d\t@%s\t%s\t%s\n", user.ID, user.Username, user.EffectiveRole(), user.AddedAt.Format("2006-01-02"))
+		}
+		return w.Flush()
+	})
+}
+
+func addUser(c *cli.Context) error {
+	userID, err := strconv.ParseInt(c.Args().First(), 10, 64)
+	if err != nil {
+		return oops.Wrapf(appErrors.ErrInvalidRequest, "usage: users add <userID> [--username name] [--role role]")
+	}
+	role, err := userDomain.ParseRole(c.String("role"))
+	if err != nil {
+		return oops.With("role", c.String("role")).Wrap(appErrors.ErrInvalidRole)
+	}
+
+	return withServices(c, func(injector do.Injector) error {
+		user, err := do.MustInvoke[*userService.Service](injector).AddUser(cliActor, userID, c.String("username"), role)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(c.App.Writer, "✅ User %d saved with role %s\n", user.ID, user.Role)
+		return nil
+	})
+}
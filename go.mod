@@ -5,6 +5,7 @@ go 1.24.0
 tool github.com/abice/go-enum
 
 require (
+	github.com/fsnotify/fsnotify v1.9.0
 	github.com/go-telegram/bot v1.17.0
 	github.com/gorilla/feeds v1.2.0
 	github.com/knadh/koanf/parsers/json v1.0.0
@@ -18,6 +19,7 @@ require (
 	github.com/samber/oops v1.20.0
 	github.com/samber/slog-http v1.10.0
 	github.com/samber/slog-multi v1.2.0
+	github.com/urfave/cli/v2 v2.27.7
 )
 
 require (
@@ -28,7 +30,6 @@ require (
 	github.com/abice/go-enum v0.9.2 // indirect
 	github.com/cpuguy83/go-md2man/v2 v2.0.7 // indirect
 	github.com/davecgh/go-spew v1.1.2-0.20180830191138-d8f796af33cc // indirect
-	github.com/fsnotify/fsnotify v1.9.0 // indirect
 	github.com/go-viper/mapstructure/v2 v2.5.0 // indirect
 	github.com/golang/mock v1.6.0 // indirect
 	github.com/google/uuid v1.6.0 // indirect
@@ -48,7 +49,6 @@ require (
 	github.com/samber/go-type-to-string v1.8.0 // indirect
 	github.com/shopspring/decimal v1.4.0 // indirect
 	github.com/spf13/cast v1.7.0 // indirect
-	github.com/urfave/cli/v2 v2.27.7 // indirect
 	github.com/xrash/smetrics v0.0.0-20240521201337-686a1a2994c1 // indirect
 	go.opentelemetry.io/otel v1.29.0 // indirect
 	go.opentelemetry.io/otel/trace v1.29.0 // indirect
@@ -5,76 +5,96 @@ import (
 	"log/slog"
 
 	"github.com/go-telegram/bot"
+	authService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/auth/service"
 	channelRepo "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/repository"
 	channelService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/service"
 	feedService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/feed/service"
+	mediaService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/media/service"
 	messageRepo "github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/repository"
 	messageService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/service"
+	searchService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/search/service"
+	storageDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/storage/domain"
+	storageService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/storage/service"
 	userRepo "github.com/reshetovitsme/rss-telegram-feed/internal/modules/user/repository"
 	userService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/user/service"
 	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/config"
-	telegramHandler "github.com/reshetovitsme/rss-telegram-feed/internal/transport/telegram"
 	httpServer "github.com/reshetovitsme/rss-telegram-feed/internal/transport/http"
+	telegramHandler "github.com/reshetovitsme/rss-telegram-feed/internal/transport/telegram"
 	"github.com/samber/do/v2"
+	"github.com/samber/lo"
 	"github.com/samber/oops"
 )
 
 // Service names for dependency injection
 const (
-	ServiceConfig         = "config"
-	ServiceChannelRepo    = "channel-repository"
-	ServiceMessageRepo    = "message-repository"
-	ServiceUserRepo       = "user-repository"
-	ServiceChannelService = "channel-service"
-	ServiceMessageService = "message-service"
-	ServiceUserService    = "user-service"
-	ServiceFeedService    = "feed-service"
+	ServiceConfig          = "config"
+	ServiceStorageBackend  = "storage-backend"
+	ServiceChannelRepo     = "channel-repository"
+	ServiceMessageRepo     = "message-repository"
+	ServiceUserRepo        = "user-repository"
+	ServiceChannelService  = "channel-service"
+	ServiceMessageService  = "message-service"
+	ServiceUserService     = "user-service"
+	ServiceFeedService     = "feed-service"
+	ServiceAuthService     = "auth-service"
+	ServiceStorageService  = "storage-service"
+	ServiceSearchService   = "search-service"
+	ServiceMediaService    = "media-service"
 	ServiceTelegramHandler = "telegram-handler"
-	ServiceHTTPServer     = "http-server"
-	ServiceBot            = "bot"
+	ServiceHTTPServer      = "http-server"
+	ServiceBot             = "bot"
 )
 
-// Setup initializes the dependency injection container
-func Setup() (do.Injector, error) {
+// Setup initializes the dependency injection container. Config is read from
+// configFile, or from the first config file in the working directory when
+// configFile is empty. Services are created lazily on first use, so commands
+// that never invoke the bot do not connect to Telegram.
+func Setup(configFile string) (do.Injector, error) {
 	injector := do.New()
 
 	// Register Config
-	do.Provide(injector, func(i do.Injector) (*config.Config, error) {
-		cfg, err := config.Load()
+	do.Provide(injector, func(i do.Injector) (*config.Store, error) {
+		if configFile == "" {
+			configFile = config.FindConfigFile()
+		}
+		cfg, err := config.LoadFile(configFile)
 		if err != nil {
-			return nil, oops.With("context", "failed to load config").Wrap(err)
+			return nil, oops.With("config_file", configFile, "context", "failed to load config").Wrap(err)
 		}
-		return cfg, nil
+		return config.NewStore(cfg, configFile), nil
 	})
 
-	// Register Channel Repository
-	do.Provide(injector, func(i do.Injector) (channelRepo.Repository, error) {
-		cfg := do.MustInvoke[*config.Config](i)
-		repo, err := channelRepo.NewFileStorage(cfg.StoragePath)
+	// Register Storage Backend
+	do.Provide(injector, func(i do.Injector) (*storageService.Backend, error) {
+		cfg := do.MustInvoke[*config.Store](i).Get()
+		backend, err := storageService.OpenBackend(storageDomain.BackendSpec{Backend: cfg.StorageBackend, Path: cfg.StoragePath})
 		if err != nil {
-			return nil, oops.With("storage_path", cfg.StoragePath, "context", "failed to initialize channel repository").Wrap(err)
+			return nil, oops.With("storage_path", cfg.StoragePath, "context", "failed to initialize storage backend").Wrap(err)
 		}
-		return repo, nil
+		return backend, nil
+	})
+
+	// Register Channel Repository
+	do.Provide(injector, func(i do.Injector) (channelRepo.Repository, error) {
+		return do.MustInvoke[*storageService.Backend](i).Channels, nil
 	})
 
-	// Register Message Repository
+	// Register Search Service, indexing the backend's messages directly
+	do.Provide(injector, func(i do.Injector) (*searchService.Service, error) {
+		backend := do.MustInvoke[*storageService.Backend](i)
+		return searchService.New(backend.Channels, backend.Messages), nil
+	})
+
+	// Register Message Repository, keeping the search index current
 	do.Provide(injector, func(i do.Injector) (messageRepo.Repository, error) {
-		cfg := do.MustInvoke[*config.Config](i)
-		repo, err := messageRepo.NewFileStorage(cfg.StoragePath)
-		if err != nil {
-			return nil, oops.With("storage_path", cfg.StoragePath, "context", "failed to initialize message repository").Wrap(err)
-		}
-		return repo, nil
+		backend := do.MustInvoke[*storageService.Backend](i)
+		search := do.MustInvoke[*searchService.Service](i)
+		return searchService.NewIndexedRepository(backend.Messages, search), nil
 	})
 
 	// Register User Repository
 	do.Provide(injector, func(i do.Injector) (userRepo.Repository, error) {
-		cfg := do.MustInvoke[*config.Config](i)
-		repo, err := userRepo.NewFileStorage(cfg.StoragePath)
-		if err != nil {
-			return nil, oops.With("storage_path", cfg.StoragePath, "context", "failed to initialize user repository").Wrap(err)
-		}
-		return repo, nil
+		return do.MustInvoke[*storageService.Backend](i).Users, nil
 	})
 
 	// Register Message Service
@@ -91,7 +111,7 @@ func Setup() (do.Injector, error) {
 
 	// Register Channel Service
 	do.Provide(injector, func(i do.Injector) (*channelService.Service, error) {
-		cfg := do.MustInvoke[*config.Config](i)
+		cfg := do.MustInvoke[*config.Store](i)
 		chRepo := do.MustInvoke[channelRepo.Repository](i)
 		msgRepo := do.MustInvoke[messageRepo.Repository](i)
 		return channelService.New(cfg, chRepo, msgRepo), nil
@@ -99,32 +119,63 @@ func Setup() (do.Injector, error) {
 
 	// Register Feed Service
 	do.Provide(injector, func(i do.Injector) (*feedService.Service, error) {
+		cfg := do.MustInvoke[*config.Store](i)
+		chRepo := do.MustInvoke[channelRepo.Repository](i)
+		msgRepo := do.MustInvoke[messageRepo.Repository](i)
+		media := do.MustInvoke[*mediaService.Service](i)
+		return feedService.New(cfg, chRepo, msgRepo, media), nil
+	})
+
+	// Register Media Service
+	do.Provide(injector, func(i do.Injector) (*mediaService.Service, error) {
+		cfg := do.MustInvoke[*config.Store](i)
+		return mediaService.New(cfg), nil
+	})
+
+	// Register Auth Service
+	do.Provide(injector, func(i do.Injector) (*authService.Service, error) {
+		cfg := do.MustInvoke[*config.Store](i)
+		return authService.New(cfg)
+	})
+
+	// Register Storage Service
+	do.Provide(injector, func(i do.Injector) (*storageService.Service, error) {
+		cfg := do.MustInvoke[*config.Store](i)
 		chRepo := do.MustInvoke[channelRepo.Repository](i)
 		msgRepo := do.MustInvoke[messageRepo.Repository](i)
-		return feedService.New(chRepo, msgRepo), nil
+		usrRepo := do.MustInvoke[userRepo.Repository](i)
+		return storageService.New(cfg, chRepo, msgRepo, usrRepo), nil
 	})
 
 	// Register Telegram Handler
 	do.Provide(injector, func(i do.Injector) (*telegramHandler.Handler, error) {
-		cfg := do.MustInvoke[*config.Config](i)
+		cfg := do.MustInvoke[*config.Store](i)
 		channelService := do.MustInvoke[*channelService.Service](i)
 		feedService := do.MustInvoke[*feedService.Service](i)
 		userService := do.MustInvoke[*userService.Service](i)
-		return telegramHandler.New(cfg, channelService, feedService, userService), nil
+		authService := do.MustInvoke[*authService.Service](i)
+		searchService := do.MustInvoke[*searchService.Service](i)
+		mediaService := do.MustInvoke[*mediaService.Service](i)
+		return telegramHandler.New(cfg, channelService, feedService, userService, authService, searchService, mediaService), nil
 	})
 
 	// Register HTTP Server
 	do.Provide(injector, func(i do.Injector) (*httpServer.Server, error) {
-		cfg := do.MustInvoke[*config.Config](i)
+		cfg := do.MustInvoke[*config.Store](i)
 		feedService := do.MustInvoke[*feedService.Service](i)
-		server := httpServer.New(cfg, feedService)
+		channelService := do.MustInvoke[*channelService.Service](i)
+		userService := do.MustInvoke[*userService.Service](i)
+		authService := do.MustInvoke[*authService.Service](i)
+		searchService := do.MustInvoke[*searchService.Service](i)
+		mediaService := do.MustInvoke[*mediaService.Service](i)
+		server := httpServer.New(cfg, feedService, channelService, userService, authService, searchService, mediaService)
 		server.SetLogger(slog.Default())
 		return server, nil
 	})
 
 	// Register Bot (needs to be initialized after handlers are ready)
 	do.Provide(injector, func(i do.Injector) (*bot.Bot, error) {
-		cfg := do.MustInvoke[*config.Config](i)
+		cfg := do.MustInvoke[*config.Store](i).Get()
 		telegramHandler := do.MustInvoke[*telegramHandler.Handler](i)
 
 		opts := []bot.Option{
@@ -142,6 +193,7 @@ func Setup() (do.Injector, error) {
 		// Set bot in channel service
 		channelService := do.MustInvoke[*channelService.Service](i)
 		channelService.SetBot(b)
+		do.MustInvoke[*mediaService.Service](i).SetBot(b)
 
 		return b, nil
 	})
@@ -149,19 +201,46 @@ func Setup() (do.Injector, error) {
 	return injector, nil
 }
 
-// Shutdown gracefully shuts down all services
+// Shutdown gracefully shuts down the services that were started
 func Shutdown(injector do.Injector) error {
 	ctx := context.Background()
 
-	// Shutdown bot if it exists
-	if b, err := do.Invoke[*bot.Bot](injector); err == nil && b != nil {
-		b.Close(ctx)
+	// Shutdown bot if it was created
+	if invoked[*bot.Bot](injector) {
+		if b, err := do.Invoke[*bot.Bot](injector); err == nil && b != nil {
+			b.Close(ctx)
+		}
+	}
+
+	// Store albums the bot was still collecting
+	if invoked[*telegramHandler.Handler](injector) {
+		if handler, err := do.Invoke[*telegramHandler.Handler](injector); err == nil && handler != nil {
+			handler.Close()
+		}
 	}
 
-	// Shutdown channel service if it exists
-	if channelService, err := do.Invoke[*channelService.Service](injector); err == nil && channelService != nil {
-		channelService.Stop()
+	// Save the access times of archived media
+	if invoked[*mediaService.Service](injector) {
+		if mediaService, err := do.Invoke[*mediaService.Service](injector); err == nil && mediaService != nil {
+			mediaService.Close()
+		}
+	}
+
+	// Shutdown channel service if it was created
+	if invoked[*channelService.Service](injector) {
+		if channelService, err := do.Invoke[*channelService.Service](injector); err == nil && channelService != nil {
+			channelService.Stop()
+		}
 	}
 
 	return nil
 }
+
+// invoked reports whether a service has already been created, so shutdown
+// does not construct services a command never used
+func invoked[T any](injector do.Injector) bool {
+	name := do.NameOf[T]()
+	return lo.ContainsBy(injector.ListInvokedServices(), func(service do.ServiceDescription) bool {
+		return service.Service == name
+	})
+}
@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+// LoginToken is a one-time token that signs a user into the web dashboard
+type LoginToken struct {
+	Token     string    `json:"token"`
+	UserID    int64     `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Session identifies a signed-in dashboard user
+type Session struct {
+	UserID    int64     `json:"user_id"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// TelegramIdentity is the user data signed by Telegram in the Login Widget flow
+type TelegramIdentity struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"username"`
+	FirstName string    `json:"first_name"`
+	AuthDate  time.Time `json:"auth_date"`
+}
@@ -0,0 +1,308 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/auth/domain"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/config"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/fileutil"
+	"github.com/samber/oops"
+)
+
+const (
+	// loginTokenTTL is how long a /weblogin link stays valid
+	loginTokenTTL = 10 * time.Minute
+	// telegramAuthMaxAge rejects Login Widget data signed longer ago than this
+	telegramAuthMaxAge = 24 * time.Hour
+	// revokedFile keeps sign-outs across restarts, relative to the storage path
+	revokedFile = "sessions/revoked.json"
+)
+
+// Service issues and verifies dashboard credentials
+type Service struct {
+	cfg           *config.Store
+	sessionSecret []byte
+	tokens        map[string]domain.LoginToken
+	// revoked holds when each user last signed out; sessions issued
+	// before then are rejected
+	revoked map[int64]time.Time
+	mu      sync.Mutex
+}
+
+// New creates a new auth service, loading the sign-outs recorded so far
+func New(cfg *config.Store) (*Service, error) {
+	secret := cfg.Get().DashboardSessionSecret
+	if secret == "" {
+		// Derive a stable secret from the bot token so sessions survive restarts
+		secret = "dashboard-session:" + cfg.Get().TelegramBotToken
+	}
+	sum := sha256.Sum256([]byte(secret))
+
+	s := &Service{
+		cfg:           cfg,
+		sessionSecret: sum[:],
+		tokens:        make(map[string]domain.LoginToken),
+	}
+	revoked, err := s.loadRevoked()
+	if err != nil {
+		return nil, err
+	}
+	s.revoked = revoked
+	return s, nil
+}
+
+// IssueLoginToken creates a one-time login token for a user
+func (s *Service) IssueLoginToken(userID int64) (*domain.LoginToken, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, oops.With("context", "failed to generate login token").Wrap(err)
+	}
+
+	token := domain.LoginToken{
+		Token:     base64.RawURLEncoding.EncodeToString(raw),
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(loginTokenTTL),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Drop expired tokens while we hold the lock
+	now := time.Now()
+	for key, existing := range s.tokens {
+		if now.After(existing.ExpiresAt) {
+			delete(s.tokens, key)
+		}
+	}
+	s.tokens[token.Token] = token
+
+	return &token, nil
+}
+
+// ConsumeLoginToken validates a login token and invalidates it
+func (s *Service) ConsumeLoginToken(token string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	loginToken, ok := s.tokens[token]
+	if !ok {
+		return 0, errors.ErrInvalidLogin
+	}
+	delete(s.tokens, token)
+
+	if time.Now().After(loginToken.ExpiresAt) {
+		return 0, errors.ErrInvalidLogin
+	}
+
+	return loginToken.UserID, nil
+}
+
+// VerifyTelegramLogin checks data signed by Telegram in the Login Widget flow.
+// See https://core.telegram.org/widgets/login#checking-authorization
+func (s *Service) VerifyTelegramLogin(values url.Values) (*domain.TelegramIdentity, error) {
+	hash := values.Get("hash")
+	if hash == "" {
+		return nil, errors.ErrInvalidLogin
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if key != "hash" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, key+"="+values.Get(key))
+	}
+	dataCheckString := strings.Join(lines, "\n")
+
+	secretKey := sha256.Sum256([]byte(s.cfg.Get().TelegramBotToken))
+	mac := hmac.New(sha256.New, secretKey[:])
+	mac.Write([]byte(dataCheckString))
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(hash))) {
+		return nil, oops.With("reason", "signature mismatch").Wrap(errors.ErrInvalidLogin)
+	}
+
+	authDate, err := strconv.ParseInt(values.Get("auth_date"), 10, 64)
+	if err != nil || time.Since(time.Unix(authDate, 0)) > telegramAuthMaxAge {
+		return nil, oops.With("reason", "stale auth_date").Wrap(errors.ErrInvalidLogin)
+	}
+
+	userID, err := strconv.ParseInt(values.Get("id"), 10, 64)
+	if err != nil {
+		return nil, oops.With("reason", "invalid id").Wrap(errors.ErrInvalidLogin)
+	}
+
+	return &domain.TelegramIdentity{
+		ID:        userID,
+		Username:  values.Get("username"),
+		FirstName: values.Get("first_name"),
+		AuthDate:  time.Unix(authDate, 0),
+	}, nil
+}
+
+// NewSession returns a signed session value for a user
+func (s *Service) NewSession(userID int64) (string, *domain.Session) {
+	now := time.Now()
+	session := &domain.Session{
+		UserID:    userID,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(s.sessionTTL()),
+	}
+
+	payload := fmt.Sprintf("%d.%d.%d", session.UserID, session.IssuedAt.UnixNano(), session.ExpiresAt.Unix())
+	return payload + "." + s.sign(payload), session
+}
+
+// ParseSession verifies a signed session value and that its user has not
+// signed out since it was issued
+func (s *Service) ParseSession(value string) (*domain.Session, error) {
+	payload, signature, ok := cutLast(value, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign(payload))) {
+		return nil, errors.ErrInvalidSession
+	}
+
+	fields := strings.Split(payload, ".")
+	if len(fields) != 3 {
+		return nil, errors.ErrInvalidSession
+	}
+
+	userID, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return nil, errors.ErrInvalidSession
+	}
+	issuedAt, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, errors.ErrInvalidSession
+	}
+	expiresAt, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil || time.Now().After(time.Unix(expiresAt, 0)) {
+		return nil, errors.ErrInvalidSession
+	}
+
+	session := &domain.Session{UserID: userID, IssuedAt: time.Unix(0, issuedAt), ExpiresAt: time.Unix(expiresAt, 0)}
+	s.mu.Lock()
+	revokedAt, revoked := s.revoked[userID]
+	s.mu.Unlock()
+	if revoked && !session.IssuedAt.After(revokedAt) {
+		return nil, errors.ErrInvalidSession
+	}
+	return session, nil
+}
+
+// RevokeSessions ends every dashboard session of a user issued so far. The
+// sign-out is stored, so the sessions stay revoked across restarts.
+func (s *Service) RevokeSessions(userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[userID] = time.Now()
+
+	// Forget revocations no session issued before them can outlive
+	for id, revokedAt := range s.revoked {
+		if time.Since(revokedAt) > s.sessionTTL() {
+			delete(s.revoked, id)
+		}
+	}
+	return s.saveRevoked()
+}
+
+func (s *Service) revokedPath() string {
+	return filepath.Join(s.cfg.Get().StoragePath, revokedFile)
+}
+
+// loadRevoked reads the stored sign-outs. A file that cannot be read fails
+// startup rather than bringing the sessions it revoked back.
+func (s *Service) loadRevoked() (map[int64]time.Time, error) {
+	revoked := make(map[int64]time.Time)
+	path := s.revokedPath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return revoked, nil
+		}
+		return nil, oops.With("path", path, "context", "failed to read session revocations").Wrap(err)
+	}
+	if err := json.Unmarshal(data, &revoked); err != nil {
+		return nil, oops.With("path", path).Wrapf(errors.ErrCorruptRecord, "failed to unmarshal session revocations: %v", err)
+	}
+	return revoked, nil
+}
+
+// saveRevoked stores the sign-outs; the caller holds s.mu
+func (s *Service) saveRevoked() error {
+	path := s.revokedPath()
+	data, err := json.Marshal(s.revoked)
+	if err != nil {
+		return oops.With("path", path, "context", "failed to marshal session revocations").Wrap(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return oops.With("path", path, "context", "failed to create sessions directory").Wrap(err)
+	}
+	return fileutil.WriteFileAtomic(path, data, 0600)
+}
+
+// FeedToken returns the token that scopes the search and tag feeds of a user
+// to the channels they may see. It is derived from the user ID, so feed links
+// stay valid until the session secret changes.
+func (s *Service) FeedToken(userID int64) string {
+	payload := strconv.FormatInt(userID, 10)
+	return payload + "." + s.sign("feed:"+payload)
+}
+
+// ParseFeedToken verifies a feed token and returns the user it was issued to
+func (s *Service) ParseFeedToken(token string) (int64, error) {
+	payload, signature, ok := cutLast(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign("feed:"+payload))) {
+		return 0, errors.ErrInvalidFeedToken
+	}
+	userID, err := strconv.ParseInt(payload, 10, 64)
+	if err != nil {
+		return 0, errors.ErrInvalidFeedToken
+	}
+	return userID, nil
+}
+
+// CSRFToken derives the form token bound to a session value
+func (s *Service) CSRFToken(sessionValue string) string {
+	return s.sign("csrf:" + sessionValue)
+}
+
+func (s *Service) sessionTTL() time.Duration {
+	if ttl := s.cfg.Get().DashboardSessionTTL; ttl > 0 {
+		return time.Duration(ttl) * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+func (s *Service) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.sessionSecret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
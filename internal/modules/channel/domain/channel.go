@@ -1,17 +1,34 @@
 package domain
 
-import "time"
+import (
+	"slices"
+	"strings"
+	"time"
+)
 
 // Channel represents a Telegram channel being monitored
 type Channel struct {
-	ID          string    `json:"id"`
-	Username    string    `json:"username"`
-	Title       string    `json:"title"`
-	AddedBy     int64     `json:"added_by"`
-	AddedAt     time.Time `json:"added_at"`
-	Filters     []Filter  `json:"filters"`
-	LastUpdate  time.Time `json:"last_update"`
-	IsActive    bool      `json:"is_active"`
+	ID         string           `json:"id"`
+	Username   string           `json:"username"`
+	Title      string           `json:"title"`
+	AddedBy    int64            `json:"added_by"`
+	AddedAt    time.Time        `json:"added_at"`
+	SharedWith []int64          `json:"shared_with,omitempty"`
+	Filters    []Filter         `json:"filters"`
+	Feeds      []FeedDefinition `json:"feeds,omitempty"`
+	Podcast    *Podcast         `json:"podcast,omitempty"`
+	// TitleTemplate formats the titles of feed items, see TitlePlaceholders
+	TitleTemplate string `json:"title_template,omitempty"`
+	// DeletedPosts is how feeds show posts deleted from the channel,
+	// hidden when empty
+	DeletedPosts DeletedPostPolicy `json:"deleted_posts,omitempty"`
+	// Rewrites change the text of feed items, see RewriteRule
+	Rewrites   []RewriteRule `json:"rewrites,omitempty"`
+	LastUpdate time.Time     `json:"last_update"`
+	IsActive   bool          `json:"is_active"`
+	// Source is "config" for channels declared in the config file.
+	// Empty for channels added through the bot or the API.
+	Source Source `json:"source,omitempty"`
 }
 
 // Filter represents content filtering criteria
@@ -20,3 +37,110 @@ type Filter struct {
 	Keywords []string   `json:"keywords"`
 	Enabled  bool       `json:"enabled"`
 }
+
+// FeedDefinition describes a named view of a channel with its own filters.
+// The channel-level Filters remain in effect for the default feed only.
+type FeedDefinition struct {
+	Name        string   `json:"name"`
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Filters     []Filter `json:"filters"`
+	// Limit is the maximum number of items in the feed (0 means the default)
+	Limit int `json:"limit,omitempty"`
+	// MaxAgeDays excludes posts older than this many days (0 means no limit)
+	MaxAgeDays int `json:"max_age_days,omitempty"`
+}
+
+// FindFeed returns the named feed definition of the channel
+func (c *Channel) FindFeed(name string) (*FeedDefinition, bool) {
+	name = NormalizeFeedName(name)
+	for i := range c.Feeds {
+		if c.Feeds[i].Name == name {
+			return &c.Feeds[i], true
+		}
+	}
+	return nil, false
+}
+
+// RemoveFeed deletes the named feed definition and reports whether it existed
+func (c *Channel) RemoveFeed(name string) bool {
+	name = NormalizeFeedName(name)
+	for i := range c.Feeds {
+		if c.Feeds[i].Name == name {
+			c.Feeds = append(c.Feeds[:i], c.Feeds[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// HidesDeleted reports whether posts deleted from the channel are left out
+// of its feeds rather than marked
+func (c *Channel) HidesDeleted() bool {
+	return c.DeletedPosts != DeletedPostPolicyMark
+}
+
+// OwnerID returns the user who added the channel
+func (c *Channel) OwnerID() int64 {
+	return c.AddedBy
+}
+
+// IsSharedWith reports whether the owner shared the channel with a user
+func (c *Channel) IsSharedWith(userID int64) bool {
+	return slices.Contains(c.SharedWith, userID)
+}
+
+// AccessibleBy reports whether the user owns the channel or it was shared with them
+func (c *Channel) AccessibleBy(userID int64) bool {
+	return c.AddedBy == userID || c.IsSharedWith(userID)
+}
+
+// Share grants a user access to the channel and reports whether it changed
+func (c *Channel) Share(userID int64) bool {
+	if c.AccessibleBy(userID) {
+		return false
+	}
+	c.SharedWith = append(c.SharedWith, userID)
+	return true
+}
+
+// Unshare revokes a user's shared access and reports whether it changed
+func (c *Channel) Unshare(userID int64) bool {
+	index := slices.Index(c.SharedWith, userID)
+	if index < 0 {
+		return false
+	}
+	c.SharedWith = slices.Delete(c.SharedWith, index, index+1)
+	return true
+}
+
+// ValidChannelID reports whether id is a Telegram chat ID, an optional '-'
+// followed by digits. Channels are stored under their ID, so nothing else
+// may be accepted as one.
+func ValidChannelID(id string) bool {
+	digits := strings.TrimPrefix(id, "-")
+	if digits == "" || len(digits) > 20 {
+		return false
+	}
+	return strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }) < 0
+}
+
+// NormalizeFeedName lowercases a feed name given by a user, so "Tech" and
+// "tech" refer to the same feed
+func NormalizeFeedName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// ValidFeedName reports whether name can be used as a feed name in URLs:
+// 1-64 characters of lowercase letters, digits, '-' or '_'
+func ValidFeedName(name string) bool {
+	if name == "" || len(name) > 64 {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+// Collection is a feed that merges posts of several channels
+type Collection struct {
+	Name        string    `json:"name"`
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	ChannelIDs  []string  `json:"channels"`
+	Filters     []Filter  `json:"filters"`
+	Limit       int       `json:"limit,omitempty"`
+	MaxAgeDays  int       `json:"max_age_days,omitempty"`
+	AddedBy     int64     `json:"added_by"`
+	AddedAt     time.Time `json:"added_at"`
+	SharedWith  []int64   `json:"shared_with,omitempty"`
+	Source      Source    `json:"source,omitempty"`
+}
+
+// OwnerID returns the user who added the collection
+func (c *Collection) OwnerID() int64 {
+	return c.AddedBy
+}
+
+// IsSharedWith reports whether the owner shared the collection with a user
+func (c *Collection) IsSharedWith(userID int64) bool {
+	return slices.Contains(c.SharedWith, userID)
+}
+
+// AccessibleBy reports whether the user owns the collection or it was shared with them
+func (c *Collection) AccessibleBy(userID int64) bool {
+	return c.AddedBy == userID || c.IsSharedWith(userID)
+}
+
+// Definition returns the feed definition used to render the collection
+func (c *Collection) Definition() *FeedDefinition {
+	return &FeedDefinition{
+		Name:        c.Name,
+		Title:       c.Title,
+		Description: c.Description,
+		Filters:     c.Filters,
+		Limit:       c.Limit,
+		MaxAgeDays:  c.MaxAgeDays,
+	}
+}
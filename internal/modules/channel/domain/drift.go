@@ -0,0 +1,24 @@
+package domain
+
+// Drift describes a difference between a declared and a stored channel or collection
+type Drift struct {
+	// Kind is "channel" or "collection"
+	Kind   string      `json:"kind"`
+	ID     string      `json:"id"`
+	Action DriftAction `json:"action"`
+	// Fields lists the differing fields of an update
+	Fields []string `json:"fields,omitempty"`
+}
+
+// ReconcileReport is the result of reconciling declared channels and collections
+type ReconcileReport struct {
+	// Applied is false when drift was only reported
+	Applied bool     `json:"applied"`
+	Drifts  []Drift  `json:"drifts"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// InSync reports whether stored state matched the declarations
+func (r *ReconcileReport) InSync() bool {
+	return len(r.Drifts) == 0 && len(r.Errors) == 0
+}
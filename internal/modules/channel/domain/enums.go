@@ -3,9 +3,34 @@
 package domain
 
 // FilterType represents the type of content filter
-// ENUM(keywords,exclude_keywords,author)
+// ENUM(keywords,exclude_keywords,author,media,exclude_media,exclude_forwards,tags,exclude_tags)
 type FilterType string
 
 // AppEnv represents the application environment
 // ENUM(local,production,development,testing)
 type AppEnv string
+
+// Source records where a channel or collection is defined
+// ENUM(config)
+type Source string
+
+// ReconcileMode controls how declared channels are applied to storage
+// ENUM(apply,drift,off)
+type ReconcileMode string
+
+// DriftAction is the change needed to bring stored state to the declared state
+// ENUM(create,update,delete)
+type DriftAction string
+
+// DeletionCheck is how the channel service checks that stored posts still
+// exist in their channel
+// ENUM(off,copy,forward)
+type DeletionCheck string
+
+// DeletedPostPolicy is how the feeds of a channel show deleted posts
+// ENUM(hide,mark)
+type DeletedPostPolicy string
+
+// RewriteType is how a rewrite rule changes the text of feed items
+// ENUM(replace,strip_signature,strip_utm,expand_links)
+type RewriteType string
@@ -0,0 +1,146 @@
+package domain
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	messageDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/domain"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/samber/oops"
+)
+
+// UnmarshalJSON decodes a filter, treating a missing "enabled" field as true
+// so that filters submitted without it take effect
+func (f *Filter) UnmarshalJSON(data []byte) error {
+	type rawFilter Filter
+	raw := rawFilter{Enabled: true}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*f = Filter(raw)
+	return nil
+}
+
+// Matches reports whether a message satisfies the filter.
+// Disabled filters match every message.
+func (f Filter) Matches(msg *messageDomain.Message) bool {
+	if !f.Enabled {
+		return true
+	}
+
+	switch f.Type {
+	case FilterTypeKeywords:
+		// At least one keyword must be present
+		return containsAny(msg.Text, f.Keywords)
+	case FilterTypeExcludeKeywords:
+		// None of the keywords may be present
+		return !containsAny(msg.Text, f.Keywords)
+	case FilterTypeAuthor:
+		// Author must match one of the listed names
+		return containsAny(msg.Author, f.Keywords)
+	case FilterTypeMedia:
+		// The message must contain one of the listed media types
+		return hasMediaType(msg, f.Keywords)
+	case FilterTypeExcludeMedia:
+		// The message may contain none of the listed media types
+		return !hasMediaType(msg, f.Keywords)
+	case FilterTypeExcludeForwards:
+		// The message may not be forwarded, from the listed sources if any
+		return !isForwardFrom(msg, f.Keywords)
+	case FilterTypeTags:
+		// The message must carry one of the listed tags
+		return hasTag(msg, f.Keywords)
+	case FilterTypeExcludeTags:
+		// The message may carry none of the listed tags
+		return !hasTag(msg, f.Keywords)
+	}
+
+	return true
+}
+
+// Validate checks that a filter has a known type and keywords, and that
+// the keywords of a media filter are media types. Forward filters without
+// keywords apply to every forward.
+func (f Filter) Validate() error {
+	if !f.Type.IsValid() {
+		return oops.Wrapf(errors.ErrInvalidFilter, "unknown filter type %q", f.Type)
+	}
+	if len(f.Keywords) == 0 && f.Type != FilterTypeExcludeForwards {
+		return oops.Wrapf(errors.ErrInvalidFilter, "filter needs at least one keyword")
+	}
+	if f.Type == FilterTypeMedia || f.Type == FilterTypeExcludeMedia {
+		for _, keyword := range f.Keywords {
+			if _, err := messageDomain.ParseMediaType(strings.TrimSpace(keyword)); err != nil {
+				return oops.Wrapf(errors.ErrInvalidFilter, "unknown media type %q, expected one of %s",
+					keyword, strings.Join(messageDomain.MediaTypeNames(), ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// PassesFilters checks if a message passes all enabled filters
+func PassesFilters(filters []Filter, msg *messageDomain.Message) bool {
+	for _, filter := range filters {
+		if !filter.Matches(msg) {
+			return false
+		}
+	}
+	return true
+}
+
+// HasActiveFilters reports whether any of the filters is enabled
+func HasActiveFilters(filters []Filter) bool {
+	for _, filter := range filters {
+		if filter.Enabled {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAny(text string, keywords []string) bool {
+	textLower := strings.ToLower(text)
+	for _, keyword := range keywords {
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		if keyword != "" && strings.Contains(textLower, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasMediaType(msg *messageDomain.Message, types []string) bool {
+	for _, media := range msg.Media {
+		for _, name := range types {
+			if mediaType, err := messageDomain.ParseMediaType(strings.TrimSpace(name)); err == nil && media.Type == mediaType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasTag(msg *messageDomain.Message, tags []string) bool {
+	return slices.ContainsFunc(tags, msg.HasTag)
+}
+
+// isForwardFrom reports whether a message was forwarded from one of the
+// sources, given as names or @usernames, or at all when sources is empty
+func isForwardFrom(msg *messageDomain.Message, sources []string) bool {
+	if msg.Forward == nil {
+		return false
+	}
+	if len(sources) == 0 {
+		return true
+	}
+	for _, source := range sources {
+		source = strings.TrimSpace(source)
+		if strings.EqualFold(source, msg.Forward.Name) ||
+			(msg.Forward.Username != "" && strings.EqualFold(strings.TrimPrefix(source, "@"), msg.Forward.Username)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"net/mail"
+	"net/url"
+	"slices"
+	"strings"
+
+	messageDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/domain"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/samber/oops"
+)
+
+// Podcast holds the podcast metadata of a channel. While enabled, the
+// feeds of the channel only contain posts with audio and carry it as an
+// episode enclosure with iTunes tags.
+type Podcast struct {
+	Enabled bool   `json:"enabled"`
+	Author  string `json:"author,omitempty"`
+	// Image is the URL of the square cover art
+	Image string `json:"image,omitempty"`
+	// Category is an Apple Podcasts category, "Parent > Subcategory" for a
+	// subcategory such as "Technology > Tech News"
+	Category string `json:"category,omitempty"`
+	Explicit bool   `json:"explicit"`
+	Language string `json:"language,omitempty"`
+	// Email is the owner contact listed in podcast directories
+	Email string `json:"email,omitempty"`
+}
+
+// PodcastMediaTypes are the media types published as podcast episodes
+var PodcastMediaTypes = []messageDomain.MediaType{
+	messageDomain.MediaTypeAudio,
+	messageDomain.MediaTypeVoice,
+}
+
+// PodcastEnabled reports whether the feeds of the channel are podcasts
+func (c *Channel) PodcastEnabled() bool {
+	return c.Podcast != nil && c.Podcast.Enabled
+}
+
+// Categories splits Category into the category and its subcategory
+func (p *Podcast) Categories() (string, string) {
+	category, subcategory, _ := strings.Cut(p.Category, ">")
+	return strings.TrimSpace(category), strings.TrimSpace(subcategory)
+}
+
+// Validate checks that the cover art is an http(s) URL and the owner email
+// an address
+func (p *Podcast) Validate() error {
+	if p.Image != "" {
+		image, err := url.Parse(p.Image)
+		if err != nil || (image.Scheme != "http" && image.Scheme != "https") || image.Host == "" {
+			return oops.With("image", p.Image).Wrapf(errors.ErrInvalidRequest, "podcast image must be an http(s) URL")
+		}
+	}
+	if p.Email != "" {
+		if _, err := mail.ParseAddress(p.Email); err != nil {
+			return oops.With("email", p.Email).Wrapf(errors.ErrInvalidRequest, "podcast email is not an address")
+		}
+	}
+	return nil
+}
+
+// Episode returns the media of a message published as its episode
+func Episode(msg *messageDomain.Message) (*messageDomain.Media, bool) {
+	for i := range msg.Media {
+		if slices.Contains(PodcastMediaTypes, msg.Media[i].Type) {
+			return &msg.Media[i], true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/samber/oops"
+)
+
+// RewriteRule changes the text of the feed items of a channel, such as
+// dropping the footer appended to every post. Rules are applied in order
+// when feeds are rendered; stored posts keep their original text.
+type RewriteRule struct {
+	Type RewriteType `json:"type"`
+	// Pattern is the regular expression a replace rule replaces, or the
+	// one trailing lines match to be stripped by strip_signature. Without
+	// it strip_signature strips trailing lines that mention the channel.
+	Pattern string `json:"pattern,omitempty"`
+	// Replacement of the matches of a replace rule, where $1 refers to the
+	// first group of Pattern
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// Validate checks that a rule has a known type and that its pattern
+// compiles. Replace rules need a pattern, link rules take none.
+func (r RewriteRule) Validate() error {
+	if !r.Type.IsValid() {
+		return oops.Wrapf(errors.ErrInvalidRequest, "unknown rewrite type %q, expected one of %s", r.Type, strings.Join(RewriteTypeNames(), ", "))
+	}
+	switch r.Type {
+	case RewriteTypeReplace:
+		if r.Pattern == "" {
+			return oops.Wrapf(errors.ErrInvalidRequest, "replace rule needs a pattern")
+		}
+	case RewriteTypeStripSignature:
+		if r.Replacement != "" {
+			return oops.Wrapf(errors.ErrInvalidRequest, "strip_signature rule takes no replacement")
+		}
+	case RewriteTypeStripUtm, RewriteTypeExpandLinks:
+		if r.Pattern != "" || r.Replacement != "" {
+			return oops.Wrapf(errors.ErrInvalidRequest, "%s rule takes no pattern", r.Type)
+		}
+	}
+	if _, err := regexp.Compile(r.Pattern); err != nil {
+		return oops.With("pattern", r.Pattern).Wrapf(errors.ErrInvalidRequest, "invalid rewrite pattern: %v", err)
+	}
+	return nil
+}
+
+// String describes the rule the way the bot lists it
+func (r RewriteRule) String() string {
+	switch {
+	case r.Type == RewriteTypeReplace:
+		return string(r.Type) + " " + r.Pattern + " => " + r.Replacement
+	case r.Pattern != "":
+		return string(r.Type) + " " + r.Pattern
+	}
+	return string(r.Type)
+}
@@ -0,0 +1,11 @@
+package domain
+
+import "github.com/reshetovitsme/rss-telegram-feed/internal/shared/schema"
+
+// ChannelSchema and CollectionSchema version stored channel and collection
+// records. Append an upgrade whenever a field change needs older records
+// converted.
+var (
+	ChannelSchema    = schema.New("channel")
+	CollectionSchema = schema.New("collection")
+)
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	appErrors "github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture %s: %v", name, err)
+	}
+	return data
+}
+
+func TestChannelSchemaLegacyRecord(t *testing.T) {
+	var channel Channel
+	version, err := ChannelSchema.Unmarshal(readFixture(t, "channel_v1.json"), &channel)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if version != 1 {
+		t.Errorf("version = %d, want 1", version)
+	}
+	if channel.ID != "-1001234567890" || channel.AddedBy != 1001 || len(channel.Filters) != 1 || !channel.IsActive {
+		t.Errorf("unexpected channel %+v", channel)
+	}
+}
+
+func TestCollectionSchemaLegacyRecord(t *testing.T) {
+	var collection Collection
+	version, err := CollectionSchema.Unmarshal(readFixture(t, "collection_v1.json"), &collection)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if version != 1 {
+		t.Errorf("version = %d, want 1", version)
+	}
+	if collection.Name != "tech" || !reflect.DeepEqual(collection.ChannelIDs, []string{"-1001234567890"}) || collection.AddedBy != 1001 {
+		t.Errorf("unexpected collection %+v", collection)
+	}
+}
+
+func TestChannelSchemaRoundTrip(t *testing.T) {
+	want := Channel{
+		ID:           "-1001234567890",
+		Username:     "news",
+		Title:        "News",
+		AddedBy:      1001,
+		AddedAt:      time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		SharedWith:   []int64{1002},
+		Filters:      []Filter{{Type: FilterTypeTags, Keywords: []string{"news"}, Enabled: true}},
+		DeletedPosts: DeletedPostPolicyMark,
+		Rewrites:     []RewriteRule{{Type: RewriteTypeStripSignature}},
+		IsActive:     true,
+	}
+	data, err := ChannelSchema.Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var got Channel
+	version, err := ChannelSchema.Unmarshal(data, &got)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if version != ChannelSchema.Version() || !reflect.DeepEqual(got, want) {
+		t.Errorf("got version %d, channel %+v; want version %d, channel %+v", version, got, ChannelSchema.Version(), want)
+	}
+}
+
+func TestChannelSchemaNewerVersion(t *testing.T) {
+	data := []byte(`{"schema_version": 99, "data": {"id": "-1001234567890"}}`)
+	var channel Channel
+	if _, err := ChannelSchema.Unmarshal(data, &channel); !errors.Is(err, appErrors.ErrSchemaVersion) {
+		t.Errorf("err = %v, want %v", err, appErrors.ErrSchemaVersion)
+	}
+}
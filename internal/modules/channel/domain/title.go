@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/samber/oops"
+)
+
+// TitlePlaceholders are the placeholders a title template may contain:
+// the title derived from the post, the channel title and username, and the
+// post date
+var TitlePlaceholders = []string{"{title}", "{channel}", "{username}", "{date}"}
+
+var placeholderPattern = regexp.MustCompile(`\{[a-z_]*\}`)
+
+// ValidateTitleTemplate checks that a title template only uses known
+// placeholders
+func ValidateTitleTemplate(template string) error {
+	for _, placeholder := range placeholderPattern.FindAllString(template, -1) {
+		if !slices.Contains(TitlePlaceholders, placeholder) {
+			return oops.With("placeholder", placeholder).Wrapf(errors.ErrInvalidRequest, "unknown title placeholder %s, expected one of %s", placeholder, strings.Join(TitlePlaceholders, ", "))
+		}
+	}
+	return nil
+}
+
+// ItemTitle applies the title template of the channel to the title derived
+// from a post; without a template the derived title is used as is
+func (c *Channel) ItemTitle(title string, date time.Time) string {
+	if c.TitleTemplate == "" {
+		return title
+	}
+	return strings.TrimSpace(strings.NewReplacer(
+		"{title}", title,
+		"{channel}", c.Title,
+		"{username}", c.Handle(),
+		"{date}", date.Format(time.DateOnly),
+	).Replace(c.TitleTemplate))
+}
+
+// Handle returns the @username of the channel, or its title for channels
+// without a public username
+func (c *Channel) Handle() string {
+	if c.Username == "" {
+		return c.Title
+	}
+	return "@" + c.Username
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/domain"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/fileutil"
+	"github.com/samber/oops"
+)
+
+func (s *FileStorage) SaveCollection(collection *domain.Collection) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := filepath.Join(s.collectionPath, collection.Name+".json")
+	data, err := domain.CollectionSchema.Marshal(collection)
+	if err != nil {
+		return oops.With("collection", collection.Name, "context", "failed to marshal collection").Wrap(err)
+	}
+
+	return fileutil.WriteFileAtomic(path, data, 0644)
+}
+
+func (s *FileStorage) GetCollection(name string) (*domain.Collection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	path := filepath.Join(s.collectionPath, name+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.ErrCollectionNotFound
+		}
+		return nil, oops.With("collection", name, "context", "failed to read collection").Wrap(err)
+	}
+
+	var collection domain.Collection
+	if _, err := domain.CollectionSchema.Unmarshal(data, &collection); err != nil {
+		return nil, oops.With("collection", name, "path", path).Wrap(err)
+	}
+
+	return &collection, nil
+}
+
+func (s *FileStorage) GetAllCollections() ([]*domain.Collection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries, err := os.ReadDir(s.collectionPath)
+	if err != nil {
+		return nil, oops.With("directory", s.collectionPath, "context", "failed to read collections directory").Wrap(err)
+	}
+
+	collections := make([]*domain.Collection, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		path := filepath.Join(s.collectionPath, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, oops.With("path", path, "context", "failed to read collection").Wrap(err)
+		}
+
+		var collection domain.Collection
+		if _, err := domain.CollectionSchema.Unmarshal(data, &collection); err != nil {
+			slog.Warn("Skipping corrupt collection record", "path", path, "error", err)
+			continue
+		}
+
+		collections = append(collections, &collection)
+	}
+
+	return collections, nil
+}
+
+func (s *FileStorage) DeleteCollection(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := filepath.Join(s.collectionPath, name+".json")
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return errors.ErrCollectionNotFound
+		}
+		return oops.With("collection", name, "context", "failed to delete collection").Wrap(err)
+	}
+
+	return nil
+}
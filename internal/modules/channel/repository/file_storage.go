@@ -1,21 +1,22 @@
 package repository
 
 import (
-	"encoding/json"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sync"
 
 	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/domain"
 	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
-	"github.com/samber/lo"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/fileutil"
 	"github.com/samber/oops"
 )
 
 // FileStorage implements channel.Repository using file system
 type FileStorage struct {
-	basePath string
-	mu       sync.RWMutex
+	basePath       string
+	collectionPath string
+	mu             sync.RWMutex
 }
 
 // NewFileStorage creates a new file-based channel repository
@@ -25,7 +26,12 @@ func NewFileStorage(basePath string) (Repository, error) {
 		return nil, oops.With("base_path", basePath, "context", "failed to create channels directory").Wrap(err)
 	}
 
-	return &FileStorage{basePath: channelPath}, nil
+	collectionPath := filepath.Join(basePath, "collections")
+	if err := os.MkdirAll(collectionPath, 0755); err != nil {
+		return nil, oops.With("base_path", basePath, "context", "failed to create collections directory").Wrap(err)
+	}
+
+	return &FileStorage{basePath: channelPath, collectionPath: collectionPath}, nil
 }
 
 func (s *FileStorage) SaveChannel(channel *domain.Channel) error {
@@ -33,12 +39,12 @@ func (s *FileStorage) SaveChannel(channel *domain.Channel) error {
 	defer s.mu.Unlock()
 
 	path := filepath.Join(s.basePath, channel.ID+".json")
-	data, err := json.MarshalIndent(channel, "", "  ")
+	data, err := domain.ChannelSchema.Marshal(channel)
 	if err != nil {
 		return oops.With("channel_id", channel.ID, "context", "failed to marshal channel").Wrap(err)
 	}
 
-	return os.WriteFile(path, data, 0644)
+	return fileutil.WriteFileAtomic(path, data, 0644)
 }
 
 func (s *FileStorage) GetChannel(channelID string) (*domain.Channel, error) {
@@ -55,8 +61,8 @@ func (s *FileStorage) GetChannel(channelID string) (*domain.Channel, error) {
 	}
 
 	var channel domain.Channel
-	if err := json.Unmarshal(data, &channel); err != nil {
-		return nil, oops.With("channel_id", channelID, "context", "failed to unmarshal channel").Wrap(err)
+	if _, err := domain.ChannelSchema.Unmarshal(data, &channel); err != nil {
+		return nil, oops.With("channel_id", channelID, "path", path).Wrap(err)
 	}
 
 	return &channel, nil
@@ -71,24 +77,28 @@ func (s *FileStorage) GetAllChannels() ([]*domain.Channel, error) {
 		return nil, oops.With("directory", s.basePath, "context", "failed to read channels directory").Wrap(err)
 	}
 
-	channels := lo.FilterMap(entries, func(entry os.DirEntry, _ int) (*domain.Channel, bool) {
+	channels := make([]*domain.Channel, 0, len(entries))
+	for _, entry := range entries {
 		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
-			return nil, false
+			continue
 		}
 
 		path := filepath.Join(s.basePath, entry.Name())
 		data, err := os.ReadFile(path)
 		if err != nil {
-			return nil, false
+			return nil, oops.With("path", path, "context", "failed to read channel").Wrap(err)
 		}
 
 		var channel domain.Channel
-		if err := json.Unmarshal(data, &channel); err != nil {
-			return nil, false
+		if _, err := domain.ChannelSchema.Unmarshal(data, &channel); err != nil {
+			// One corrupt record must not hide every other channel, the
+			// integrity scan reports it
+			slog.Warn("Skipping corrupt channel record", "path", path, "error", err)
+			continue
 		}
 
-		return &channel, true
-	})
+		channels = append(channels, &channel)
+	}
 
 	return channels, nil
 }
@@ -98,5 +108,12 @@ func (s *FileStorage) DeleteChannel(channelID string) error {
 	defer s.mu.Unlock()
 
 	path := filepath.Join(s.basePath, channelID+".json")
-	return os.Remove(path)
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return errors.ErrChannelNotFound
+		}
+		return oops.With("channel_id", channelID, "context", "failed to delete channel").Wrap(err)
+	}
+
+	return nil
 }
@@ -12,4 +12,9 @@ type Repository interface {
 	GetChannel(channelID string) (*domain.Channel, error)
 	GetAllChannels() ([]*domain.Channel, error)
 	DeleteChannel(channelID string) error
+
+	SaveCollection(collection *domain.Collection) error
+	GetCollection(name string) (*domain.Collection, error)
+	GetAllCollections() ([]*domain.Collection, error)
+	DeleteCollection(name string) error
 }
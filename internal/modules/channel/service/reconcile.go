@@ -0,0 +1,343 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/domain"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/config"
+	"github.com/samber/lo"
+)
+
+const (
+	driftKindChannel    = "channel"
+	driftKindCollection = "collection"
+)
+
+// ReconcileDeclared brings stored channels and collections in line with the
+// config file according to the configured reconcile mode and logs the result
+func (s *Service) ReconcileDeclared(ctx context.Context) *domain.ReconcileReport {
+	cfg := s.cfg.Get()
+	if cfg.ChannelsReconcile == domain.ReconcileModeOff {
+		return &domain.ReconcileReport{Drifts: []domain.Drift{}}
+	}
+
+	apply := cfg.ChannelsReconcile == domain.ReconcileModeApply
+	report := s.Reconcile(ctx, cfg.Channels, cfg.Collections, apply)
+
+	for _, drift := range report.Drifts {
+		if apply {
+			slog.Info("Reconciled declared state", "kind", drift.Kind, "id", drift.ID, "action", drift.Action, "fields", drift.Fields)
+		} else {
+			slog.Warn("Declared state drift", "kind", drift.Kind, "id", drift.ID, "action", drift.Action, "fields", drift.Fields)
+		}
+	}
+	for _, reconcileErr := range report.Errors {
+		slog.Error("Failed to reconcile declared state", "error", reconcileErr)
+	}
+	if report.InSync() {
+		slog.Debug("Declared channels are in sync")
+	}
+
+	return report
+}
+
+// Reconcile compares declared channels and collections with storage. With
+// apply set it creates, updates and deletes records to match; otherwise it
+// only reports drift. Only records previously created from the config are
+// deleted; channels added through the bot are never pruned.
+func (s *Service) Reconcile(ctx context.Context, channels []config.ChannelSpec, collections []config.CollectionSpec, apply bool) *domain.ReconcileReport {
+	report := &domain.ReconcileReport{Applied: apply, Drifts: []domain.Drift{}}
+	s.reconcileChannels(ctx, channels, apply, report)
+	s.reconcileCollections(collections, apply, report)
+	return report
+}
+
+func (s *Service) reconcileChannels(ctx context.Context, specs []config.ChannelSpec, apply bool, report *domain.ReconcileReport) {
+	stored, err := s.channelRepo.GetAllChannels()
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("load channels: %v", err))
+		return
+	}
+	storedByID := lo.KeyBy(stored, func(channel *domain.Channel) string { return channel.ID })
+
+	declared := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		id := spec.ID
+		if id == "" {
+			// Prefer a stored channel with the same username over a Bot API lookup
+			if channel, ok := lo.Find(stored, func(channel *domain.Channel) bool {
+				return strings.EqualFold(channel.Username, strings.TrimPrefix(spec.Username, "@"))
+			}); ok {
+				id = channel.ID
+			}
+		}
+		if id == "" {
+			resolved, err := s.ResolveChannel(ctx, spec.Username, spec.Owner)
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("resolve channel @%s: %v", strings.TrimPrefix(spec.Username, "@"), err))
+				continue
+			}
+			id = resolved.ID
+		}
+		declared[id] = true
+
+		existing, ok := storedByID[id]
+		if !ok {
+			channel := channelFromSpec(spec, id)
+			if channel.Title == "" {
+				channel.Title = channel.ID
+			}
+			report.Drifts = append(report.Drifts, domain.Drift{Kind: driftKindChannel, ID: id, Action: domain.DriftActionCreate})
+			if apply {
+				s.applyChannel(channel, report)
+			}
+			continue
+		}
+
+		desired := channelFromSpec(spec, id)
+		fields := diffChannel(existing, desired)
+		if len(fields) == 0 {
+			continue
+		}
+		report.Drifts = append(report.Drifts, domain.Drift{Kind: driftKindChannel, ID: id, Action: domain.DriftActionUpdate, Fields: fields})
+		if apply {
+			mergeChannel(existing, desired)
+			s.applyChannel(existing, report)
+		}
+	}
+
+	for _, channel := range stored {
+		if channel.Source != domain.SourceConfig || declared[channel.ID] {
+			continue
+		}
+		report.Drifts = append(report.Drifts, domain.Drift{Kind: driftKindChannel, ID: channel.ID, Action: domain.DriftActionDelete})
+		if apply {
+			if err := s.DeleteChannel(channel.ID); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("delete channel %s: %v", channel.ID, err))
+			}
+		}
+	}
+}
+
+func (s *Service) applyChannel(channel *domain.Channel, report *domain.ReconcileReport) {
+	if err := s.channelRepo.SaveChannel(channel); err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("save channel %s: %v", channel.ID, err))
+		return
+	}
+	if channel.IsActive {
+		s.AddChannel(channel.ID)
+	} else {
+		s.RemoveChannel(channel.ID)
+	}
+}
+
+func (s *Service) reconcileCollections(specs []config.CollectionSpec, apply bool, report *domain.ReconcileReport) {
+	stored, err := s.channelRepo.GetAllCollections()
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("load collections: %v", err))
+		return
+	}
+	storedByName := lo.KeyBy(stored, func(collection *domain.Collection) string { return collection.Name })
+
+	declared := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		declared[spec.Name] = true
+		desired := collectionFromSpec(spec)
+
+		existing, ok := storedByName[spec.Name]
+		if !ok {
+			report.Drifts = append(report.Drifts, domain.Drift{Kind: driftKindCollection, ID: spec.Name, Action: domain.DriftActionCreate})
+			if apply {
+				s.applyCollection(desired, report)
+			}
+			continue
+		}
+
+		fields := diffCollection(existing, desired)
+		if len(fields) == 0 {
+			continue
+		}
+		report.Drifts = append(report.Drifts, domain.Drift{Kind: driftKindCollection, ID: spec.Name, Action: domain.DriftActionUpdate, Fields: fields})
+		if apply {
+			// Collections created elsewhere stay unpruned, see mergeChannel
+			desired.AddedAt, desired.Source = existing.AddedAt, existing.Source
+			if spec.Owner == 0 {
+				desired.AddedBy = existing.AddedBy
+			}
+			s.applyCollection(desired, report)
+		}
+	}
+
+	for _, collection := range stored {
+		if collection.Source != domain.SourceConfig || declared[collection.Name] {
+			continue
+		}
+		report.Drifts = append(report.Drifts, domain.Drift{Kind: driftKindCollection, ID: collection.Name, Action: domain.DriftActionDelete})
+		if apply {
+			if err := s.channelRepo.DeleteCollection(collection.Name); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("delete collection %s: %v", collection.Name, err))
+			}
+		}
+	}
+}
+
+func (s *Service) applyCollection(collection *domain.Collection, report *domain.ReconcileReport) {
+	if err := s.channelRepo.SaveCollection(collection); err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("save collection %s: %v", collection.Name, err))
+	}
+}
+
+// channelFromSpec builds the declared state of a channel
+func channelFromSpec(spec config.ChannelSpec, id string) *domain.Channel {
+	username := strings.TrimPrefix(spec.Username, "@")
+	title := spec.Title
+	if title == "" {
+		title = username
+	}
+
+	feeds := make([]domain.FeedDefinition, 0, len(spec.Feeds))
+	for _, feed := range spec.Feeds {
+		feeds = append(feeds, domain.FeedDefinition{
+			Name:        feed.Name,
+			Title:       feed.Title,
+			Description: feed.Description,
+			Filters:     config.ToFilters(feed.Filters),
+			Limit:       feed.Limit,
+			MaxAgeDays:  feed.MaxAgeDays,
+		})
+	}
+
+	now := time.Now()
+	return &domain.Channel{
+		ID:         id,
+		Username:   username,
+		Title:      title,
+		AddedBy:    spec.Owner,
+		AddedAt:    now,
+		SharedWith: spec.SharedWith,
+		Filters:    config.ToFilters(spec.Filters),
+		Feeds:      feeds,
+		LastUpdate: now,
+		IsActive:   spec.Active == nil || *spec.Active,
+		Source:     domain.SourceConfig,
+	}
+}
+
+// diffChannel lists the declared fields of desired that differ from existing.
+// Empty username, title and owner are treated as undeclared.
+func diffChannel(existing, desired *domain.Channel) []string {
+	var fields []string
+	if desired.Username != "" && existing.Username != desired.Username {
+		fields = append(fields, "username")
+	}
+	if desired.Title != "" && existing.Title != desired.Title {
+		fields = append(fields, "title")
+	}
+	if desired.AddedBy != 0 && existing.AddedBy != desired.AddedBy {
+		fields = append(fields, "owner")
+	}
+	if !sameUserIDs(existing.SharedWith, desired.SharedWith) {
+		fields = append(fields, "shared_with")
+	}
+	if existing.IsActive != desired.IsActive {
+		fields = append(fields, "active")
+	}
+	if !sameFilters(existing.Filters, desired.Filters) {
+		fields = append(fields, "filters")
+	}
+	if !slices.EqualFunc(existing.Feeds, desired.Feeds, sameFeed) {
+		fields = append(fields, "feeds")
+	}
+	return fields
+}
+
+// mergeChannel copies the declared fields of desired onto existing, keeping
+// runtime state such as AddedAt and LastUpdate. Source is kept too: a
+// channel added through the bot and later declared stays unpruned when its
+// declaration is removed.
+func mergeChannel(existing, desired *domain.Channel) {
+	if desired.Username != "" {
+		existing.Username = desired.Username
+	}
+	if desired.Title != "" {
+		existing.Title = desired.Title
+	}
+	if desired.AddedBy != 0 {
+		existing.AddedBy = desired.AddedBy
+	}
+	existing.SharedWith = desired.SharedWith
+	existing.IsActive = desired.IsActive
+	existing.Filters = desired.Filters
+	existing.Feeds = desired.Feeds
+}
+
+// collectionFromSpec builds the declared state of a collection
+func collectionFromSpec(spec config.CollectionSpec) *domain.Collection {
+	return &domain.Collection{
+		Name:        spec.Name,
+		Title:       spec.Title,
+		Description: spec.Description,
+		ChannelIDs:  spec.Channels,
+		Filters:     config.ToFilters(spec.Filters),
+		Limit:       spec.Limit,
+		MaxAgeDays:  spec.MaxAgeDays,
+		AddedBy:     spec.Owner,
+		AddedAt:     time.Now(),
+		SharedWith:  spec.SharedWith,
+		Source:      domain.SourceConfig,
+	}
+}
+
+// diffCollection lists the declared fields of desired that differ from existing
+func diffCollection(existing, desired *domain.Collection) []string {
+	var fields []string
+	if existing.Title != desired.Title {
+		fields = append(fields, "title")
+	}
+	if existing.Description != desired.Description {
+		fields = append(fields, "description")
+	}
+	if !slices.Equal(existing.ChannelIDs, desired.ChannelIDs) {
+		fields = append(fields, "channels")
+	}
+	if !sameFilters(existing.Filters, desired.Filters) {
+		fields = append(fields, "filters")
+	}
+	if existing.Limit != desired.Limit {
+		fields = append(fields, "limit")
+	}
+	if existing.MaxAgeDays != desired.MaxAgeDays {
+		fields = append(fields, "max_age_days")
+	}
+	if desired.AddedBy != 0 && existing.AddedBy != desired.AddedBy {
+		fields = append(fields, "owner")
+	}
+	if !sameUserIDs(existing.SharedWith, desired.SharedWith) {
+		fields = append(fields, "shared_with")
+	}
+	return fields
+}
+
+func sameFilters(a, b []domain.Filter) bool {
+	return slices.EqualFunc(a, b, func(x, y domain.Filter) bool {
+		return x.Type == y.Type && x.Enabled == y.Enabled && slices.Equal(x.Keywords, y.Keywords)
+	})
+}
+
+func sameFeed(a, b domain.FeedDefinition) bool {
+	return a.Name == b.Name && a.Title == b.Title && a.Description == b.Description &&
+		a.Limit == b.Limit && a.MaxAgeDays == b.MaxAgeDays && sameFilters(a.Filters, b.Filters)
+}
+
+// sameUserIDs compares user ID lists ignoring order
+func sameUserIDs(a, b []int64) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
@@ -2,8 +2,11 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-telegram/bot"
@@ -12,12 +15,16 @@ import (
 	messageDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/domain"
 	messageRepo "github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/repository"
 	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/config"
+	"github.com/samber/lo"
 	"github.com/samber/oops"
 )
 
+// retentionCheckInterval is how often expired messages are pruned
+const retentionCheckInterval = time.Hour
+
 // Service handles channel business logic
 type Service struct {
-	cfg         *config.Config
+	cfg         *config.Store
 	channelRepo channelRepo.Repository
 	messageRepo messageRepo.Repository
 	bot         *bot.Bot
@@ -26,18 +33,23 @@ type Service struct {
 	ctx         context.Context
 	cancel      context.CancelFunc
 	wg          sync.WaitGroup
+	// intervalChanged wakes monitorLoop to pick up a new update interval
+	intervalChanged chan struct{}
+	// verifying is set while recent posts are checked for deletion
+	verifying atomic.Bool
 }
 
 // New creates a new channel service
-func New(cfg *config.Config, channelRepo channelRepo.Repository, messageRepo messageRepo.Repository) *Service {
+func New(cfg *config.Store, channelRepo channelRepo.Repository, messageRepo messageRepo.Repository) *Service {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Service{
-		cfg:         cfg,
-		channelRepo: channelRepo,
-		messageRepo: messageRepo,
-		channels:    make(map[string]bool),
-		ctx:         ctx,
-		cancel:      cancel,
+		cfg:             cfg,
+		channelRepo:     channelRepo,
+		messageRepo:     messageRepo,
+		channels:        make(map[string]bool),
+		ctx:             ctx,
+		cancel:          cancel,
+		intervalChanged: make(chan struct{}, 1),
 	}
 }
 
@@ -95,6 +107,27 @@ func (s *Service) GetAllChannels() ([]*domain.Channel, error) {
 	return s.channelRepo.GetAllChannels()
 }
 
+// GetChannelsAccessibleBy retrieves the channels a user added or that were shared with them
+func (s *Service) GetChannelsAccessibleBy(userID int64) ([]*domain.Channel, error) {
+	channels, err := s.channelRepo.GetAllChannels()
+	if err != nil {
+		return nil, err
+	}
+	return lo.Filter(channels, func(channel *domain.Channel, _ int) bool {
+		return channel.AccessibleBy(userID)
+	}), nil
+}
+
+// GetCollection retrieves a collection by name
+func (s *Service) GetCollection(name string) (*domain.Collection, error) {
+	return s.channelRepo.GetCollection(name)
+}
+
+// GetAllCollections retrieves all collections
+func (s *Service) GetAllCollections() ([]*domain.Collection, error) {
+	return s.channelRepo.GetAllCollections()
+}
+
 // SaveChannel saves a channel
 func (s *Service) SaveChannel(channel *domain.Channel) error {
 	return s.channelRepo.SaveChannel(channel)
@@ -106,23 +139,44 @@ func (s *Service) DeleteChannel(channelID string) error {
 	return s.channelRepo.DeleteChannel(channelID)
 }
 
-// ProcessMessage processes a message from a channel
-func (s *Service) ProcessMessage(channel *domain.Channel, msgText string, msgDate int64, msgID int64, author string, media []messageDomain.Media, link string) error {
-	// Apply filters
-	if !s.passesFilters(channel, msgText) {
-		return nil
+// ResolveChannel looks up a public channel by username through the Bot API
+// and returns an unsaved channel record for it
+func (s *Service) ResolveChannel(ctx context.Context, username string, addedBy int64) (*domain.Channel, error) {
+	if s.bot == nil {
+		return nil, oops.Errorf("bot not initialized")
+	}
+
+	username = strings.TrimPrefix(username, "@")
+	chat, err := s.bot.GetChat(ctx, &bot.GetChatParams{
+		ChatID: "@" + username,
+	})
+	if err != nil {
+		return nil, oops.With("username", username, "context", "failed to get channel info").Wrap(err)
 	}
 
-	// Convert to message domain
-	message := &messageDomain.Message{
-		ID:          msgID,
-		ChannelID:   channel.ID,
-		ChannelName: channel.Title,
-		Text:        msgText,
-		Date:        time.Unix(msgDate, 0),
-		Author:      author,
-		Media:       media,
-		Link:        link,
+	return &domain.Channel{
+		ID:         fmt.Sprintf("%d", chat.ID),
+		Username:   username,
+		Title:      chat.Title,
+		AddedBy:    addedBy,
+		AddedAt:    time.Now(),
+		Filters:    []domain.Filter{},
+		LastUpdate: time.Now(),
+		IsActive:   true,
+	}, nil
+}
+
+// ProcessMessage processes a message from a channel.
+// Every post is stored so that filters can be applied at read time;
+// only when FilterOnIngest is enabled are non-matching posts dropped here.
+// The channel ID and name of the message are taken from channel.
+func (s *Service) ProcessMessage(channel *domain.Channel, message *messageDomain.Message) error {
+	message.ChannelID = channel.ID
+	message.ChannelName = channel.Title
+
+	// Drop filtered messages before saving only if configured to do so
+	if s.cfg.Get().FilterOnIngest && !domain.PassesFilters(channel.Filters, message) {
+		return nil
 	}
 
 	// Save message
@@ -139,53 +193,20 @@ func (s *Service) ProcessMessage(channel *domain.Channel, msgText string, msgDat
 	return nil
 }
 
-// passesFilters checks if a message passes all enabled filters
-func (s *Service) passesFilters(channel *domain.Channel, text string) bool {
-	if len(channel.Filters) == 0 {
-		return true
-	}
-
-	for _, filter := range channel.Filters {
-		if !filter.Enabled {
-			continue
-		}
-
-		switch filter.Type {
-		case domain.FilterTypeKeywords:
-			// Check if any keyword is present
-			matches := false
-			for _, keyword := range filter.Keywords {
-				if contains(text, keyword) {
-					matches = true
-					break
-				}
-			}
-			if !matches {
-				return false
-			}
-		case domain.FilterTypeExcludeKeywords:
-			// Check if any exclude keyword is present
-			for _, keyword := range filter.Keywords {
-				if contains(text, keyword) {
-					return false
-				}
-			}
-		case domain.FilterTypeAuthor:
-			// Author filtering can be implemented here if needed
-		}
-	}
-
-	return true
-}
-
 func (s *Service) monitorLoop() {
 	defer s.wg.Done()
 
-	ticker := time.NewTicker(time.Duration(s.cfg.UpdateInterval) * time.Second)
+	ticker := time.NewTicker(time.Duration(s.cfg.Get().UpdateInterval) * time.Second)
 	defer ticker.Stop()
+	retention := time.NewTicker(retentionCheckInterval)
+	defer retention.Stop()
+	verification := time.NewTicker(deletionCheckInterval)
+	defer verification.Stop()
 
 	// Initial check
 	s.checkChannels()
+	s.pruneMessages()
+	s.verifyMessages()
 
 	for {
 		select {
@@ -193,6 +214,53 @@ func (s *Service) monitorLoop() {
 			return
 		case <-ticker.C:
 			s.checkChannels()
+		case <-retention.C:
+			s.pruneMessages()
+		case <-verification.C:
+			s.verifyMessages()
+		case <-s.intervalChanged:
+			ticker.Reset(time.Duration(s.cfg.Get().UpdateInterval) * time.Second)
+		}
+	}
+}
+
+// ApplyConfig reacts to a configuration reload: it reschedules monitoring,
+// applies a new retention period and reconciles declared channels
+func (s *Service) ApplyConfig(previous, current *config.Config) {
+	if previous.UpdateInterval != current.UpdateInterval {
+		select {
+		case s.intervalChanged <- struct{}{}:
+		default:
+		}
+	}
+	if previous.RetentionDays != current.RetentionDays {
+		s.pruneMessages()
+	}
+	s.ReconcileDeclared(s.ctx)
+}
+
+// pruneMessages deletes messages older than the configured retention period
+func (s *Service) pruneMessages() {
+	retention := s.cfg.Get().Retention()
+	if retention <= 0 {
+		return
+	}
+
+	channels, err := s.channelRepo.GetAllChannels()
+	if err != nil {
+		slog.Error("Failed to load channels for retention", "error", err)
+		return
+	}
+
+	cutoff := time.Now().Add(-retention)
+	for _, channel := range channels {
+		deleted, err := s.messageRepo.DeleteMessagesBefore(channel.ID, cutoff)
+		if err != nil {
+			slog.Error("Failed to prune messages", "channel_id", channel.ID, "error", err)
+			continue
+		}
+		if deleted > 0 {
+			slog.Info("Pruned expired messages", "channel_id", channel.ID, "deleted", deleted)
 		}
 	}
 }
@@ -242,33 +310,3 @@ func (s *Service) fetchChannelMessages(channelID string) error {
 
 	return nil
 }
-
-// Helper functions
-func contains(text, keyword string) bool {
-	return len(keyword) > 0 && len(text) >= len(keyword) &&
-		(text == keyword || containsSubstring(text, keyword))
-}
-
-func containsSubstring(text, substr string) bool {
-	textLower := toLower(text)
-	substrLower := toLower(substr)
-	for i := 0; i <= len(textLower)-len(substrLower); i++ {
-		if textLower[i:i+len(substrLower)] == substrLower {
-			return true
-		}
-	}
-	return false
-}
-
-func toLower(s string) string {
-	result := make([]byte, len(s))
-	for i := 0; i < len(s); i++ {
-		c := s[i]
-		if c >= 'A' && c <= 'Z' {
-			result[i] = c + 32
-		} else {
-			result[i] = c
-		}
-	}
-	return string(result)
-}
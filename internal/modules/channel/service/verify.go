@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/domain"
+	messageDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/domain"
+	"github.com/samber/oops"
+)
+
+const (
+	// deletionCheckInterval is how often recent posts are checked for deletion
+	deletionCheckInterval = 30 * time.Minute
+	// deletionCheckDelay spaces out the Bot API calls of a check to stay
+	// well below Telegram's rate limits
+	deletionCheckDelay = 200 * time.Millisecond
+)
+
+// existenceChecker reports whether a post still exists in its channel.
+// The Bot API cannot read channel history, so checkers probe posts by
+// sending them to a scratch chat and deleting the copy again.
+type existenceChecker func(ctx context.Context, b *bot.Bot, chatID int64, channelID string, messageID int64) (bool, error)
+
+// existenceCheckers are the strategies deletion_check selects from
+var existenceCheckers = map[domain.DeletionCheck]existenceChecker{
+	domain.DeletionCheckCopy:    copyCheck,
+	domain.DeletionCheckForward: forwardCheck,
+}
+
+// copyCheck copies the post to the scratch chat. Copies do not show where
+// they came from, so the scratch chat stays uncluttered by forward headers.
+func copyCheck(ctx context.Context, b *bot.Bot, chatID int64, channelID string, messageID int64) (bool, error) {
+	copied, err := b.CopyMessage(ctx, &bot.CopyMessageParams{
+		ChatID:              chatID,
+		FromChatID:          channelID,
+		MessageID:           int(messageID),
+		DisableNotification: true,
+	})
+	if err != nil {
+		return probeResult(err)
+	}
+	deleteProbe(ctx, b, chatID, copied.ID)
+	return true, nil
+}
+
+// forwardCheck forwards the post to the scratch chat, for channels whose
+// posts cannot be copied
+func forwardCheck(ctx context.Context, b *bot.Bot, chatID int64, channelID string, messageID int64) (bool, error) {
+	forwarded, err := b.ForwardMessage(ctx, &bot.ForwardMessageParams{
+		ChatID:              chatID,
+		FromChatID:          channelID,
+		MessageID:           int(messageID),
+		DisableNotification: true,
+	})
+	if err != nil {
+		return probeResult(err)
+	}
+	deleteProbe(ctx, b, chatID, forwarded.ID)
+	return true, nil
+}
+
+// probeResult tells a missing post apart from a failed probe
+func probeResult(err error) (bool, error) {
+	if errors.Is(err, bot.ErrorBadRequest) {
+		reason := strings.ToLower(err.Error())
+		if strings.Contains(reason, "message to copy not found") ||
+			strings.Contains(reason, "message to forward not found") ||
+			strings.Contains(reason, "message_id_invalid") {
+			return false, nil
+		}
+	}
+	return false, err
+}
+
+func deleteProbe(ctx context.Context, b *bot.Bot, chatID int64, messageID int) {
+	if _, err := b.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: messageID}); err != nil {
+		slog.Warn("Failed to delete deletion check message", "chat_id", chatID, "message_id", messageID, "error", err)
+	}
+}
+
+// verifyMessages checks the recent posts of every active channel and
+// tombstones those deleted from Telegram. A run is skipped while the
+// previous one is still going.
+func (s *Service) verifyMessages() {
+	cfg := s.cfg.Get()
+	check, ok := existenceCheckers[cfg.DeletionCheck]
+	if !ok || s.bot == nil || !s.verifying.CompareAndSwap(false, true) {
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.verifying.Store(false)
+
+		channels, err := s.channelRepo.GetAllChannels()
+		if err != nil {
+			slog.Error("Failed to load channels for deletion check", "error", err)
+			return
+		}
+
+		since := time.Now().Add(-time.Duration(cfg.DeletionCheckHours) * time.Hour)
+		for _, channel := range channels {
+			if !channel.IsActive {
+				continue
+			}
+			deleted, err := s.verifyChannel(s.ctx, check, cfg.DeletionCheckChat, channel, since)
+			if s.ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				slog.Error("Failed to check channel for deleted posts", "channel_id", channel.ID, "error", err)
+				continue
+			}
+			if deleted > 0 {
+				slog.Info("Found deleted posts", "channel_id", channel.ID, "deleted", deleted)
+			}
+		}
+	}()
+}
+
+// verifyChannel checks the posts of a channel stored since the given time
+// and returns how many were tombstoned
+func (s *Service) verifyChannel(ctx context.Context, check existenceChecker, chatID int64, channel *domain.Channel, since time.Time) (int, error) {
+	messages, err := s.messageRepo.GetRecentMessages(channel.ID, since)
+	if err != nil {
+		return 0, oops.With("channel_id", channel.ID, "context", "failed to get recent messages").Wrap(err)
+	}
+
+	deleted := 0
+	for _, message := range messages {
+		if message.DeletedAt != nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return deleted, ctx.Err()
+		case <-time.After(deletionCheckDelay):
+		}
+
+		exists, err := check(ctx, s.bot, chatID, channel.ID, message.ID)
+		if err != nil {
+			return deleted, oops.With("channel_id", channel.ID, "message_id", message.ID, "context", "failed to check message").Wrap(err)
+		}
+		if exists {
+			continue
+		}
+
+		if err := s.tombstone(message); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// tombstone marks a message deleted from its channel
+func (s *Service) tombstone(message *messageDomain.Message) error {
+	now := time.Now()
+	message.DeletedAt = &now
+	if err := s.messageRepo.SaveMessage(message); err != nil {
+		return oops.With("channel_id", message.ChannelID, "message_id", message.ID, "context", "failed to save tombstone").Wrap(err)
+	}
+	slog.Info("Post deleted from channel", "channel_id", message.ChannelID, "message_id", message.ID)
+	return nil
+}
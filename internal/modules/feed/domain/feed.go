@@ -1,6 +1,11 @@
 package domain
 
-import "time"
+import (
+	"time"
+
+	"github.com/gorilla/feeds"
+	channelDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/domain"
+)
 
 // FeedConfig represents RSS feed configuration
 type FeedConfig struct {
@@ -9,3 +14,47 @@ type FeedConfig struct {
 	Link      string    `json:"link"`
 	Updated   time.Time `json:"updated"`
 }
+
+// Feed is a generated feed together with the tags gorilla/feeds has no
+// fields for
+type Feed struct {
+	*feeds.Feed
+	// Podcast adds the iTunes channel tags, nil for plain feeds
+	Podcast *channelDomain.Podcast
+	// Extensions holds the extra tags of items, keyed by item ID
+	Extensions map[string]*ItemExtension
+}
+
+// ItemExtension holds the extra tags of a feed item
+type ItemExtension struct {
+	// Duration of the podcast episode
+	Duration time.Duration
+	// Thumbnails are the scaled-down images of the item, smallest first
+	Thumbnails []Thumbnail
+	// Categories are the tags of the item
+	Categories []string
+}
+
+// Thumbnail is a preview image of a feed item
+type Thumbnail struct {
+	URL   string
+	Width int
+}
+
+// NewFeed wraps a feed without extensions
+func NewFeed(feed *feeds.Feed) *Feed {
+	return &Feed{
+		Feed:       feed,
+		Extensions: map[string]*ItemExtension{},
+	}
+}
+
+// Extend returns the extension of an item, creating it when missing
+func (f *Feed) Extend(item *feeds.Item) *ItemExtension {
+	extension, ok := f.Extensions[item.Id]
+	if !ok {
+		extension = &ItemExtension{}
+		f.Extensions[item.Id] = extension
+	}
+	return extension
+}
@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"encoding/xml"
+	"strconv"
+
+	"github.com/gorilla/feeds"
+)
+
+const (
+	itunesNamespace   = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+	mediaRSSNamespace = "http://search.yahoo.com/mrss/"
+)
+
+type rssDocument struct {
+	XMLName          xml.Name    `xml:"rss"`
+	Version          string      `xml:"version,attr"`
+	ContentNamespace string      `xml:"xmlns:content,attr"`
+	ITunesNamespace  string      `xml:"xmlns:itunes,attr,omitempty"`
+	MediaNamespace   string      `xml:"xmlns:media,attr,omitempty"`
+	Channel          *rssChannel `xml:"channel"`
+}
+
+// rssChannel extends the gorilla/feeds channel, its Items replace the
+// embedded ones
+type rssChannel struct {
+	*feeds.RssFeed
+	ITunesAuthor   string          `xml:"itunes:author,omitempty"`
+	ITunesImage    *itunesImage    `xml:"itunes:image"`
+	ITunesCategory *itunesCategory `xml:"itunes:category"`
+	ITunesExplicit string          `xml:"itunes:explicit,omitempty"`
+	ITunesOwner    *itunesOwner    `xml:"itunes:owner"`
+	ITunesType     string          `xml:"itunes:type,omitempty"`
+	Items          []*rssItem      `xml:"item"`
+}
+
+// rssItem extends the gorilla/feeds item, its Categories replace the
+// embedded single category
+type rssItem struct {
+	*feeds.RssItem
+	Categories     []string         `xml:"category"`
+	ITunesDuration string           `xml:"itunes:duration,omitempty"`
+	Thumbnails     []mediaThumbnail `xml:"media:thumbnail"`
+}
+
+type mediaThumbnail struct {
+	URL   string `xml:"url,attr"`
+	Width int    `xml:"width,attr,omitempty"`
+}
+
+type itunesImage struct {
+	Href string `xml:"href,attr"`
+}
+
+type itunesCategory struct {
+	Text        string          `xml:"text,attr"`
+	Subcategory *itunesCategory `xml:"itunes:category"`
+}
+
+type itunesOwner struct {
+	Name  string `xml:"itunes:name,omitempty"`
+	Email string `xml:"itunes:email"`
+}
+
+// ToRss renders the feed as RSS 2.0 with its extension tags
+func (f *Feed) ToRss() (string, error) {
+	return feeds.ToXML(f)
+}
+
+// FeedXml implements feeds.XmlFeed
+func (f *Feed) FeedXml() interface{} {
+	base := (&feeds.Rss{Feed: f.Feed}).RssFeed()
+	channel := &rssChannel{RssFeed: base}
+	document := &rssDocument{
+		Version:          "2.0",
+		ContentNamespace: "http://purl.org/rss/1.0/modules/content/",
+		Channel:          channel,
+	}
+
+	for i, item := range base.Items {
+		rendered := &rssItem{RssItem: item}
+		if extension, ok := f.Extensions[f.Items[i].Id]; ok {
+			rendered.Categories = extension.Categories
+			if extension.Duration > 0 {
+				rendered.ITunesDuration = strconv.Itoa(int(extension.Duration.Seconds()))
+			}
+			for _, thumbnail := range extension.Thumbnails {
+				rendered.Thumbnails = append(rendered.Thumbnails, mediaThumbnail{URL: thumbnail.URL, Width: thumbnail.Width})
+			}
+			if len(rendered.Thumbnails) > 0 {
+				document.MediaNamespace = mediaRSSNamespace
+			}
+		}
+		channel.Items = append(channel.Items, rendered)
+	}
+
+	if podcast := f.Podcast; podcast != nil {
+		document.ITunesNamespace = itunesNamespace
+		base.Language = podcast.Language
+		channel.ITunesAuthor = podcast.Author
+		channel.ITunesExplicit = strconv.FormatBool(podcast.Explicit)
+		channel.ITunesType = "episodic"
+		if podcast.Image != "" {
+			channel.ITunesImage = &itunesImage{Href: podcast.Image}
+		}
+		if category, subcategory := podcast.Categories(); category != "" {
+			channel.ITunesCategory = &itunesCategory{Text: category}
+			if subcategory != "" {
+				channel.ITunesCategory.Subcategory = &itunesCategory{Text: subcategory}
+			}
+		}
+		if podcast.Email != "" {
+			channel.ITunesOwner = &itunesOwner{Name: podcast.Author, Email: podcast.Email}
+		}
+	}
+	return document
+}
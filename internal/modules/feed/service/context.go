@@ -0,0 +1,71 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/domain"
+)
+
+// renderForward credits the source of a forwarded post
+func renderForward(origin *domain.ForwardOrigin) string {
+	name := escapeHTML(origin.Name)
+	if link := origin.Link(); domain.SafeLink(link) {
+		name = fmt.Sprintf(`<a href="%s">%s</a>`, escapeHTML(link), name)
+	}
+	return fmt.Sprintf("<p><em>Forwarded from %s</em></p>", name)
+}
+
+// renderReply quotes the post a message replies to: the quoted part when
+// the author chose one, otherwise the start of the replied-to post
+func renderReply(reply *domain.Reply) string {
+	label := "In reply to"
+	if reply.Source != "" {
+		label += " " + escapeHTML(reply.Source)
+	}
+	if domain.SafeLink(reply.Link) {
+		label = fmt.Sprintf(`<a href="%s">%s</a>`, escapeHTML(reply.Link), label)
+	}
+
+	text := reply.Quote
+	if text == "" {
+		text = reply.Text
+	}
+	if text == "" {
+		return fmt.Sprintf("<p><em>%s</em></p>", label)
+	}
+	return fmt.Sprintf("<blockquote><p><em>%s</em></p><p>%s</p></blockquote>", label, escapeHTML(text))
+}
+
+// renderLinkPreview links the URL previewed under a post. Links stored
+// before their scheme was checked are shown as text only.
+func renderLinkPreview(url string) string {
+	if !domain.SafeLink(url) {
+		return fmt.Sprintf(`<p>🔗 %s</p>`, escapeHTML(url))
+	}
+	return fmt.Sprintf(`<p>🔗 <a href="%s">%s</a></p>`, escapeHTML(url), escapeHTML(url))
+}
+
+// describeContext describes the forward source and reply target of a
+// message in plain text, one per line
+func describeContext(msg *domain.Message) string {
+	var lines []string
+	if msg.Forward != nil {
+		lines = append(lines, strings.TrimSpace("Forwarded from "+msg.Forward.Name+" "+msg.Forward.Link()))
+	}
+	if msg.Reply != nil {
+		quote := msg.Reply.Quote
+		if quote == "" {
+			quote = msg.Reply.Text
+		}
+		line := "In reply to"
+		if msg.Reply.Source != "" {
+			line += " " + msg.Reply.Source
+		}
+		if quote != "" {
+			line += fmt.Sprintf(": %q", quote)
+		}
+		lines = append(lines, strings.TrimSpace(line+" "+msg.Reply.Link))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/domain"
+)
+
+// renderGallery renders the media of a message as HTML through the media
+// proxy. Photos and videos are shown inline, other files are linked, and
+// the parts of an album are grouped into one gallery. Photos are shown as
+// thumbnails of thumbnailSizes, letting readers pick one for the screen.
+// Files evicted from the media archive are replaced by a placeholder
+// pointing to the post in Telegram.
+func renderGallery(msg *domain.Message, baseURL string, thumbnailSizes []int, evicted map[string]bool) string {
+	var html strings.Builder
+	if len(msg.Media) > 1 {
+		html.WriteString(`<div class="gallery">`)
+	}
+	for _, item := range msg.Media {
+		if evicted[item.FileID] {
+			html.WriteString(renderEvicted(item, msg.Link))
+			continue
+		}
+		html.WriteString(renderMedia(item, baseURL, thumbnailSizes))
+	}
+	if len(msg.Media) > 1 {
+		html.WriteString(`</div>`)
+	}
+	return html.String()
+}
+
+func renderMedia(media domain.Media, baseURL string, thumbnailSizes []int) string {
+	src := escapeHTML(media.PublicURL(baseURL))
+	caption := escapeHTML(media.Caption)
+	poster := ""
+	if media.Thumbnail != "" && len(thumbnailSizes) > 0 {
+		poster = fmt.Sprintf(` poster="%s"`, escapeHTML(media.ThumbnailURL(baseURL, thumbnailSizes[len(thumbnailSizes)-1])))
+	}
+
+	var body string
+	switch media.Type {
+	case domain.MediaTypePhoto:
+		body = fmt.Sprintf(`<a href="%s">%s</a>`, src, renderImage(media, baseURL, thumbnailSizes, caption))
+	case domain.MediaTypeVideo, domain.MediaTypeVideoNote:
+		body = fmt.Sprintf(`<video src="%s"%s controls preload="metadata"><a href="%s">%s</a></video>`, src, poster, src, mediaLabel(media))
+	case domain.MediaTypeAnimation:
+		body = fmt.Sprintf(`<video src="%s"%s autoplay loop muted playsinline><a href="%s">%s</a></video>`, src, poster, src, mediaLabel(media))
+	case domain.MediaTypeAudio, domain.MediaTypeVoice:
+		body = fmt.Sprintf(`<audio src="%s" controls preload="metadata"><a href="%s">%s</a></audio><br>%s`, src, src, mediaLabel(media), mediaLabel(media))
+	case domain.MediaTypeSticker:
+		body = renderSticker(media, src)
+	case domain.MediaTypePoll:
+		body = renderPoll(media.Poll)
+	case domain.MediaTypeLocation:
+		body = fmt.Sprintf(`<a href="%s">📍 %s</a>`, escapeHTML(media.Location.MapURL()), formatCoordinates(*media.Location))
+	case domain.MediaTypeVenue:
+		body = fmt.Sprintf(`<a href="%s">📍 %s</a><br>%s`, escapeHTML(media.Venue.Location.MapURL()), escapeHTML(media.Venue.Title), escapeHTML(media.Venue.Address))
+	case domain.MediaTypeContact:
+		body = fmt.Sprintf(`👤 %s, <a href="tel:%s">%s</a>`, escapeHTML(media.Contact.Name()), escapeHTML(media.Contact.PhoneNumber), escapeHTML(media.Contact.PhoneNumber))
+	case domain.MediaTypeDice:
+		body = fmt.Sprintf("%s %d", escapeHTML(media.Dice.Emoji), media.Dice.Value)
+	case domain.MediaTypePaidMedia:
+		body = fmt.Sprintf("⭐ Paid media for %d stars, available in Telegram", media.Stars)
+	default:
+		body = fmt.Sprintf(`<a href="%s">%s</a>`, src, mediaLabel(media))
+	}
+
+	if caption == "" {
+		return fmt.Sprintf("<figure>%s</figure>", body)
+	}
+	return fmt.Sprintf("<figure>%s<figcaption>%s</figcaption></figure>", body, caption)
+}
+
+// renderEvicted stands in for a media file removed from the archive
+func renderEvicted(media domain.Media, link string) string {
+	return fmt.Sprintf(`<figure>🗄 %s is no longer archived, <a href="%s">view it in Telegram</a></figure>`, escapeHTML(mediaLabel(media)), escapeHTML(link))
+}
+
+// renderImage shows a photo. With thumbnails, the largest is the fallback
+// source and all of them are offered in srcset; the link keeps the full size.
+func renderImage(media domain.Media, baseURL string, thumbnailSizes []int, alt string) string {
+	if media.Thumbnail == "" || len(thumbnailSizes) == 0 {
+		return fmt.Sprintf(`<img src="%s" alt="%s" loading="lazy">`, escapeHTML(media.PublicURL(baseURL)), alt)
+	}
+
+	candidates := make([]string, 0, len(thumbnailSizes))
+	for _, width := range thumbnailSizes {
+		candidates = append(candidates, fmt.Sprintf("%s %dw", media.ThumbnailURL(baseURL, width), width))
+	}
+	largest := thumbnailSizes[len(thumbnailSizes)-1]
+	return fmt.Sprintf(`<img src="%s" srcset="%s" sizes="(max-width: %dpx) 100vw, %dpx" alt="%s" loading="lazy">`,
+		escapeHTML(media.ThumbnailURL(baseURL, largest)), escapeHTML(strings.Join(candidates, ", ")), largest, largest, alt)
+}
+
+// renderSticker shows a sticker as an image; animated stickers cannot be
+// displayed by feed readers and fall back to their emoji
+func renderSticker(media domain.Media, src string) string {
+	switch media.MimeType {
+	case "video/webm":
+		return fmt.Sprintf(`<video src="%s" autoplay loop muted playsinline>%s</video>`, src, escapeHTML(media.Emoji))
+	case "application/x-tgsticker":
+		return fmt.Sprintf("%s Sticker", escapeHTML(media.Emoji))
+	}
+	return fmt.Sprintf(`<img src="%s" alt="%s" width="128" loading="lazy">`, src, escapeHTML(media.Emoji))
+}
+
+func renderPoll(poll *domain.Poll) string {
+	var html strings.Builder
+	kind := "Poll"
+	if poll.Quiz {
+		kind = "Quiz"
+	}
+	if poll.Closed {
+		kind += ", closed"
+	}
+	html.WriteString(fmt.Sprintf("<p><strong>📊 %s</strong> (%s)</p><ul>", escapeHTML(poll.Question), kind))
+	for _, option := range poll.Options {
+		html.WriteString(fmt.Sprintf("<li>%s — %s</li>", escapeHTML(option.Text), formatVotes(option.Voters, poll.Voters)))
+	}
+	html.WriteString("</ul>")
+	return html.String()
+}
+
+// describeMedia describes a media item in one line of plain text
+func describeMedia(media domain.Media, baseURL string) string {
+	switch media.Type {
+	case domain.MediaTypePoll:
+		options := make([]string, 0, len(media.Poll.Options))
+		for _, option := range media.Poll.Options {
+			options = append(options, fmt.Sprintf("%s: %s", option.Text, formatVotes(option.Voters, media.Poll.Voters)))
+		}
+		return fmt.Sprintf("poll: %s (%s)", media.Poll.Question, strings.Join(options, "; "))
+	case domain.MediaTypeLocation:
+		return fmt.Sprintf("location: %s %s", formatCoordinates(*media.Location), media.Location.MapURL())
+	case domain.MediaTypeVenue:
+		return fmt.Sprintf("venue: %s, %s %s", media.Venue.Title, media.Venue.Address, media.Venue.Location.MapURL())
+	case domain.MediaTypeContact:
+		return fmt.Sprintf("contact: %s, %s", media.Contact.Name(), media.Contact.PhoneNumber)
+	case domain.MediaTypeDice:
+		return fmt.Sprintf("dice: %s %d", media.Dice.Emoji, media.Dice.Value)
+	case domain.MediaTypePaidMedia:
+		return fmt.Sprintf("paid media: %d stars", media.Stars)
+	}
+	return fmt.Sprintf("%s: %s", media.Type, media.PublicURL(baseURL))
+}
+
+// mediaLabel names a file for links and players, with its duration or size
+func mediaLabel(media domain.Media) string {
+	label := media.Title
+	if label == "" {
+		label = media.FileName
+	}
+	if label == "" {
+		label = map[domain.MediaType]string{
+			domain.MediaTypePhoto:     "Photo",
+			domain.MediaTypeSticker:   "Sticker",
+			domain.MediaTypeVideo:     "Video",
+			domain.MediaTypeVideoNote: "Video message",
+			domain.MediaTypeAnimation: "GIF",
+			domain.MediaTypeAudio:     "Audio",
+			domain.MediaTypeVoice:     "Voice message",
+		}[media.Type]
+	}
+	if label == "" {
+		label = "Document"
+	}
+
+	switch {
+	case media.Duration > 0:
+		label += fmt.Sprintf(" (%d:%02d)", media.Duration/60, media.Duration%60)
+	case media.FileSize > 0:
+		label += fmt.Sprintf(" (%s)", formatSize(media.FileSize))
+	}
+	return escapeHTML(label)
+}
+
+func formatVotes(votes int, total int) string {
+	noun := "votes"
+	if votes == 1 {
+		noun = "vote"
+	}
+	if total == 0 {
+		return fmt.Sprintf("%d %s", votes, noun)
+	}
+	return fmt.Sprintf("%d %s (%d%%)", votes, noun, votes*100/total)
+}
+
+func formatCoordinates(location domain.Location) string {
+	return fmt.Sprintf("%.5f, %.5f", location.Latitude, location.Longitude)
+}
+
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	size, exponent := float64(bytes)/unit, 0
+	for size >= unit && exponent < 3 {
+		size /= unit
+		exponent++
+	}
+	return fmt.Sprintf("%.1f %cB", size, "KMGT"[exponent])
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	channelDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/domain"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/domain"
+)
+
+var (
+	// linkPattern finds the links of a text that strip_utm cleans up
+	linkPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+	// shortLinkPattern finds t.me links written without a scheme or on
+	// the telegram.me and telegram.dog mirrors
+	shortLinkPattern = regexp.MustCompile(`(?i)(^|[^\w.@/-])(?:https?://)?(?:www\.)?(?:t|telegram)\.(?:me|dog)/`)
+)
+
+// rewritePatterns caches the compiled patterns of rewrite rules, which are
+// applied to every item of every feed request
+var rewritePatterns sync.Map
+
+// rewriteMessage applies the rewrite rules of a channel to a copy of a
+// message, leaving the stored message untouched. channel may be nil when
+// it is no longer stored.
+func rewriteMessage(channel *channelDomain.Channel, msg *domain.Message) *domain.Message {
+	if channel == nil || len(channel.Rewrites) == 0 {
+		return msg
+	}
+
+	rewritten := *msg
+	rewritten.Text = rewriteText(channel, msg.Text)
+	rewritten.Media = slices.Clone(msg.Media)
+	for i := range rewritten.Media {
+		rewritten.Media[i].Caption = rewriteText(channel, rewritten.Media[i].Caption)
+	}
+	if slices.ContainsFunc(channel.Rewrites, func(rule channelDomain.RewriteRule) bool {
+		return rule.Type == channelDomain.RewriteTypeStripUtm
+	}) {
+		rewritten.LinkPreview = stripUTM(msg.LinkPreview)
+	}
+	return &rewritten
+}
+
+// rewriteText applies the rules of a channel to a text in order
+func rewriteText(channel *channelDomain.Channel, text string) string {
+	if text == "" {
+		return text
+	}
+	for _, rule := range channel.Rewrites {
+		switch rule.Type {
+		case channelDomain.RewriteTypeReplace:
+			if pattern := rewritePattern(channel, rule.Pattern); pattern != nil {
+				text = pattern.ReplaceAllString(text, rule.Replacement)
+			}
+		case channelDomain.RewriteTypeStripSignature:
+			if pattern := signaturePattern(channel, rule); pattern != nil {
+				text = stripSignature(text, pattern)
+			}
+		case channelDomain.RewriteTypeStripUtm:
+			text = linkPattern.ReplaceAllStringFunc(text, stripUTM)
+		case channelDomain.RewriteTypeExpandLinks:
+			text = shortLinkPattern.ReplaceAllString(text, "${1}https://t.me/")
+		}
+	}
+	return text
+}
+
+// rewritePattern compiles a rule pattern once. Rules are validated when
+// saved, so a pattern that fails to compile is only logged and skipped.
+func rewritePattern(channel *channelDomain.Channel, expr string) *regexp.Regexp {
+	if cached, ok := rewritePatterns.Load(expr); ok {
+		return cached.(*regexp.Regexp)
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		slog.Warn("Skipping invalid rewrite pattern", "channel_id", channel.ID, "pattern", expr, "error", err)
+		return nil
+	}
+	rewritePatterns.Store(expr, pattern)
+	return pattern
+}
+
+// signaturePattern returns the pattern of the signature lines a rule
+// strips: its own, or mentions of the channel by default
+func signaturePattern(channel *channelDomain.Channel, rule channelDomain.RewriteRule) *regexp.Regexp {
+	if rule.Pattern != "" {
+		return rewritePattern(channel, rule.Pattern)
+	}
+	if channel.Username == "" {
+		return nil
+	}
+	return rewritePattern(channel, `(?i)(?:@|t\.me/)`+regexp.QuoteMeta(channel.Username)+`\b`)
+}
+
+// stripSignature removes the trailing lines of a text that match the
+// signature pattern, along with the blank lines and separators such as
+// "———" between them. The first line is always kept so a post that is
+// nothing but a mention of the channel does not lose its text.
+func stripSignature(text string, pattern *regexp.Regexp) string {
+	lines := strings.Split(strings.TrimRightFunc(text, unicode.IsSpace), "\n")
+	stripped := false
+	for len(lines) > 1 {
+		line := strings.TrimSpace(lines[len(lines)-1])
+		if line != "" && !pattern.MatchString(line) && (!stripped || hasText(line)) {
+			break
+		}
+		stripped = stripped || line != ""
+		lines = lines[:len(lines)-1]
+	}
+	if !stripped {
+		return text
+	}
+	return strings.TrimRightFunc(strings.Join(lines, "\n"), unicode.IsSpace)
+}
+
+// hasText reports whether a line has letters or digits rather than only
+// separators and emoji
+func hasText(line string) bool {
+	return strings.IndexFunc(line, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}) >= 0
+}
+
+// stripUTM removes the utm_* tracking parameters from the query of a link,
+// keeping the order of the other parameters and any punctuation the link
+// was followed by in the text
+func stripUTM(link string) string {
+	trimmed := strings.TrimRight(link, ".,;:!?)")
+	trailing := link[len(trimmed):]
+
+	base, rest, ok := strings.Cut(trimmed, "?")
+	if !ok {
+		return link
+	}
+	query, fragment, hasFragment := strings.Cut(rest, "#")
+
+	params := strings.Split(query, "&")
+	kept := slices.DeleteFunc(slices.Clone(params), func(param string) bool {
+		return strings.HasPrefix(strings.ToLower(param), "utm_")
+	})
+	if len(kept) == len(params) {
+		return link
+	}
+
+	if len(kept) > 0 {
+		base += "?" + strings.Join(kept, "&")
+	}
+	if hasFragment {
+		base += "#" + fragment
+	}
+	return base + trailing
+}
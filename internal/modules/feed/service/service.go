@@ -1,61 +1,353 @@
 package service
 
 import (
+	"cmp"
 	"fmt"
 	"log/slog"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/feeds"
+	channelDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/domain"
 	channelRepo "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/repository"
+	feedDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/feed/domain"
+	mediaService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/media/service"
 	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/domain"
 	messageRepo "github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/repository"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/config"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/samber/lo"
 	"github.com/samber/oops"
 )
 
+const (
+	// defaultFeedLimit is the number of items in a feed without an explicit limit
+	defaultFeedLimit = 50
+	// maxFeedLimit caps the per-feed item limit
+	maxFeedLimit = 500
+)
+
 // Service handles RSS feed generation
 type Service struct {
+	cfg         *config.Store
 	channelRepo channelRepo.Repository
 	messageRepo messageRepo.Repository
+	media       *mediaService.Service
 }
 
 // New creates a new feed service
-func New(channelRepo channelRepo.Repository, messageRepo messageRepo.Repository) *Service {
+func New(cfg *config.Store, channelRepo channelRepo.Repository, messageRepo messageRepo.Repository, media *mediaService.Service) *Service {
 	return &Service{
+		cfg:         cfg,
 		channelRepo: channelRepo,
 		messageRepo: messageRepo,
+		media:       media,
 	}
 }
 
-// GenerateFeed generates an RSS feed for a channel
-func (s *Service) GenerateFeed(channelID string, baseURL string) (*feeds.Feed, error) {
+// GenerateFeed generates the default RSS feed for a channel
+func (s *Service) GenerateFeed(channelID string, baseURL string) (*feedDomain.Feed, error) {
 	channel, err := s.channelRepo.GetChannel(channelID)
 	if err != nil {
 		return nil, oops.With("channel_id", channelID, "context", "channel not found").Wrap(err)
 	}
 
-	messages, err := s.messageRepo.GetMessages(channelID, 50) // Get last 50 messages
+	definition := &channelDomain.FeedDefinition{
+		Filters: channel.Filters,
+	}
+	return s.buildFeed(channel, definition, fmt.Sprintf("%s/rss/%s", baseURL, channel.ID), baseURL)
+}
+
+// GenerateNamedFeed generates the RSS feed for a named feed definition of a channel
+func (s *Service) GenerateNamedFeed(channelID string, feedName string, baseURL string) (*feedDomain.Feed, error) {
+	channel, err := s.channelRepo.GetChannel(channelID)
+	if err != nil {
+		return nil, oops.With("channel_id", channelID, "context", "channel not found").Wrap(err)
+	}
+
+	definition, ok := channel.FindFeed(feedName)
+	if !ok {
+		return nil, oops.With("channel_id", channelID, "feed_name", feedName).Wrap(errors.ErrFeedNotFound)
+	}
+
+	return s.buildFeed(channel, definition, fmt.Sprintf("%s/rss/%s/%s", baseURL, channel.ID, definition.Name), baseURL)
+}
+
+// GenerateCollectionFeed generates the RSS feed merging the channels of a collection
+func (s *Service) GenerateCollectionFeed(name string, baseURL string) (*feedDomain.Feed, error) {
+	collection, err := s.channelRepo.GetCollection(name)
+	if err != nil {
+		return nil, oops.With("collection", name, "context", "collection not found").Wrap(err)
+	}
+
+	definition := collection.Definition()
+	limit, since := feedBounds(definition)
+
+	channels, err := s.collectionChannels(collection)
+	if err != nil {
+		return nil, oops.With("collection", name, "context", "failed to load channels").Wrap(err)
+	}
+
+	messages, err := s.mergeMessages(channels, func(*channelDomain.Channel) []channelDomain.Filter {
+		return definition.Filters
+	}, limit, since)
+	if err != nil {
+		return nil, oops.With("collection", name).Wrap(err)
+	}
+
+	title := fmt.Sprintf("%s - RSS Feed", collection.Name)
+	if collection.Title != "" {
+		title = collection.Title
+	}
+	description := fmt.Sprintf("RSS feed for collection: %s", collection.Name)
+	if collection.Description != "" {
+		description = collection.Description
+	}
+
+	feed := feedDomain.NewFeed(&feeds.Feed{
+		Title:       title,
+		Link:        &feeds.Link{Href: fmt.Sprintf("%s/rss/collection/%s", baseURL, collection.Name)},
+		Description: description,
+		Created:     collection.AddedAt,
+	})
+	for _, channel := range channels {
+		if channel.LastUpdate.After(feed.Updated) {
+			feed.Updated = channel.LastUpdate
+		}
+	}
+
+	byID := lo.KeyBy(channels, func(channel *channelDomain.Channel) string { return channel.ID })
+	for _, msg := range messages {
+		s.addItem(feed, byID[msg.ChannelID], msg, baseURL)
+	}
+	return feed, nil
+}
+
+// GenerateSearchFeed generates the RSS feed of messages matching a search
+// query. Items are ordered newest first, so subscribing to the feed shows
+// new matching posts.
+func (s *Service) GenerateSearchFeed(query string, messages []*domain.Message, baseURL string) *feedDomain.Feed {
+	messages = slices.Clone(messages)
+	slices.SortStableFunc(messages, func(a, b *domain.Message) int {
+		return b.Date.Compare(a.Date)
+	})
+
+	feed := feedDomain.NewFeed(&feeds.Feed{
+		Title:       fmt.Sprintf("Search: %s - RSS Feed", query),
+		Link:        &feeds.Link{Href: fmt.Sprintf("%s/rss/search?q=%s", baseURL, url.QueryEscape(query))},
+		Description: fmt.Sprintf("Telegram posts matching: %s", query),
+		Created:     time.Now(),
+	})
+	channels := map[string]*channelDomain.Channel{}
+	for _, msg := range messages {
+		if msg.Date.After(feed.Updated) {
+			feed.Updated = msg.Date
+		}
+		channel, ok := channels[msg.ChannelID]
+		if !ok {
+			// Items of channels that are gone keep their plain titles
+			var err error
+			if channel, err = s.channelRepo.GetChannel(msg.ChannelID); err != nil {
+				channel = nil
+			}
+			channels[msg.ChannelID] = channel
+		}
+		if msg.DeletedAt != nil && (channel == nil || channel.HidesDeleted()) {
+			continue
+		}
+		s.addItem(feed, channel, msg, baseURL)
+	}
+	return feed
+}
+
+// mergeMessages collects the messages of several channels that pass the
+// filters of each, newest first across channels, keeping the overall limit
+func (s *Service) mergeMessages(channels []*channelDomain.Channel, filters func(*channelDomain.Channel) []channelDomain.Filter, limit int, since time.Time) ([]*domain.Message, error) {
+	var messages []*domain.Message
+	for _, channel := range channels {
+		channelMessages, err := s.collectMessages(channel, filters(channel), limit, since)
+		if err != nil {
+			return nil, oops.With("channel_id", channel.ID, "context", "failed to get messages").Wrap(err)
+		}
+		messages = append(messages, channelMessages...)
+	}
+
+	slices.SortStableFunc(messages, func(a, b *domain.Message) int {
+		return b.Date.Compare(a.Date)
+	})
+	if len(messages) > limit {
+		messages = messages[:limit]
+	}
+	return messages, nil
+}
+
+// collectionChannels resolves the channel references of a collection. A
+// reference is a channel ID or an @username of a stored channel; unknown
+// references are skipped.
+func (s *Service) collectionChannels(collection *channelDomain.Collection) ([]*channelDomain.Channel, error) {
+	var stored []*channelDomain.Channel
+	var channels []*channelDomain.Channel
+	for _, ref := range collection.ChannelIDs {
+		if username, ok := strings.CutPrefix(ref, "@"); ok {
+			if stored == nil {
+				var err error
+				if stored, err = s.channelRepo.GetAllChannels(); err != nil {
+					return nil, err
+				}
+			}
+			for _, channel := range stored {
+				if strings.EqualFold(channel.Username, username) {
+					channels = append(channels, channel)
+					break
+				}
+			}
+			continue
+		}
+
+		channel, err := s.channelRepo.GetChannel(ref)
+		if err != nil {
+			slog.Warn("Collection references unknown channel", "collection", collection.Name, "channel", ref)
+			continue
+		}
+		channels = append(channels, channel)
+	}
+	return channels, nil
+}
+
+// feedBounds returns the item limit and the oldest allowed post date of a feed
+func feedBounds(definition *channelDomain.FeedDefinition) (int, time.Time) {
+	limit := definition.Limit
+	if limit <= 0 {
+		limit = defaultFeedLimit
+	}
+	limit = min(limit, maxFeedLimit)
+
+	var since time.Time
+	if definition.MaxAgeDays > 0 {
+		since = time.Now().AddDate(0, 0, -definition.MaxAgeDays)
+	}
+	return limit, since
+}
+
+func (s *Service) buildFeed(channel *channelDomain.Channel, definition *channelDomain.FeedDefinition, feedURL string, baseURL string) (*feedDomain.Feed, error) {
+	limit, since := feedBounds(definition)
+
+	filters := definition.Filters
+	if channel.PodcastEnabled() {
+		// Only posts with an episode belong to a podcast
+		filters = append(slices.Clone(filters), podcastFilter())
+	}
+
+	messages, err := s.collectMessages(channel, filters, limit, since)
 	if err != nil {
-		return nil, oops.With("channel_id", channelID, "context", "failed to get messages").Wrap(err)
+		return nil, oops.With("channel_id", channel.ID, "feed_name", definition.Name, "context", "failed to get messages").Wrap(err)
+	}
+
+	title := fmt.Sprintf("%s - RSS Feed", channel.Title)
+	if definition.Name != "" {
+		title = fmt.Sprintf("%s (%s) - RSS Feed", channel.Title, definition.Name)
+	}
+	if definition.Title != "" {
+		title = definition.Title
 	}
 
-	feed := &feeds.Feed{
-		Title:       fmt.Sprintf("%s - RSS Feed", channel.Title),
-		Link:        &feeds.Link{Href: fmt.Sprintf("%s/rss/%s", baseURL, channel.ID)},
-		Description: fmt.Sprintf("RSS feed for Telegram channel: %s", channel.Title),
+	description := fmt.Sprintf("RSS feed for Telegram channel: %s", channel.Title)
+	if definition.Description != "" {
+		description = definition.Description
+	}
+
+	feed := feedDomain.NewFeed(&feeds.Feed{
+		Title:       title,
+		Link:        &feeds.Link{Href: feedURL},
+		Description: description,
 		Author:      &feeds.Author{Name: channel.Username},
 		Created:     channel.AddedAt,
 		Updated:     channel.LastUpdate,
+	})
+	if channel.PodcastEnabled() {
+		feed.Podcast = channel.Podcast
+		if channel.Podcast.Image != "" {
+			feed.Image = &feeds.Image{Url: channel.Podcast.Image, Title: title, Link: feedURL}
+		}
 	}
 
-	var items []*feeds.Item
 	for _, msg := range messages {
-		item := s.messageToFeedItem(msg, baseURL)
-		items = append(items, item)
+		item := s.addItem(feed, channel, msg, baseURL)
+		if channel.PodcastEnabled() {
+			addEpisode(feed, channel, item, msg, baseURL)
+		}
 	}
-
-	feed.Items = items
 	return feed, nil
 }
 
+// podcastFilter keeps the posts with an episode
+func podcastFilter() channelDomain.Filter {
+	keywords := make([]string, 0, len(channelDomain.PodcastMediaTypes))
+	for _, mediaType := range channelDomain.PodcastMediaTypes {
+		keywords = append(keywords, mediaType.String())
+	}
+	return channelDomain.Filter{
+		Type:     channelDomain.FilterTypeMedia,
+		Keywords: keywords,
+		Enabled:  true,
+	}
+}
+
+// addEpisode attaches the audio of a post to its item as the enclosure
+// of a podcast episode
+func addEpisode(feed *feedDomain.Feed, channel *channelDomain.Channel, item *feeds.Item, msg *domain.Message, baseURL string) {
+	episode, ok := channelDomain.Episode(msg)
+	if !ok {
+		return
+	}
+
+	// Episodes of posts without text are named after the audio metadata
+	if textTitle(msg.Text) == "" {
+		item.Title = channel.ItemTitle(cmp.Or(episode.Title, episode.FileName, fmt.Sprintf("Episode of %s", msg.Date.Format(time.DateOnly))), msg.Date)
+	}
+	item.Enclosure = &feeds.Enclosure{
+		Url:    episode.PublicURL(baseURL),
+		Length: strconv.FormatInt(episode.FileSize, 10),
+		Type:   cmp.Or(episode.MimeType, episodeMimeTypes[episode.Type]),
+	}
+	feed.Extend(item).Duration = time.Duration(episode.Duration) * time.Second
+}
+
+// episodeMimeTypes are the MIME types Telegram uses when a file has none
+var episodeMimeTypes = map[domain.MediaType]string{
+	domain.MediaTypeAudio: "audio/mpeg",
+	domain.MediaTypeVoice: "audio/ogg",
+}
+
+// collectMessages returns up to limit of the newest messages that pass the filters
+// and are not older than since (a zero since means no age limit).
+// Filtering happens here rather than at ingest so that filter changes apply
+// retroactively to everything already stored. Posts deleted from the channel
+// are left out unless the channel marks them instead.
+func (s *Service) collectMessages(channel *channelDomain.Channel, filters []channelDomain.Filter, limit int, since time.Time) ([]*domain.Message, error) {
+	var messages []*domain.Message
+	err := s.messageRepo.IterateMessages(channel.ID, func(msg *domain.Message) bool {
+		if !since.IsZero() && msg.Date.Before(since) {
+			return false
+		}
+		if msg.DeletedAt != nil && channel.HidesDeleted() {
+			return true
+		}
+		if channelDomain.PassesFilters(filters, msg) {
+			messages = append(messages, msg)
+		}
+		return len(messages) < limit
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
 // UpdateFeed triggers feed regeneration (placeholder for future caching)
 func (s *Service) UpdateFeed(channelID string) error {
 	// This method can be used to trigger feed regeneration
@@ -64,7 +356,33 @@ func (s *Service) UpdateFeed(channelID string) error {
 	return nil
 }
 
-func (s *Service) messageToFeedItem(msg *domain.Message, baseURL string) *feeds.Item {
+// addItem appends a message of channel to the feed, rewritten by the rules
+// of the channel, along with its tags as categories and the thumbnails of
+// its first media that has one. channel may be nil when it is no longer
+// stored.
+func (s *Service) addItem(feed *feedDomain.Feed, channel *channelDomain.Channel, msg *domain.Message, baseURL string) *feeds.Item {
+	sizes := s.cfg.Get().ThumbnailSizes
+	msg = rewriteMessage(channel, msg)
+	item := s.messageToFeedItem(channel, msg, baseURL, sizes)
+	for _, tag := range msg.Tags {
+		extension := feed.Extend(item)
+		extension.Categories = append(extension.Categories, strings.TrimPrefix(tag, "#"))
+	}
+	for _, media := range msg.Media {
+		if media.Thumbnail == "" || s.media.Evicted(media.FileID) {
+			continue
+		}
+		for _, width := range sizes {
+			extension := feed.Extend(item)
+			extension.Thumbnails = append(extension.Thumbnails, feedDomain.Thumbnail{URL: media.ThumbnailURL(baseURL, width), Width: width})
+		}
+		break
+	}
+	feed.Items = append(feed.Items, item)
+	return item
+}
+
+func (s *Service) messageToFeedItem(channel *channelDomain.Channel, msg *domain.Message, baseURL string, thumbnailSizes []int) *feeds.Item {
 	description := msg.Text
 	if description == "" {
 		description = "No text content"
@@ -74,41 +392,68 @@ func (s *Service) messageToFeedItem(msg *domain.Message, baseURL string) *feeds.
 	if len(msg.Media) > 0 {
 		description += "\n\nMedia:\n"
 		for _, media := range msg.Media {
-			description += fmt.Sprintf("- %s: %s\n", media.Type, media.FileID)
+			description += fmt.Sprintf("- %s\n", describeMedia(media, baseURL))
 			if media.Caption != "" {
 				description += fmt.Sprintf("  Caption: %s\n", media.Caption)
 			}
 		}
 	}
 
+	if msg.LinkPreview != "" {
+		description += fmt.Sprintf("\n\nLink: %s", msg.LinkPreview)
+	}
+	if context := describeContext(msg); context != "" {
+		description = context + "\n\n" + description
+	}
+
 	// Build content with HTML formatting for better RSS client compatibility
-	content := fmt.Sprintf("<p>%s</p>", escapeHTML(description))
-	if len(msg.Media) > 0 {
-		content += "<p><strong>Media attachments:</strong></p><ul>"
-		for _, media := range msg.Media {
-			content += fmt.Sprintf("<li>%s: %s</li>", media.Type, media.FileID)
-		}
-		content += "</ul>"
+	content := ""
+	if msg.DeletedAt != nil {
+		content += fmt.Sprintf("<p><strong>🗑 Deleted from the channel on %s</strong></p>", msg.DeletedAt.Format(time.DateOnly))
+	}
+	if msg.Forward != nil {
+		content += renderForward(msg.Forward)
+	}
+	if msg.Reply != nil {
+		content += renderReply(msg.Reply)
+	}
+	if msg.Text != "" || len(msg.Media) == 0 {
+		content += fmt.Sprintf("<p>%s</p>", escapeHTML(cmp.Or(msg.Text, "No text content")))
+	}
+	content += renderGallery(msg, baseURL, thumbnailSizes, s.evictedMedia(msg.Media))
+	if msg.LinkPreview != "" {
+		content += renderLinkPreview(msg.LinkPreview)
 	}
 
 	item := &feeds.Item{
-		Title:       truncate(msg.Text, 100),
+		Title:       itemTitle(channel, msg),
 		Link:        &feeds.Link{Href: msg.Link},
 		Description: description,
 		Content:     content,
 		Author:      &feeds.Author{Name: msg.Author},
 		Created:     msg.Date,
+		// The GUID stays stable when the post is edited or re-stored
 		Id:          fmt.Sprintf("%s-%d", msg.ChannelID, msg.ID),
+		IsPermaLink: "false",
+	}
+
+	if msg.DeletedAt != nil {
+		item.Title = "[Deleted] " + item.Title
 	}
 
 	return item
 }
 
-func truncate(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
+// evictedMedia returns the file IDs of media the local archive evicted,
+// which can no longer be served once Telegram expires them
+func (s *Service) evictedMedia(media []domain.Media) map[string]bool {
+	evicted := map[string]bool{}
+	for _, m := range media {
+		if m.FileID != "" && s.media.Evicted(m.FileID) {
+			evicted[m.FileID] = true
+		}
 	}
-	return s[:maxLen] + "..."
+	return evicted
 }
 
 func escapeHTML(s string) string {
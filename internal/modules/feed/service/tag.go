@@ -0,0 +1,87 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/gorilla/feeds"
+	channelDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/domain"
+	feedDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/feed/domain"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/domain"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/samber/lo"
+	"github.com/samber/oops"
+)
+
+// GenerateTagFeed generates the RSS feed of the posts of a channel tagged
+// with a hashtag or cashtag. The filters of the default feed still apply.
+func (s *Service) GenerateTagFeed(channelID string, tag string, baseURL string) (*feedDomain.Feed, error) {
+	if domain.NormalizeTag(tag) == "" {
+		return nil, oops.With("tag", tag).Wrap(errors.ErrFeedNotFound)
+	}
+
+	channel, err := s.channelRepo.GetChannel(channelID)
+	if err != nil {
+		return nil, oops.With("channel_id", channelID, "context", "channel not found").Wrap(err)
+	}
+
+	definition := &channelDomain.FeedDefinition{
+		Title:       fmt.Sprintf("%s %s - RSS Feed", channel.Title, displayTag(tag)),
+		Description: fmt.Sprintf("Posts of Telegram channel %s tagged %s", channel.Title, displayTag(tag)),
+		Filters:     append(slices.Clone(channel.Filters), tagFilter(tag)),
+	}
+	return s.buildFeed(channel, definition, fmt.Sprintf("%s/rss/%s/tag/%s", baseURL, channel.ID, url.PathEscape(tag)), baseURL)
+}
+
+// GenerateCrossTagFeed generates the RSS feed of the posts of the given
+// channels tagged with a hashtag or cashtag, following a topic rather than a
+// channel. The caller passes the channels the reader may see. The filters of
+// each channel's default feed still apply.
+func (s *Service) GenerateCrossTagFeed(tag string, channels []*channelDomain.Channel, baseURL string) (*feedDomain.Feed, error) {
+	if domain.NormalizeTag(tag) == "" {
+		return nil, oops.With("tag", tag).Wrap(errors.ErrFeedNotFound)
+	}
+
+	filter := tagFilter(tag)
+	messages, err := s.mergeMessages(channels, func(channel *channelDomain.Channel) []channelDomain.Filter {
+		return append(slices.Clone(channel.Filters), filter)
+	}, defaultFeedLimit, time.Time{})
+	if err != nil {
+		return nil, oops.With("tag", tag).Wrap(err)
+	}
+
+	feed := feedDomain.NewFeed(&feeds.Feed{
+		Title:       fmt.Sprintf("%s - RSS Feed", displayTag(tag)),
+		Link:        &feeds.Link{Href: fmt.Sprintf("%s/rss/tag/%s", baseURL, url.PathEscape(tag))},
+		Description: fmt.Sprintf("Telegram posts tagged %s", displayTag(tag)),
+		Created:     time.Now(),
+	})
+	byID := lo.KeyBy(channels, func(channel *channelDomain.Channel) string { return channel.ID })
+	for _, msg := range messages {
+		if msg.Date.After(feed.Updated) {
+			feed.Updated = msg.Date
+		}
+		s.addItem(feed, byID[msg.ChannelID], msg, baseURL)
+	}
+	return feed, nil
+}
+
+// tagFilter keeps the posts tagged with tag
+func tagFilter(tag string) channelDomain.Filter {
+	return channelDomain.Filter{
+		Type:     channelDomain.FilterTypeTags,
+		Keywords: []string{tag},
+		Enabled:  true,
+	}
+}
+
+// displayTag shows a tag from a URL as it appears in posts, so "news"
+// becomes "#news" while cashtags such as "$TON" keep their form
+func displayTag(tag string) string {
+	if tag[0] == '#' || tag[0] == '$' {
+		return tag
+	}
+	return "#" + tag
+}
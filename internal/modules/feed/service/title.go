@@ -0,0 +1,144 @@
+package service
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	channelDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/domain"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/domain"
+)
+
+// maxTitleLength is the length in runes item titles are cut to
+const maxTitleLength = 100
+
+var (
+	markdownLink     = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	markdownEmphasis = regexp.MustCompile("\\*\\*|__|~~|`")
+	// markdownPrefix matches headings, quotes and list markers
+	markdownPrefix = regexp.MustCompile(`^(#{1,6}\s+|>\s*|[-*•]\s+|\d+[.)]\s+)+`)
+	sentenceEnd    = regexp.MustCompile(`[.!?…](\s|$)`)
+)
+
+// itemTitle derives the title of a feed item from its post: the first
+// sentence of the first line with text, without markdown or leading emoji.
+// Posts without text are named after their media. The title template of
+// the channel is applied last; channel may be nil.
+func itemTitle(channel *channelDomain.Channel, msg *domain.Message) string {
+	title := textTitle(msg.Text)
+	if title == "" {
+		title = mediaTitle(channel, msg)
+	}
+	if channel == nil {
+		return title
+	}
+	return channel.ItemTitle(title, msg.Date)
+}
+
+// textTitle returns the first sentence of the first line that has text
+// once markdown and emoji are stripped, cut to maxTitleLength runes
+func textTitle(text string) string {
+	for line := range strings.Lines(text) {
+		line = stripMarkdown(strings.TrimSpace(line))
+		line = strings.TrimLeftFunc(line, isDecoration)
+		if line == "" {
+			continue
+		}
+		if loc := sentenceEnd.FindStringIndex(line); loc != nil && loc[1] < len(line) {
+			line = strings.TrimSpace(line[:loc[1]])
+		}
+		return strings.TrimSuffix(truncateWords(line, maxTitleLength), ".")
+	}
+	return ""
+}
+
+// stripMarkdown removes the markdown formatting of a line, keeping the
+// text of links
+func stripMarkdown(line string) string {
+	line = markdownPrefix.ReplaceAllString(line, "")
+	line = markdownLink.ReplaceAllString(line, "$1")
+	return strings.TrimSpace(markdownEmphasis.ReplaceAllString(line, ""))
+}
+
+// isDecoration reports whether a rune leading a title carries no text:
+// emoji, symbols, punctuation, spaces and the joiners between emoji
+func isDecoration(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '"' && r != '«' && r != '('
+}
+
+// truncateWords cuts s to at most maxLen runes at a word boundary and
+// marks the cut with an ellipsis
+func truncateWords(s string, maxLen int) string {
+	if utf8.RuneCountInString(s) <= maxLen {
+		return s
+	}
+	runes := []rune(s)[:maxLen]
+	cut := string(runes)
+	if i := strings.LastIndexFunc(cut, unicode.IsSpace); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRightFunc(cut, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}) + "…"
+}
+
+// mediaTitle names a post without text after its media, such as
+// "Photo from @channel"
+func mediaTitle(channel *channelDomain.Channel, msg *domain.Message) string {
+	if len(msg.Media) == 0 {
+		return withSource("Post", channel)
+	}
+
+	media := msg.Media[0]
+	switch media.Type {
+	case domain.MediaTypePoll:
+		if media.Poll != nil {
+			return truncateWords(media.Poll.Question, maxTitleLength)
+		}
+	case domain.MediaTypeVenue:
+		if media.Venue != nil {
+			return truncateWords(media.Venue.Title, maxTitleLength)
+		}
+	case domain.MediaTypeAudio:
+		if media.Title != "" {
+			return truncateWords(media.Title, maxTitleLength)
+		}
+	case domain.MediaTypeDocument:
+		if media.FileName != "" {
+			return truncateWords(media.FileName, maxTitleLength)
+		}
+	}
+
+	if len(msg.Media) > 1 {
+		return withSource("Album", channel)
+	}
+	return withSource(cmp.Or(mediaNames[media.Type], "Post"), channel)
+}
+
+func withSource(label string, channel *channelDomain.Channel) string {
+	if channel == nil {
+		return label
+	}
+	return fmt.Sprintf("%s from %s", label, channel.Handle())
+}
+
+// mediaNames are the names of media types in titles
+var mediaNames = map[domain.MediaType]string{
+	domain.MediaTypePhoto:     "Photo",
+	domain.MediaTypeVideo:     "Video",
+	domain.MediaTypeDocument:  "Document",
+	domain.MediaTypeAudio:     "Audio",
+	domain.MediaTypeVoice:     "Voice message",
+	domain.MediaTypeVideoNote: "Video message",
+	domain.MediaTypeAnimation: "GIF",
+	domain.MediaTypeSticker:   "Sticker",
+	domain.MediaTypePoll:      "Poll",
+	domain.MediaTypeLocation:  "Location",
+	domain.MediaTypeVenue:     "Venue",
+	domain.MediaTypeContact:   "Contact",
+	domain.MediaTypeDice:      "Dice",
+	domain.MediaTypePaidMedia: "Paid media",
+}
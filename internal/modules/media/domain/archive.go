@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// ArchiveIndexFile is the name of the archive index in the media directory
+const ArchiveIndexFile = "index.json"
+
+// ArchivedFile records a media file downloaded into the local archive. The
+// content is stored once per Hash, shared by every file ID with the same
+// bytes. Evicted files stay in the index so feeds can tell them apart from
+// files that were never archived.
+type ArchivedFile struct {
+	FileID      string    `json:"file_id"`
+	ChannelID   string    `json:"channel_id"`
+	Hash        string    `json:"hash"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type,omitempty"`
+	StoredAt    time.Time `json:"stored_at"`
+	LastAccess  time.Time `json:"last_access"`
+	Evicted     bool      `json:"evicted,omitempty"`
+}
@@ -0,0 +1,8 @@
+//go:generate go run github.com/abice/go-enum --file=$GOFILE --names --nocase
+
+package domain
+
+// EvictionPolicy picks which archived files are removed first when a media
+// quota is exceeded: the least recently served (lru) or the oldest stored (age)
+// ENUM(lru,age)
+type EvictionPolicy string
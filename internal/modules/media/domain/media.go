@@ -0,0 +1,21 @@
+package domain
+
+import "io"
+
+// Dir is the directory under the storage path media files are kept in, and
+// ThumbnailDir the one below it holding generated thumbnails
+const (
+	Dir          = "media"
+	ThumbnailDir = "thumbnails"
+)
+
+// MaxDownloadSize is the largest file the Bot API lets bots download
+const MaxDownloadSize = 20 << 20
+
+// File is a media file downloaded from Telegram. The caller closes Body.
+type File struct {
+	Body        io.ReadCloser
+	ContentType string
+	// Size is the length of Body in bytes, or 0 when unknown
+	Size int64
+}
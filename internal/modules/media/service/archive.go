@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/media/domain"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/fileutil"
+	"github.com/samber/oops"
+)
+
+const (
+	// archiveQueueSize is how many files may wait to be archived before new
+	// ones are dropped
+	archiveQueueSize = 256
+	// archiveSaveInterval is how often access times are written to the index
+	archiveSaveInterval = 10 * time.Minute
+)
+
+type archiveJob struct {
+	channelID string
+	fileID    string
+}
+
+// Start archives queued media files until ctx is cancelled
+func (s *Service) Start(ctx context.Context) {
+	ticker := time.NewTicker(archiveSaveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-s.queue:
+			if err := s.archive(ctx, job); err != nil && ctx.Err() == nil {
+				slog.Warn("Failed to archive media", "channel_id", job.channelID, "file_id", job.fileID, "error", err)
+			}
+		case <-ticker.C:
+			s.saveAccessTimes()
+		}
+	}
+}
+
+// Close saves the access times of archived files
+func (s *Service) Close() {
+	s.saveAccessTimes()
+}
+
+// Enqueue schedules media files of a channel post for archiving when the
+// archive is enabled. Files are dropped rather than blocking the caller when
+// the queue is full.
+func (s *Service) Enqueue(channelID string, fileIDs ...string) {
+	if !s.cfg.Get().MediaArchive {
+		return
+	}
+	for _, fileID := range fileIDs {
+		select {
+		case s.queue <- archiveJob{channelID: channelID, fileID: fileID}:
+		default:
+			slog.Warn("Media archive queue is full, file not archived", "channel_id", channelID, "file_id", fileID)
+		}
+	}
+}
+
+// Evicted reports whether a file was archived and later evicted to stay
+// within the quotas, so it can no longer be served
+func (s *Service) Evicted(fileID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, ok := s.index()[fileID]
+	return ok && file.Evicted
+}
+
+// archive downloads a file into the archive, evicting older files when a
+// quota would be exceeded
+func (s *Service) archive(ctx context.Context, job archiveJob) error {
+	s.mu.Lock()
+	_, known := s.index()[job.fileID]
+	s.mu.Unlock()
+	if known {
+		return nil
+	}
+
+	source, err := s.download(ctx, job.fileID)
+	if err != nil {
+		return err
+	}
+	defer source.Body.Close()
+
+	dir := s.archiveDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return oops.With("path", dir, "context", "failed to create media directory").Wrap(err)
+	}
+	tmp, err := os.CreateTemp(dir, ".download.tmp-*")
+	if err != nil {
+		return oops.With("path", dir, "context", "failed to create temporary file").Wrap(err)
+	}
+	// Removing fails harmlessly once the file has been renamed
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), source.Body)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return oops.With("file_id", job.fileID, "context", "failed to download file").Wrap(stripURL(err))
+	}
+
+	now := time.Now()
+	file := &domain.ArchivedFile{
+		FileID:      job.fileID,
+		ChannelID:   job.channelID,
+		Hash:        hex.EncodeToString(hash.Sum(nil)),
+		Size:        size,
+		ContentType: source.ContentType,
+		StoredAt:    now,
+		LastAccess:  now,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.makeRoom(file) {
+		slog.Info("Media file exceeds the archive quota, not archived", "channel_id", job.channelID, "file_id", job.fileID, "size", size)
+		return nil
+	}
+
+	blob := s.blobPath(file.Hash)
+	if _, err := os.Stat(blob); errors.Is(err, os.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(blob), 0755); err != nil {
+			return oops.With("path", blob, "context", "failed to create media directory").Wrap(err)
+		}
+		if err := os.Rename(tmp.Name(), blob); err != nil {
+			return oops.With("path", blob, "context", "failed to store media file").Wrap(err)
+		}
+	}
+
+	s.index()[file.FileID] = file
+	return s.saveIndex()
+}
+
+// makeRoom evicts files until the new file fits both quotas, and reports
+// whether it fits at all. The overall quota counts shared content once.
+func (s *Service) makeRoom(file *domain.ArchivedFile) bool {
+	cfg := s.cfg.Get()
+	quota, channelQuota := cfg.MediaQuota()
+	if (quota > 0 && file.Size > quota) || (channelQuota > 0 && file.Size > channelQuota) {
+		return false
+	}
+
+	for {
+		total, channel, shared := s.usage(file.ChannelID, file.Hash)
+		added := file.Size
+		if shared {
+			added = 0
+		}
+
+		overChannel := channelQuota > 0 && channel+file.Size > channelQuota
+		if !overChannel && (quota == 0 || total+added <= quota) {
+			return true
+		}
+
+		scope := ""
+		if overChannel {
+			scope = file.ChannelID
+		}
+		victim := s.nextEviction(cfg.MediaEviction, scope)
+		if victim == nil {
+			return false
+		}
+		s.evict(victim)
+	}
+}
+
+// usage sums the archived bytes overall and of one channel, and reports
+// whether content with hash is already stored
+func (s *Service) usage(channelID string, hash string) (total int64, channel int64, shared bool) {
+	seen := map[string]bool{}
+	for _, file := range s.index() {
+		if file.Evicted {
+			continue
+		}
+		if !seen[file.Hash] {
+			seen[file.Hash] = true
+			total += file.Size
+		}
+		if file.ChannelID == channelID {
+			channel += file.Size
+		}
+	}
+	return total, channel, seen[hash]
+}
+
+// nextEviction picks the file evicted first: the least recently served one
+// for the lru policy, the oldest one for age. A non-empty channelID limits
+// the choice to that channel.
+func (s *Service) nextEviction(policy domain.EvictionPolicy, channelID string) *domain.ArchivedFile {
+	var victim *domain.ArchivedFile
+	for _, file := range s.index() {
+		if file.Evicted || (channelID != "" && file.ChannelID != channelID) {
+			continue
+		}
+		if victim == nil || evictsBefore(policy, file, victim) {
+			victim = file
+		}
+	}
+	return victim
+}
+
+func evictsBefore(policy domain.EvictionPolicy, a, b *domain.ArchivedFile) bool {
+	if policy == domain.EvictionPolicyAge {
+		return a.StoredAt.Before(b.StoredAt)
+	}
+	return a.LastAccess.Before(b.LastAccess)
+}
+
+// evict marks a file evicted and removes its content once no other archived
+// file shares it
+func (s *Service) evict(file *domain.ArchivedFile) {
+	file.Evicted = true
+	slog.Info("Evicted archived media", "channel_id", file.ChannelID, "file_id", file.FileID, "size", file.Size)
+
+	for _, other := range s.index() {
+		if !other.Evicted && other.Hash == file.Hash {
+			return
+		}
+	}
+	if err := os.Remove(s.blobPath(file.Hash)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("Failed to remove evicted media", "file_id", file.FileID, "error", err)
+	}
+}
+
+// openArchived opens a file from the archive, reporting false when the
+// archive does not hold it
+func (s *Service) openArchived(fileID string) (*domain.File, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, ok := s.index()[fileID]
+	if !ok || file.Evicted {
+		return nil, false
+	}
+	blob, err := os.Open(s.blobPath(file.Hash))
+	if err != nil {
+		slog.Warn("Archived media is missing, downloading it again", "file_id", fileID, "error", err)
+		return nil, false
+	}
+
+	file.LastAccess = time.Now()
+	s.accessed = true
+	return &domain.File{Body: blob, ContentType: file.ContentType, Size: file.Size}, true
+}
+
+// index returns the archived files by file ID, loading them on first use.
+// The caller holds s.mu.
+func (s *Service) index() map[string]*domain.ArchivedFile {
+	if s.archived != nil {
+		return s.archived
+	}
+
+	s.archived = map[string]*domain.ArchivedFile{}
+	dir := s.archiveDir()
+	data, err := os.ReadFile(filepath.Join(dir, domain.ArchiveIndexFile))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Error("Failed to read media archive index", "path", dir, "error", err)
+		}
+		return s.archived
+	}
+
+	var files []*domain.ArchivedFile
+	if err := json.Unmarshal(data, &files); err != nil {
+		slog.Error("Failed to parse media archive index", "path", dir, "error", err)
+		return s.archived
+	}
+	for _, file := range files {
+		s.archived[file.FileID] = file
+	}
+
+	// Downloads interrupted by a crash leave temporary files behind
+	if entries, err := os.ReadDir(dir); err == nil {
+		for _, entry := range entries {
+			if fileutil.IsTempFile(entry.Name()) {
+				os.Remove(filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	return s.archived
+}
+
+// saveIndex writes the archive index. The caller holds s.mu.
+func (s *Service) saveIndex() error {
+	files := make([]*domain.ArchivedFile, 0, len(s.archived))
+	for _, file := range s.archived {
+		files = append(files, file)
+	}
+	slices.SortFunc(files, func(a, b *domain.ArchivedFile) int {
+		return strings.Compare(a.FileID, b.FileID)
+	})
+
+	data, err := json.MarshalIndent(files, "", "  ")
+	if err != nil {
+		return oops.With("context", "failed to encode media archive index").Wrap(err)
+	}
+	path := filepath.Join(s.archiveDir(), domain.ArchiveIndexFile)
+	if err := fileutil.WriteFileAtomic(path, data, 0644); err != nil {
+		return oops.With("context", "failed to write media archive index").Wrap(err)
+	}
+	s.accessed = false
+	return nil
+}
+
+// saveAccessTimes writes the index when files were served since the last
+// save; access times alone are not worth a write per request
+func (s *Service) saveAccessTimes() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.accessed {
+		return
+	}
+	if err := s.saveIndex(); err != nil {
+		slog.Warn("Failed to save media access times", "error", err)
+	}
+}
+
+func (s *Service) archiveDir() string {
+	return filepath.Join(s.cfg.Get().StoragePath, domain.Dir)
+}
+
+// blobPath names archived content by its hash, spread over subdirectories
+func (s *Service) blobPath(hash string) string {
+	return filepath.Join(s.archiveDir(), hash[:2], hash)
+}
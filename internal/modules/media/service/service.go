@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sync"
+
+	"github.com/go-telegram/bot"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/media/domain"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/config"
+	appErrors "github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/samber/oops"
+)
+
+// Service downloads media files of stored messages through the Bot API,
+// keeps the optional local archive and scales images down to thumbnails
+type Service struct {
+	cfg    *config.Store
+	bot    *bot.Bot
+	client *http.Client
+
+	mu       sync.Mutex
+	archived map[string]*domain.ArchivedFile // nil until the index is loaded
+	accessed bool                            // access times changed since the index was saved
+	queue    chan archiveJob
+}
+
+// New creates a new media service
+func New(cfg *config.Store) *Service {
+	return &Service{
+		cfg:    cfg,
+		client: http.DefaultClient,
+		queue:  make(chan archiveJob, archiveQueueSize),
+	}
+}
+
+// SetBot sets the Telegram bot instance
+func (s *Service) SetBot(b *bot.Bot) {
+	s.bot = b
+}
+
+// Open opens a file by its Telegram file ID, from the local archive when it
+// holds the file and through the Bot API otherwise. Files the bot cannot
+// access, including those above the Bot API download limit, are reported as
+// ErrMediaNotFound.
+func (s *Service) Open(ctx context.Context, fileID string) (*domain.File, error) {
+	if file, ok := s.openArchived(fileID); ok {
+		return file, nil
+	}
+	return s.download(ctx, fileID)
+}
+
+// download starts downloading a file through the Bot API
+func (s *Service) download(ctx context.Context, fileID string) (*domain.File, error) {
+	if s.bot == nil {
+		return nil, oops.With("file_id", fileID).Wrap(appErrors.ErrMediaUnavailable)
+	}
+
+	file, err := s.bot.GetFile(ctx, &bot.GetFileParams{FileID: fileID})
+	if err != nil {
+		if errors.Is(err, bot.ErrorBadRequest) || errors.Is(err, bot.ErrorNotFound) {
+			return nil, oops.With("file_id", fileID, "reason", err.Error()).Wrap(appErrors.ErrMediaNotFound)
+		}
+		return nil, oops.With("file_id", fileID, "context", "failed to get file").Wrap(err)
+	}
+
+	// The download link contains the bot token, so it must never reach a
+	// client or a log line
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, s.bot.FileDownloadLink(file), nil)
+	if err != nil {
+		return nil, oops.With("file_id", fileID, "context", "failed to build download request").Wrap(stripURL(err))
+	}
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, oops.With("file_id", fileID, "context", "failed to download file").Wrap(stripURL(err))
+	}
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		if response.StatusCode == http.StatusNotFound {
+			return nil, oops.With("file_id", fileID).Wrap(appErrors.ErrMediaNotFound)
+		}
+		return nil, oops.With("file_id", fileID, "status", response.StatusCode).Errorf("failed to download file")
+	}
+
+	// Telegram serves most files as application/octet-stream; the
+	// extension of the file path is more telling
+	contentType := mime.TypeByExtension(path.Ext(file.FilePath))
+	if contentType == "" {
+		contentType = response.Header.Get("Content-Type")
+	}
+
+	return &domain.File{
+		Body:        response.Body,
+		ContentType: contentType,
+		Size:        max(response.ContentLength, 0),
+	}, nil
+}
+
+// stripURL drops the request URL from an HTTP client error
+func stripURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
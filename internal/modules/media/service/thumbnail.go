@@ -0,0 +1,170 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/media/domain"
+	appErrors "github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/fileutil"
+	"github.com/samber/oops"
+)
+
+const (
+	// maxImageBytes is the largest image thumbnails are made of
+	maxImageBytes = domain.MaxDownloadSize
+	// maxImagePixels refuses images that would take too much memory to decode
+	maxImagePixels = 50_000_000
+	// thumbnailQuality is the JPEG quality of generated thumbnails
+	thumbnailQuality = 80
+)
+
+// Thumbnail returns the image of a file ID scaled down to width pixels as a
+// JPEG. Only the configured thumbnail sizes are served. Thumbnails are
+// generated on first request and cached under the media directory; images
+// narrower than width keep their size.
+func (s *Service) Thumbnail(ctx context.Context, fileID string, width int) (*domain.File, error) {
+	if !slices.Contains(s.cfg.Get().ThumbnailSizes, width) {
+		return nil, oops.With("file_id", fileID, "width", width).Wrap(appErrors.ErrMediaNotFound)
+	}
+
+	cachePath := s.thumbnailPath(fileID, width)
+	if file, err := openCached(cachePath); err == nil {
+		return file, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, oops.With("file_id", fileID, "path", cachePath, "context", "failed to read cached thumbnail").Wrap(err)
+	}
+
+	original, err := s.Open(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(original.Body, maxImageBytes+1))
+	original.Body.Close()
+	if err != nil {
+		return nil, oops.With("file_id", fileID, "context", "failed to download image").Wrap(err)
+	}
+	if len(data) > maxImageBytes {
+		return nil, oops.With("file_id", fileID, "size", len(data)).Wrap(appErrors.ErrUnsupportedImage)
+	}
+
+	thumbnail, err := makeThumbnail(data, width)
+	if err != nil {
+		return nil, oops.With("file_id", fileID, "width", width).Wrap(err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		return nil, oops.With("path", cachePath, "context", "failed to create thumbnail directory").Wrap(err)
+	}
+	if err := fileutil.WriteFileAtomic(cachePath, thumbnail, 0644); err != nil {
+		return nil, oops.With("file_id", fileID, "context", "failed to cache thumbnail").Wrap(err)
+	}
+
+	return &domain.File{
+		Body:        io.NopCloser(bytes.NewReader(thumbnail)),
+		ContentType: "image/jpeg",
+		Size:        int64(len(thumbnail)),
+	}, nil
+}
+
+// thumbnailPath names cached thumbnails by a hash of the file ID, which may
+// be longer than a file name allows
+func (s *Service) thumbnailPath(fileID string, width int) string {
+	sum := sha256.Sum256([]byte(fileID))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(s.cfg.Get().StoragePath, domain.Dir, domain.ThumbnailDir, name[:2], fmt.Sprintf("%s-%d.jpg", name, width))
+}
+
+func openCached(path string) (*domain.File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &domain.File{Body: file, ContentType: "image/jpeg", Size: info.Size()}, nil
+}
+
+// makeThumbnail decodes a JPEG, PNG or GIF image and encodes it as a JPEG
+// at most width pixels wide. Transparent areas become white.
+func makeThumbnail(data []byte, width int) ([]byte, error) {
+	header, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, oops.Wrapf(appErrors.ErrUnsupportedImage, "%v", err)
+	}
+	if header.Width*header.Height > maxImagePixels {
+		return nil, oops.With("image_width", header.Width, "image_height", header.Height).Wrapf(appErrors.ErrUnsupportedImage, "image is too large")
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, oops.Wrapf(appErrors.ErrUnsupportedImage, "%v", err)
+	}
+
+	bounds := src.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), src, bounds.Min, draw.Over)
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, scaleToWidth(flat, width), &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, oops.With("context", "failed to encode thumbnail").Wrap(err)
+	}
+	return out.Bytes(), nil
+}
+
+// scaleToWidth shrinks an image to width pixels keeping its aspect ratio.
+// Every target pixel is the average of the source pixels it covers, which
+// keeps fine detail from aliasing. Narrower images are returned unchanged.
+func scaleToWidth(src *image.RGBA, width int) *image.RGBA {
+	srcWidth, srcHeight := src.Bounds().Dx(), src.Bounds().Dy()
+	if srcWidth <= width {
+		return src
+	}
+	height := max(1, srcHeight*width/srcWidth)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := range height {
+		y0, y1 := y*srcHeight/height, max((y+1)*srcHeight/height, y*srcHeight/height+1)
+		for x := range width {
+			x0, x1 := x*srcWidth/width, max((x+1)*srcWidth/width, x*srcWidth/width+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					pixel := row[sx*4 : sx*4+4]
+					r += uint64(pixel[0])
+					g += uint64(pixel[1])
+					b += uint64(pixel[2])
+					a += uint64(pixel[3])
+					n++
+				}
+			}
+
+			offset := y*dst.Stride + x*4
+			dst.Pix[offset] = uint8(r / n)
+			dst.Pix[offset+1] = uint8(g / n)
+			dst.Pix[offset+2] = uint8(b / n)
+			dst.Pix[offset+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// ForwardOrigin records where a forwarded message was first posted
+type ForwardOrigin struct {
+	Type OriginType `json:"type"`
+	// Name is the title of the chat or channel, or the name of the user
+	Name     string `json:"name"`
+	Username string `json:"username,omitempty"`
+	// MessageID is the original post in a channel
+	MessageID int64     `json:"message_id,omitempty"`
+	Date      time.Time `json:"date"`
+}
+
+// Link returns the public link to the original post, or to its source when
+// the post is unknown. It is empty for sources without a username.
+func (o ForwardOrigin) Link() string {
+	switch {
+	case o.Username == "":
+		return ""
+	case o.MessageID != 0:
+		return fmt.Sprintf("https://t.me/%s/%d", o.Username, o.MessageID)
+	}
+	return "https://t.me/" + o.Username
+}
+
+// Reply records the message a post replies to
+type Reply struct {
+	MessageID int64 `json:"message_id"`
+	// Source names the chat of a reply to another chat, empty for replies
+	// within the channel
+	Source string `json:"source,omitempty"`
+	// Text is the beginning of the replied-to message, when known
+	Text string `json:"text,omitempty"`
+	// Quote is the part of the replied-to message the post quotes
+	Quote string `json:"quote,omitempty"`
+	Link  string `json:"link,omitempty"`
+}
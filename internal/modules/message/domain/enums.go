@@ -3,5 +3,9 @@
 package domain
 
 // MediaType represents the type of media content
-// ENUM(photo,video,document,audio)
+// ENUM(photo,video,document,audio,voice,video_note,animation,sticker,poll,location,venue,contact,dice,paid_media)
 type MediaType string
+
+// OriginType is the kind of source a forwarded message comes from
+// ENUM(user,hidden_user,chat,channel)
+type OriginType string
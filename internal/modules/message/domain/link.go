@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// SafeLink reports whether a link taken from a post may be published as an
+// href: an absolute http(s) URL or a tg: deep link. Other schemes, such as
+// javascript: or data:, would run in the feed readers and the dashboard
+// that show the link.
+func SafeLink(link string) bool {
+	// Browsers ignore whitespace and control characters inside a scheme,
+	// so "java\tscript:" must not pass as a relative link
+	if strings.ContainsFunc(link, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) {
+		return false
+	}
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	switch parsed.Scheme {
+	case "http", "https":
+		return parsed.Host != ""
+	case "tg":
+		return true
+	}
+	return false
+}
@@ -1,6 +1,12 @@
 package domain
 
-import "time"
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // Message represents a Telegram message stored for RSS feed
 type Message struct {
@@ -12,13 +18,119 @@ type Message struct {
 	Author      string    `json:"author"`
 	Media       []Media   `json:"media"`
 	Link        string    `json:"link"`
+	// MediaGroupID identifies the album a message was merged from
+	MediaGroupID string `json:"media_group_id,omitempty"`
+	// Forward is set when the message was forwarded from elsewhere
+	Forward *ForwardOrigin `json:"forward,omitempty"`
+	// Reply is set when the message replies to another message
+	Reply *Reply `json:"reply,omitempty"`
+	// LinkPreview is the URL previewed under the text
+	LinkPreview string `json:"link_preview,omitempty"`
+	// Tags are the hashtags and cashtags of the text as written, such as
+	// "#news" and "$TON"
+	Tags []string `json:"tags,omitempty"`
+	// DeletedAt is when the post was found deleted from its channel; the
+	// message is kept as a tombstone
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
-// Media represents multimedia content in a message
+// Media represents multimedia content in a message. File-based media carry
+// a FileID; polls, locations, venues, contacts and dice carry the matching
+// detail field instead.
 type Media struct {
-	Type      MediaType `json:"type"`
-	FileID    string    `json:"file_id"`
-	URL       string    `json:"url"`
-	Thumbnail string    `json:"thumbnail,omitempty"`
-	Caption   string    `json:"caption,omitempty"`
+	Type   MediaType `json:"type"`
+	FileID string    `json:"file_id"`
+	URL    string    `json:"url"`
+	// Thumbnail is the file ID of the image thumbnails are scaled down
+	// from: a photo itself, or the preview Telegram made of other files
+	Thumbnail string `json:"thumbnail,omitempty"`
+	Caption   string `json:"caption,omitempty"`
+	FileName  string `json:"file_name,omitempty"`
+	MimeType  string `json:"mime_type,omitempty"`
+	FileSize  int64  `json:"file_size,omitempty"`
+	// Title of an audio track, as "Performer - Title" when both are known
+	Title string `json:"title,omitempty"`
+	// Duration of audio, voice and video in seconds
+	Duration int `json:"duration,omitempty"`
+	// Emoji a sticker stands for
+	Emoji string `json:"emoji,omitempty"`
+	// Stars is the price of paid media
+	Stars int `json:"stars,omitempty"`
+
+	Poll     *Poll     `json:"poll,omitempty"`
+	Location *Location `json:"location,omitempty"`
+	Venue    *Venue    `json:"venue,omitempty"`
+	Contact  *Contact  `json:"contact,omitempty"`
+	Dice     *Dice     `json:"dice,omitempty"`
+}
+
+// Poll is a poll or quiz with its results when stored
+type Poll struct {
+	Question string       `json:"question"`
+	Options  []PollOption `json:"options"`
+	Quiz     bool         `json:"quiz,omitempty"`
+	Closed   bool         `json:"closed,omitempty"`
+	Voters   int          `json:"voters"`
+}
+
+// PollOption is an answer of a poll
+type PollOption struct {
+	Text   string `json:"text"`
+	Voters int    `json:"voters"`
+}
+
+// Location is a point on the map
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// MapURL links to the location on OpenStreetMap
+func (l Location) MapURL() string {
+	latitude := strconv.FormatFloat(l.Latitude, 'f', -1, 64)
+	longitude := strconv.FormatFloat(l.Longitude, 'f', -1, 64)
+	return fmt.Sprintf("https://www.openstreetmap.org/?mlat=%s&mlon=%s#map=16/%s/%s", latitude, longitude, latitude, longitude)
+}
+
+// Venue is a named place
+type Venue struct {
+	Title    string   `json:"title"`
+	Address  string   `json:"address"`
+	Location Location `json:"location"`
+}
+
+// Contact is a shared phone contact
+type Contact struct {
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name,omitempty"`
+	PhoneNumber string `json:"phone_number"`
+}
+
+// Name returns the full name of the contact
+func (c Contact) Name() string {
+	return strings.TrimSpace(c.FirstName + " " + c.LastName)
+}
+
+// Dice is an animated emoji with a random value
+type Dice struct {
+	Emoji string `json:"emoji"`
+	Value int    `json:"value"`
+}
+
+// PublicURL returns where the media can be fetched: its own URL when set,
+// otherwise the media proxy of the server at baseURL
+func (m Media) PublicURL(baseURL string) string {
+	if m.URL != "" {
+		return m.URL
+	}
+	return baseURL + "/media/" + url.PathEscape(m.FileID)
+}
+
+// ThumbnailURL returns where the thumbnail of the given width is served by
+// the media proxy, or an empty string when the media has no thumbnail
+func (m Media) ThumbnailURL(baseURL string, width int) string {
+	if m.Thumbnail == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/media/%s/thumbnail/%d", baseURL, url.PathEscape(m.Thumbnail), width)
 }
@@ -0,0 +1,34 @@
+package domain
+
+import "github.com/reshetovitsme/rss-telegram-feed/internal/shared/schema"
+
+// Schema versions stored message records. Append an upgrade whenever a
+// field change needs older records converted.
+var Schema = schema.New("message",
+	// 1 → 2: photos are scaled down from the photo itself, other media keep
+	// no thumbnail as Telegram's was not recorded
+	func(record map[string]any) error {
+		media, _ := record["media"].([]any)
+		for _, item := range media {
+			fields, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			if fields["type"] == MediaTypePhoto.String() && fields["thumbnail"] == nil {
+				fields["thumbnail"] = fields["file_id"]
+			}
+		}
+		return nil
+	},
+	// 2 → 3: tags were not recorded, so they are parsed from the text
+	func(record map[string]any) error {
+		if _, ok := record["tags"]; ok {
+			return nil
+		}
+		text, _ := record["text"].(string)
+		if tags := ParseTags(text); len(tags) > 0 {
+			record["tags"] = tags
+		}
+		return nil
+	},
+)
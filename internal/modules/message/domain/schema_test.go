@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	appErrors "github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture %s: %v", name, err)
+	}
+	return data
+}
+
+func unmarshalFixture(t *testing.T, name string, wantVersion int) Message {
+	t.Helper()
+	var msg Message
+	version, err := Schema.Unmarshal(readFixture(t, name), &msg)
+	if err != nil {
+		t.Fatalf("Unmarshal %s: %v", name, err)
+	}
+	if version != wantVersion {
+		t.Errorf("version = %d, want %d", version, wantVersion)
+	}
+	return msg
+}
+
+func TestSchemaUpgradeFromV1(t *testing.T) {
+	msg := unmarshalFixture(t, "message_v1.json", 1)
+
+	// 1 → 2: photos are their own thumbnail, other media get none
+	if got := msg.Media[0].Thumbnail; got != "photo-file" {
+		t.Errorf("photo thumbnail = %q, want %q", got, "photo-file")
+	}
+	if got := msg.Media[1].Thumbnail; got != "" {
+		t.Errorf("document thumbnail = %q, want none", got)
+	}
+	// 2 → 3: tags are parsed from the text, once per tag in any case
+	if want := []string{"#News", "$TON"}; !reflect.DeepEqual(msg.Tags, want) {
+		t.Errorf("tags = %q, want %q", msg.Tags, want)
+	}
+}
+
+func TestSchemaUpgradeFromV2(t *testing.T) {
+	msg := unmarshalFixture(t, "message_v2.json", 2)
+
+	if got := msg.Media[0].Thumbnail; got != "video-thumbnail" {
+		t.Errorf("thumbnail = %q, want %q", got, "video-thumbnail")
+	}
+	if want := []string{"#digest"}; !reflect.DeepEqual(msg.Tags, want) {
+		t.Errorf("tags = %q, want %q", msg.Tags, want)
+	}
+
+	// Tags already recorded are not parsed again
+	tagged := unmarshalFixture(t, "message_v2_tagged.json", 2)
+	if want := []string{"#kept"}; !reflect.DeepEqual(tagged.Tags, want) {
+		t.Errorf("tags = %q, want %q", tagged.Tags, want)
+	}
+}
+
+func TestSchemaRoundTrip(t *testing.T) {
+	deletedAt := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+	want := Message{
+		ID:        45,
+		ChannelID: "-1001234567890",
+		Text:      "Current #release",
+		Date:      time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Media:     []Media{{Type: MediaTypePhoto, FileID: "photo-file", Thumbnail: "photo-file"}},
+		Link:      "https://t.me/news/45",
+		Tags:      []string{"#release"},
+		DeletedAt: &deletedAt,
+	}
+	data, err := Schema.Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var got Message
+	version, err := Schema.Unmarshal(data, &got)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if version != Schema.Version() || !reflect.DeepEqual(got, want) {
+		t.Errorf("got version %d, message %+v; want version %d, message %+v", version, got, Schema.Version(), want)
+	}
+}
+
+func TestSchemaNewerVersion(t *testing.T) {
+	data := []byte(`{"schema_version": 99, "data": {"id": 46}}`)
+	var msg Message
+	if _, err := Schema.Unmarshal(data, &msg); !errors.Is(err, appErrors.ErrSchemaVersion) {
+		t.Errorf("err = %v, want %v", err, appErrors.ErrSchemaVersion)
+	}
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// tagPattern finds hashtags and cashtags in text without entities
+var tagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_])(#[\p{L}\p{N}_]+|\$[A-Z]{1,8}\b)`)
+
+// NormalizeTag returns the form tags are compared in: lower case, without
+// the # of hashtags and any @bot suffix. Cashtags keep their $.
+func NormalizeTag(tag string) string {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+	tag, _, _ = strings.Cut(tag, "@")
+	return strings.ToLower(tag)
+}
+
+// AddTag appends a tag unless the message already has it in any case
+func (m *Message) AddTag(tag string) {
+	tag, _, _ = strings.Cut(tag, "@")
+	if name := NormalizeTag(tag); name == "" || name == "$" || m.HasTag(tag) {
+		return
+	}
+	m.Tags = append(m.Tags, tag)
+}
+
+// HasTag reports whether the message is tagged with tag, compared with
+// NormalizeTag
+func (m *Message) HasTag(tag string) bool {
+	tag = NormalizeTag(tag)
+	return slices.ContainsFunc(m.Tags, func(t string) bool {
+		return NormalizeTag(t) == tag
+	})
+}
+
+// ParseTags finds the hashtags and cashtags of a text. Telegram marks them
+// as entities; this is for text stored before tags were recorded.
+func ParseTags(text string) []string {
+	msg := &Message{}
+	for _, match := range tagPattern.FindAllStringSubmatch(text, -1) {
+		msg.AddTag(match[1])
+	}
+	return msg.Tags
+}
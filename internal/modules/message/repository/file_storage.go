@@ -1,14 +1,21 @@
 package repository
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/domain"
+	appErrors "github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/fileutil"
+	"github.com/samber/lo"
 	"github.com/samber/oops"
 )
 
@@ -39,51 +46,71 @@ func (s *FileStorage) SaveMessage(message *domain.Message) error {
 	}
 
 	path := filepath.Join(msgDir, fmt.Sprintf("%d.json", message.ID))
-	data, err := json.MarshalIndent(message, "", "  ")
+	data, err := domain.Schema.Marshal(message)
 	if err != nil {
 		return oops.With("channel_id", message.ChannelID, "message_id", message.ID, "context", "failed to marshal message").Wrap(err)
 	}
 
-	return os.WriteFile(path, data, 0644)
+	return fileutil.WriteFileAtomic(path, data, 0644)
 }
 
-func (s *FileStorage) GetMessages(channelID string, limit int) ([]*domain.Message, error) {
+func (s *FileStorage) GetMessage(channelID string, messageID int64) (*domain.Message, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	msgDir := filepath.Join(s.basePath, channelID)
-	entries, err := os.ReadDir(msgDir)
+	message, err := readMessage(filepath.Join(s.basePath, channelID, fmt.Sprintf("%d.json", messageID)))
 	if err != nil {
 		if os.IsNotExist(err) {
-			return []*domain.Message{}, nil
+			return nil, oops.With("channel_id", channelID, "message_id", messageID).Wrap(appErrors.ErrMessageNotFound)
 		}
-		return nil, oops.With("channel_id", channelID, "message_dir", msgDir, "context", "failed to read messages directory").Wrap(err)
+		return nil, oops.With("channel_id", channelID, "message_id", messageID).Wrap(err)
 	}
+	return message, nil
+}
 
+func (s *FileStorage) GetMessages(channelID string, limit int) ([]*domain.Message, error) {
 	var messages []*domain.Message
-	count := 0
-	for i := len(entries) - 1; i >= 0 && count < limit; i-- {
-		entry := entries[i]
-		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
-			continue
+	err := s.IterateMessages(channelID, func(message *domain.Message) bool {
+		if len(messages) >= limit {
+			return false
 		}
+		messages = append(messages, message)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		path := filepath.Join(msgDir, entry.Name())
-		data, err := os.ReadFile(path)
+	return messages, nil
+}
+
+func (s *FileStorage) IterateMessages(channelID string, fn func(message *domain.Message) bool) error {
+	msgDir := filepath.Join(s.basePath, channelID)
+	s.mu.RLock()
+	ids, err := listMessageIDs(msgDir)
+	s.mu.RUnlock()
+	if err != nil {
+		return oops.With("channel_id", channelID, "message_dir", msgDir, "context", "failed to read messages directory").Wrap(err)
+	}
+
+	// The lock is only held while each record is read, so fn may take its
+	// time or save messages without blocking other writers
+	for i := len(ids) - 1; i >= 0; i-- {
+		path := filepath.Join(msgDir, fmt.Sprintf("%d.json", ids[i]))
+		message, err := s.readMessageLocked(path)
 		if err != nil {
-			continue
+			if skipMessage(path, err) {
+				continue
+			}
+			return oops.With("channel_id", channelID).Wrap(err)
 		}
 
-		var message domain.Message
-		if err := json.Unmarshal(data, &message); err != nil {
-			continue
+		if !fn(message) {
+			break
 		}
-
-		messages = append(messages, &message)
-		count++
 	}
 
-	return messages, nil
+	return nil
 }
 
 func (s *FileStorage) GetRecentMessages(channelID string, since time.Time) ([]*domain.Message, error) {
@@ -106,20 +133,123 @@ func (s *FileStorage) GetRecentMessages(channelID string, since time.Time) ([]*d
 		}
 
 		path := filepath.Join(msgDir, entry.Name())
-		data, err := os.ReadFile(path)
+		message, err := readMessage(path)
 		if err != nil {
-			continue
+			if skipMessage(path, err) {
+				continue
+			}
+			return nil, oops.With("channel_id", channelID).Wrap(err)
 		}
 
-		var message domain.Message
-		if err := json.Unmarshal(data, &message); err != nil {
+		if message.Date.After(since) {
+			messages = append(messages, message)
+		}
+	}
+
+	return messages, nil
+}
+
+func (s *FileStorage) DeleteMessagesBefore(channelID string, before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msgDir := filepath.Join(s.basePath, channelID)
+	ids, err := listMessageIDs(msgDir)
+	if err != nil {
+		return 0, oops.With("channel_id", channelID, "message_dir", msgDir, "context", "failed to read messages directory").Wrap(err)
+	}
+
+	deleted := 0
+	for _, id := range ids {
+		path := filepath.Join(msgDir, fmt.Sprintf("%d.json", id))
+		message, err := readMessage(path)
+		if err != nil {
+			if skipMessage(path, err) {
+				continue
+			}
+			return deleted, oops.With("channel_id", channelID).Wrap(err)
+		}
+
+		if !message.Date.Before(before) {
 			continue
 		}
+		if err := os.Remove(path); err != nil {
+			return deleted, oops.With("channel_id", channelID, "message_id", id, "context", "failed to delete message").Wrap(err)
+		}
+		deleted++
+	}
 
-		if message.Date.After(since) {
-			messages = append(messages, &message)
+	return deleted, nil
+}
+
+func (s *FileStorage) DeleteChannelMessages(channelID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msgDir := filepath.Join(s.basePath, channelID)
+	if err := os.RemoveAll(msgDir); err != nil {
+		return oops.With("channel_id", channelID, "message_dir", msgDir, "context", "failed to delete messages").Wrap(err)
+	}
+	return nil
+}
+
+// readMessageLocked loads a message file under the read lock
+func (s *FileStorage) readMessageLocked(path string) (*domain.Message, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return readMessage(path)
+}
+
+// readMessage loads a message file. Read errors are returned as is, so
+// os.IsNotExist still applies; the record is decoded and upgraded through
+// domain.Schema.
+func readMessage(path string) (*domain.Message, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var message domain.Message
+	if _, err := domain.Schema.Unmarshal(data, &message); err != nil {
+		return nil, oops.With("path", path).Wrap(err)
+	}
+	return &message, nil
+}
+
+// skipMessage reports whether a listing should go on past a message that
+// failed to load: it was deleted meanwhile, or its record is corrupt. Corrupt
+// records are logged and left for the integrity scan to report, so one bad
+// file does not hide the rest of the channel.
+func skipMessage(path string, err error) bool {
+	if os.IsNotExist(err) {
+		return true
+	}
+	if errors.Is(err, appErrors.ErrCorruptRecord) || errors.Is(err, appErrors.ErrSchemaVersion) {
+		slog.Warn("Skipping corrupt message record", "path", path, "error", err)
+		return true
+	}
+	return false
+}
+
+// listMessageIDs returns the IDs of messages stored in a channel directory,
+// sorted numerically in ascending order
+func listMessageIDs(msgDir string) ([]int64, error) {
+	entries, err := os.ReadDir(msgDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []int64{}, nil
 		}
+		return nil, err
 	}
 
-	return messages, nil
+	ids := lo.FilterMap(entries, func(entry os.DirEntry, _ int) (int64, bool) {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			return 0, false
+		}
+		id, err := strconv.ParseInt(strings.TrimSuffix(entry.Name(), ".json"), 10, 64)
+		return id, err == nil
+	})
+	slices.Sort(ids)
+
+	return ids, nil
 }
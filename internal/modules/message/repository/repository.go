@@ -9,6 +9,16 @@ import (
 // Repository defines the interface for message data persistence
 type Repository interface {
 	SaveMessage(message *domain.Message) error
+	GetMessage(channelID string, messageID int64) (*domain.Message, error)
 	GetMessages(channelID string, limit int) ([]*domain.Message, error)
 	GetRecentMessages(channelID string, since time.Time) ([]*domain.Message, error)
+	// IterateMessages calls fn for each message of a channel, newest first,
+	// until fn returns false. fn runs without the repository locked, so it
+	// may save or delete messages; messages saved meanwhile are not visited.
+	IterateMessages(channelID string, fn func(message *domain.Message) bool) error
+	// DeleteMessagesBefore removes messages of a channel posted before the
+	// given time and returns how many were deleted
+	DeleteMessagesBefore(channelID string, before time.Time) (int, error)
+	// DeleteChannelMessages removes all messages of a channel
+	DeleteChannelMessages(channelID string) error
 }
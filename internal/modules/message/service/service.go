@@ -33,3 +33,8 @@ func (s *Service) GetMessages(channelID string, limit int) ([]*domain.Message, e
 func (s *Service) GetRecentMessages(channelID string, since time.Time) ([]*domain.Message, error) {
 	return s.repo.GetRecentMessages(channelID, since)
 }
+
+// IterateMessages walks messages for a channel, newest first, until fn returns false
+func (s *Service) IterateMessages(channelID string, fn func(message *domain.Message) bool) error {
+	return s.repo.IterateMessages(channelID, fn)
+}
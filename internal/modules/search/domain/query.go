@@ -0,0 +1,213 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	messageDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/domain"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/samber/oops"
+)
+
+// Query is a parsed search query. Every term and phrase must match;
+// excluded terms must not.
+type Query struct {
+	Raw      string
+	Terms    []string
+	Phrases  [][]string
+	Excluded []string
+	// Channels restricts results to these channel IDs or @usernames
+	Channels []string
+	// Since and Until bound the post date, zero means unbounded
+	Since time.Time
+	Until time.Time
+}
+
+// Hit is a message matching a query
+type Hit struct {
+	Message *messageDomain.Message `json:"message"`
+	Score   float64                `json:"score"`
+	Snippet string                 `json:"snippet"`
+}
+
+// Results is one page of hits, best first
+type Results struct {
+	Query  string `json:"query"`
+	Total  int    `json:"total"`
+	Offset int    `json:"offset"`
+	Hits   []Hit  `json:"hits"`
+}
+
+// ParseQuery parses words, "quoted phrases", -excluded words and the
+// channel:, since: and until: operators. Dates are YYYY-MM-DD; until is
+// inclusive.
+func ParseQuery(raw string) (*Query, error) {
+	query := &Query{Raw: strings.TrimSpace(raw)}
+
+	for _, part := range splitQuery(query.Raw) {
+		if part.quoted {
+			if words := Tokenize(part.text); len(words) > 0 {
+				query.Phrases = append(query.Phrases, words)
+			}
+			continue
+		}
+
+		if operator, value, found := strings.Cut(part.text, ":"); found && value != "" {
+			switch strings.ToLower(operator) {
+			case "channel":
+				query.Channels = append(query.Channels, value)
+				continue
+			case "since", "until":
+				date, err := time.Parse(time.DateOnly, value)
+				if err != nil {
+					return nil, oops.With("operator", operator, "value", value).Wrapf(errors.ErrInvalidRequest, "dates must be YYYY-MM-DD")
+				}
+				if strings.EqualFold(operator, "since") {
+					query.Since = date
+				} else {
+					query.Until = date.AddDate(0, 0, 1)
+				}
+				continue
+			}
+		}
+
+		if word, ok := strings.CutPrefix(part.text, "-"); ok {
+			query.Excluded = append(query.Excluded, Tokenize(word)...)
+			continue
+		}
+		query.Terms = append(query.Terms, Tokenize(part.text)...)
+	}
+
+	if len(query.Terms) == 0 && len(query.Phrases) == 0 {
+		return nil, oops.With("query", raw).Wrapf(errors.ErrInvalidRequest, "search query has no words")
+	}
+	return query, nil
+}
+
+// Required returns every distinct word that must appear in a match
+func (q *Query) Required() []string {
+	seen := map[string]bool{}
+	var words []string
+	for _, word := range q.Terms {
+		if !seen[word] {
+			seen[word] = true
+			words = append(words, word)
+		}
+	}
+	for _, phrase := range q.Phrases {
+		for _, word := range phrase {
+			if !seen[word] {
+				seen[word] = true
+				words = append(words, word)
+			}
+		}
+	}
+	return words
+}
+
+// Tokenize splits text into lowercase words of letters and digits
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// IndexedText returns the searchable text of a message: its text, the
+// captions and titles of its media, and the wording of polls and venues
+func IndexedText(message *messageDomain.Message) string {
+	parts := []string{message.Text}
+	for _, media := range message.Media {
+		if media.Caption != "" && media.Caption != message.Text {
+			parts = append(parts, media.Caption)
+		}
+		if media.Title != "" {
+			parts = append(parts, media.Title)
+		}
+		if media.Poll != nil {
+			parts = append(parts, media.Poll.Question)
+			for _, option := range media.Poll.Options {
+				parts = append(parts, option.Text)
+			}
+		}
+		if media.Venue != nil {
+			parts = append(parts, media.Venue.Title, media.Venue.Address)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// Snippet returns up to width runes of text around the first occurrence of
+// one of the words
+func Snippet(text string, words []string, width int) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	if len(runes) <= width {
+		return string(runes)
+	}
+
+	// Lowered rune by rune so offsets match the original text
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	match := -1
+	for _, word := range words {
+		if i := runeIndex(lower, []rune(word)); i >= 0 && (match < 0 || i < match) {
+			match = i
+		}
+	}
+	match = max(match, 0)
+
+	start := max(0, min(match-width/3, len(runes)-width))
+	end := min(len(runes), start+width)
+	snippet := string(runes[start:end])
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+func runeIndex(text, word []rune) int {
+	for i := 0; i+len(word) <= len(text); i++ {
+		if slices.Equal(text[i:i+len(word)], word) {
+			return i
+		}
+	}
+	return -1
+}
+
+type queryPart struct {
+	text   string
+	quoted bool
+}
+
+// splitQuery splits on whitespace, keeping double-quoted phrases together
+func splitQuery(raw string) []queryPart {
+	var parts []queryPart
+	var current strings.Builder
+	quoted := false
+	flush := func(isPhrase bool) {
+		if current.Len() > 0 {
+			parts = append(parts, queryPart{text: current.String(), quoted: isPhrase})
+			current.Reset()
+		}
+	}
+
+	for _, r := range raw {
+		switch {
+		case r == '"' || r == '“' || r == '”':
+			flush(quoted)
+			quoted = !quoted
+		case unicode.IsSpace(r) && !quoted:
+			flush(false)
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush(quoted)
+	return parts
+}
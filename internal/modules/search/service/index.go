@@ -0,0 +1,208 @@
+package service
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+
+	messageDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/domain"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/search/domain"
+	"github.com/samber/lo"
+)
+
+// BM25 parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+type docKey struct {
+	channelID string
+	messageID int64
+}
+
+type document struct {
+	date   time.Time
+	length int
+	terms  []string
+	files  []string
+}
+
+// index is an inverted index from words to the positions they occur at in
+// each message. It also counts the messages referring to each media file,
+// so the media proxy only serves files of stored messages. It is not safe
+// for concurrent use; Service guards it.
+type index struct {
+	docs        map[docKey]*document
+	postings    map[string]map[docKey][]int
+	files       map[string]int
+	totalLength int
+}
+
+func newIndex() *index {
+	return &index{
+		docs:     map[docKey]*document{},
+		postings: map[string]map[docKey][]int{},
+		files:    map[string]int{},
+	}
+}
+
+// add indexes a message, replacing an earlier version of it
+func (ix *index) add(message *messageDomain.Message) {
+	key := docKey{channelID: message.ChannelID, messageID: message.ID}
+	ix.remove(key)
+
+	words := domain.Tokenize(domain.IndexedText(message))
+	doc := &document{date: message.Date, length: len(words)}
+	for position, word := range words {
+		postings, ok := ix.postings[word]
+		if !ok {
+			postings = map[docKey][]int{}
+			ix.postings[word] = postings
+		}
+		if _, seen := postings[key]; !seen {
+			doc.terms = append(doc.terms, word)
+		}
+		postings[key] = append(postings[key], position)
+	}
+	for _, media := range message.Media {
+		for _, fileID := range []string{media.FileID, media.Thumbnail} {
+			if fileID != "" && !slices.Contains(doc.files, fileID) {
+				doc.files = append(doc.files, fileID)
+				ix.files[fileID]++
+			}
+		}
+	}
+
+	ix.docs[key] = doc
+	ix.totalLength += doc.length
+}
+
+func (ix *index) remove(key docKey) {
+	doc, ok := ix.docs[key]
+	if !ok {
+		return
+	}
+	for _, word := range doc.terms {
+		delete(ix.postings[word], key)
+		if len(ix.postings[word]) == 0 {
+			delete(ix.postings, word)
+		}
+	}
+	for _, fileID := range doc.files {
+		ix.files[fileID]--
+		if ix.files[fileID] == 0 {
+			delete(ix.files, fileID)
+		}
+	}
+	delete(ix.docs, key)
+	ix.totalLength -= doc.length
+}
+
+// removeWhere removes the messages of a channel matching fn
+func (ix *index) removeWhere(channelID string, fn func(doc *document) bool) {
+	for key, doc := range ix.docs {
+		if key.channelID == channelID && fn(doc) {
+			ix.remove(key)
+		}
+	}
+}
+
+type match struct {
+	key   docKey
+	date  time.Time
+	score float64
+}
+
+// search returns the messages matching the query in the given channels,
+// best first. A nil channel set allows every channel.
+func (ix *index) search(query *domain.Query, channels map[string]bool) []match {
+	required := query.Required()
+
+	// Start from the rarest word, so the candidate set is smallest
+	slices.SortFunc(required, func(a, b string) int {
+		return len(ix.postings[a]) - len(ix.postings[b])
+	})
+
+	var matches []match
+	for key := range ix.postings[required[0]] {
+		doc := ix.docs[key]
+		if channels != nil && !channels[key.channelID] {
+			continue
+		}
+		if !query.Since.IsZero() && doc.date.Before(query.Since) {
+			continue
+		}
+		if !query.Until.IsZero() && !doc.date.Before(query.Until) {
+			continue
+		}
+		if !ix.containsAll(key, required[1:]) || ix.containsAny(key, query.Excluded) {
+			continue
+		}
+		if !lo.EveryBy(query.Phrases, func(phrase []string) bool { return ix.containsPhrase(key, phrase) }) {
+			continue
+		}
+
+		matches = append(matches, match{key: key, date: doc.date, score: ix.score(key, doc, required)})
+	}
+
+	slices.SortFunc(matches, func(a, b match) int {
+		if order := cmp.Compare(b.score, a.score); order != 0 {
+			return order
+		}
+		return b.date.Compare(a.date)
+	})
+	return matches
+}
+
+func (ix *index) containsAll(key docKey, words []string) bool {
+	for _, word := range words {
+		if _, ok := ix.postings[word][key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (ix *index) containsAny(key docKey, words []string) bool {
+	for _, word := range words {
+		if _, ok := ix.postings[word][key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// containsPhrase reports whether the words occur consecutively in a message
+func (ix *index) containsPhrase(key docKey, phrase []string) bool {
+	for _, start := range ix.postings[phrase[0]][key] {
+		found := true
+		for offset, word := range phrase[1:] {
+			if !slices.Contains(ix.postings[word][key], start+offset+1) {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// score ranks a message with BM25 over the query words
+func (ix *index) score(key docKey, doc *document, words []string) float64 {
+	total := float64(len(ix.docs))
+	averageLength := float64(ix.totalLength) / max(total, 1)
+
+	score := 0.0
+	for _, word := range words {
+		frequency := float64(len(ix.postings[word][key]))
+		documents := float64(len(ix.postings[word]))
+		idf := math.Log(1 + (total-documents+0.5)/(documents+0.5))
+		score += idf * frequency * (bm25K1 + 1) /
+			(frequency + bm25K1*(1-bm25B+bm25B*float64(doc.length)/max(averageLength, 1)))
+	}
+	return score
+}
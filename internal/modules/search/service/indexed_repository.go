@@ -0,0 +1,50 @@
+package service
+
+import (
+	"time"
+
+	messageDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/domain"
+	messageRepo "github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/repository"
+)
+
+// IndexedRepository wraps a message repository and updates the search index
+// whenever messages are saved or deleted through it
+type IndexedRepository struct {
+	messageRepo.Repository
+	search *Service
+}
+
+// NewIndexedRepository wraps repo so its changes are indexed by search
+func NewIndexedRepository(repo messageRepo.Repository, search *Service) messageRepo.Repository {
+	return &IndexedRepository{Repository: repo, search: search}
+}
+
+func (r *IndexedRepository) SaveMessage(message *messageDomain.Message) error {
+	if err := r.Repository.SaveMessage(message); err != nil {
+		return err
+	}
+	r.search.update(func(index *index) { index.add(message) })
+	return nil
+}
+
+func (r *IndexedRepository) DeleteMessagesBefore(channelID string, before time.Time) (int, error) {
+	deleted, err := r.Repository.DeleteMessagesBefore(channelID, before)
+	if err != nil {
+		// Entries of messages deleted before the error are skipped by Search
+		return deleted, err
+	}
+	r.search.update(func(index *index) {
+		index.removeWhere(channelID, func(doc *document) bool { return doc.date.Before(before) })
+	})
+	return deleted, nil
+}
+
+func (r *IndexedRepository) DeleteChannelMessages(channelID string) error {
+	if err := r.Repository.DeleteChannelMessages(channelID); err != nil {
+		return err
+	}
+	r.search.update(func(index *index) {
+		index.removeWhere(channelID, func(doc *document) bool { return true })
+	})
+	return nil
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	channelDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/domain"
+	channelRepo "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/repository"
+	messageDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/domain"
+	messageRepo "github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/repository"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/search/domain"
+	appErrors "github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/samber/oops"
+)
+
+// snippetWidth is the length of hit snippets in runes
+const snippetWidth = 200
+
+// Service keeps a full-text index of stored messages and answers queries.
+// The index lives in memory: it is built from storage on first use and kept
+// current by IndexedRepository as messages are saved and deleted.
+type Service struct {
+	channelRepo channelRepo.Repository
+	messageRepo messageRepo.Repository
+
+	mu    sync.RWMutex
+	index *index
+	built bool
+}
+
+// New creates a new search service. messageRepo must be the underlying
+// repository, not the IndexedRepository wrapping it.
+func New(channelRepo channelRepo.Repository, messageRepo messageRepo.Repository) *Service {
+	return &Service{
+		channelRepo: channelRepo,
+		messageRepo: messageRepo,
+		index:       newIndex(),
+	}
+}
+
+// Build indexes every stored message. Search builds the index on first use;
+// calling Build at startup moves that delay out of the first query.
+func (s *Service) Build() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.build()
+}
+
+func (s *Service) build() error {
+	started := time.Now()
+	index := newIndex()
+
+	channels, err := s.channelRepo.GetAllChannels()
+	if err != nil {
+		return oops.With("context", "failed to load channels").Wrap(err)
+	}
+	for _, channel := range channels {
+		if err := s.messageRepo.IterateMessages(channel.ID, func(message *messageDomain.Message) bool {
+			index.add(message)
+			return true
+		}); err != nil {
+			return oops.With("channel_id", channel.ID, "context", "failed to index messages").Wrap(err)
+		}
+	}
+
+	s.index = index
+	s.built = true
+	slog.Info("Search index built", "messages", len(index.docs), "words", len(index.postings), "duration", time.Since(started))
+	return nil
+}
+
+// ensureBuilt builds the index if that has not happened yet
+func (s *Service) ensureBuilt() error {
+	s.mu.RLock()
+	built := s.built
+	s.mu.RUnlock()
+	if built {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.built {
+		return nil
+	}
+	return s.build()
+}
+
+// update applies a change to the index once it is built. Before that the
+// change is already in storage, where the build will pick it up.
+func (s *Service) update(fn func(index *index)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.built {
+		fn(s.index)
+	}
+}
+
+// HasMedia reports whether a stored message refers to the media file, as its
+// file or its thumbnail
+func (s *Service) HasMedia(fileID string) (bool, error) {
+	if err := s.ensureBuilt(); err != nil {
+		return false, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.index.files[fileID] > 0, nil
+}
+
+// Search returns one page of messages matching the query, best first.
+// Only messages of the given channels are searched; the query's channel:
+// operators narrow them further.
+func (s *Service) Search(query *domain.Query, channels []*channelDomain.Channel, offset, limit int) (*domain.Results, error) {
+	if err := s.ensureBuilt(); err != nil {
+		return nil, err
+	}
+
+	allowed := map[string]bool{}
+	for _, channel := range channels {
+		if matchesChannelRefs(channel, query.Channels) {
+			allowed[channel.ID] = true
+		}
+	}
+
+	s.mu.RLock()
+	matches := s.index.search(query, allowed)
+	s.mu.RUnlock()
+
+	results := &domain.Results{Query: query.Raw, Total: len(matches), Offset: offset, Hits: []domain.Hit{}}
+	if offset >= len(matches) {
+		return results, nil
+	}
+
+	words := query.Required()
+	for _, match := range matches[offset:min(offset+limit, len(matches))] {
+		message, err := s.messageRepo.GetMessage(match.key.channelID, match.key.messageID)
+		if err != nil {
+			// Deleted since the search ran
+			if errors.Is(err, appErrors.ErrMessageNotFound) {
+				continue
+			}
+			return nil, oops.With("channel_id", match.key.channelID, "message_id", match.key.messageID, "context", "failed to load search hit").Wrap(err)
+		}
+
+		results.Hits = append(results.Hits, domain.Hit{
+			Message: message,
+			Score:   match.score,
+			Snippet: domain.Snippet(domain.IndexedText(message), words, snippetWidth),
+		})
+	}
+	return results, nil
+}
+
+// matchesChannelRefs reports whether a channel is one of the channel IDs or
+// @usernames, or whether refs is empty
+func matchesChannelRefs(channel *channelDomain.Channel, refs []string) bool {
+	if len(refs) == 0 {
+		return true
+	}
+	for _, ref := range refs {
+		if ref == channel.ID || strings.EqualFold(strings.TrimPrefix(ref, "@"), channel.Username) {
+			return true
+		}
+	}
+	return false
+}
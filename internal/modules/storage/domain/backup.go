@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+const (
+	// BackupFormatVersion is bumped whenever the archive layout changes.
+	// Restore refuses archives written with a newer version. Version 2
+	// stores records in their versioned envelope; version 1 records are
+	// bare and upgraded on restore.
+	BackupFormatVersion = 2
+	// BackupManifestName is the manifest entry, written last in the archive
+	BackupManifestName = "manifest.json"
+)
+
+// BackupManifest describes the contents of a backup archive
+type BackupManifest struct {
+	FormatVersion int          `json:"format_version"`
+	CreatedAt     time.Time    `json:"created_at"`
+	Channels      int          `json:"channels"`
+	Collections   int          `json:"collections"`
+	Users         int          `json:"users"`
+	Messages      int          `json:"messages"`
+	Media         int          `json:"media"`
+	Files         []BackupFile `json:"files"`
+}
+
+// BackupFile is a checksummed archive entry
+type BackupFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
@@ -0,0 +1,11 @@
+//go:generate go run github.com/abice/go-enum --file=$GOFILE --names --nocase
+
+package domain
+
+// IntegrityAction is what the startup integrity scan does with corrupt records
+// ENUM(report,quarantine)
+type IntegrityAction string
+
+// Backend is a storage implementation for channels, messages and users
+// ENUM(file)
+type Backend string
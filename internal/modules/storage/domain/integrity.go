@@ -0,0 +1,19 @@
+package domain
+
+// QuarantineDir is the directory under the storage path that receives
+// corrupt records, keeping their relative paths
+const QuarantineDir = "quarantine"
+
+// IntegrityReport is the result of scanning stored records
+type IntegrityReport struct {
+	Scanned          int             `json:"scanned"`
+	Corrupt          []CorruptRecord `json:"corrupt"`
+	Quarantined      int             `json:"quarantined"`
+	TempFilesRemoved int             `json:"temp_files_removed"`
+}
+
+// CorruptRecord is a stored file that cannot be decoded
+type CorruptRecord struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
@@ -0,0 +1,9 @@
+package domain
+
+// MigrationReport counts the records rewritten by a storage migration
+type MigrationReport struct {
+	Channels    int `json:"channels"`
+	Collections int `json:"collections"`
+	Users       int `json:"users"`
+	Messages    int `json:"messages"`
+}
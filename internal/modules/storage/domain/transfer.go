@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Record kinds compared when verifying a transfer
+const (
+	KindUsers       = "users"
+	KindCollections = "collections"
+	KindChannels    = "channels"
+	KindMessages    = "messages"
+)
+
+// BackendSpec identifies a storage backend and its location, written as
+// "<backend>:<path>", e.g. "file:./data"
+type BackendSpec struct {
+	Backend Backend
+	Path    string
+}
+
+// ParseBackendSpec parses "<backend>:<path>". A bare path means the file backend.
+func ParseBackendSpec(value string) (BackendSpec, error) {
+	name, path, found := strings.Cut(value, ":")
+	if !found {
+		return BackendSpec{Backend: BackendFile, Path: value}, nil
+	}
+	backend, err := ParseBackend(name)
+	if err != nil {
+		return BackendSpec{}, err
+	}
+	if path == "" {
+		return BackendSpec{}, fmt.Errorf("missing path in backend %q", value)
+	}
+	return BackendSpec{Backend: backend, Path: path}, nil
+}
+
+func (b BackendSpec) String() string {
+	return fmt.Sprintf("%s:%s", b.Backend, b.Path)
+}
+
+// KindSummary is the record count and combined checksum of one record kind
+type KindSummary struct {
+	Count    int    `json:"count"`
+	Checksum string `json:"checksum"`
+}
+
+// TransferState is the checkpoint of a transfer between backends, saved
+// after every step so an interrupted transfer can resume
+type TransferState struct {
+	From            string    `json:"from"`
+	To              string    `json:"to"`
+	UsersDone       bool      `json:"users_done"`
+	CollectionsDone bool      `json:"collections_done"`
+	ChannelsDone    []string  `json:"channels_done"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// TransferReport is the result of a transfer or dry run
+type TransferReport struct {
+	DryRun      bool                   `json:"dry_run"`
+	Resumed     bool                   `json:"resumed"`
+	Source      map[string]KindSummary `json:"source"`
+	Destination map[string]KindSummary `json:"destination,omitempty"`
+	Copied      map[string]int         `json:"copied"`
+	Skipped     int                    `json:"skipped_channels"`
+	Verified    bool                   `json:"verified"`
+}
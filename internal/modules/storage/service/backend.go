@@ -0,0 +1,39 @@
+package service
+
+import (
+	channelRepo "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/repository"
+	messageRepo "github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/repository"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/storage/domain"
+	userRepo "github.com/reshetovitsme/rss-telegram-feed/internal/modules/user/repository"
+	"github.com/samber/oops"
+)
+
+// Backend groups the repositories of one storage backend
+type Backend struct {
+	Spec     domain.BackendSpec
+	Channels channelRepo.Repository
+	Messages messageRepo.Repository
+	Users    userRepo.Repository
+}
+
+// OpenBackend creates the repositories for a storage backend
+func OpenBackend(spec domain.BackendSpec) (*Backend, error) {
+	switch spec.Backend {
+	case domain.BackendFile:
+		channels, err := channelRepo.NewFileStorage(spec.Path)
+		if err != nil {
+			return nil, oops.With("backend", spec.String(), "context", "failed to initialize channel repository").Wrap(err)
+		}
+		messages, err := messageRepo.NewFileStorage(spec.Path)
+		if err != nil {
+			return nil, oops.With("backend", spec.String(), "context", "failed to initialize message repository").Wrap(err)
+		}
+		users, err := userRepo.NewFileStorage(spec.Path)
+		if err != nil {
+			return nil, oops.With("backend", spec.String(), "context", "failed to initialize user repository").Wrap(err)
+		}
+		return &Backend{Spec: spec, Channels: channels, Messages: messages, Users: users}, nil
+	default:
+		return nil, oops.With("backend", spec.Backend).Errorf("unsupported storage backend")
+	}
+}
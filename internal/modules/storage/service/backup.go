@@ -0,0 +1,528 @@
+package service
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	channelDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/domain"
+	mediaDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/media/domain"
+	messageDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/domain"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/storage/domain"
+	userDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/user/domain"
+	appErrors "github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/fileutil"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/schema"
+	"github.com/samber/lo"
+	"github.com/samber/oops"
+)
+
+const (
+	backupPrefix = "rss-telegram-feed-"
+	backupSuffix = ".tar.gz"
+	// backupCheckInterval is how often the scheduler checks whether a backup is due
+	backupCheckInterval = time.Minute
+)
+
+// Start runs scheduled backups until ctx is cancelled. The interval is read
+// from the config on every check, so reloads apply without a restart.
+func (s *Service) Start(ctx context.Context) {
+	ticker := time.NewTicker(backupCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.runScheduledBackup()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) runScheduledBackup() {
+	cfg := s.cfg.Get()
+	if cfg.BackupInterval <= 0 {
+		return
+	}
+
+	backups, err := listBackups(cfg.BackupPath)
+	if err != nil {
+		slog.Error("Failed to list backups", "backup_path", cfg.BackupPath, "error", err)
+		return
+	}
+	if len(backups) > 0 {
+		info, err := os.Stat(backups[len(backups)-1])
+		if err == nil && time.Since(info.ModTime()) < time.Duration(cfg.BackupInterval)*time.Hour {
+			return
+		}
+	}
+
+	path, manifest, err := s.CreateBackup()
+	if err != nil {
+		slog.Error("Scheduled backup failed", "error", err)
+		return
+	}
+	slog.Info("Scheduled backup created", "path", path, "channels", manifest.Channels, "messages", manifest.Messages)
+
+	if cfg.BackupKeep > 0 {
+		backups, err := listBackups(cfg.BackupPath)
+		if err != nil {
+			slog.Error("Failed to list backups", "backup_path", cfg.BackupPath, "error", err)
+			return
+		}
+		for _, old := range backups[:max(0, len(backups)-cfg.BackupKeep)] {
+			if err := os.Remove(old); err != nil {
+				slog.Error("Failed to remove old backup", "path", old, "error", err)
+			}
+		}
+	}
+}
+
+// CreateBackup writes a timestamped backup archive to the configured backup
+// directory and returns its path
+func (s *Service) CreateBackup() (string, *domain.BackupManifest, error) {
+	dir := s.cfg.Get().BackupPath
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", nil, oops.With("backup_path", dir, "context", "failed to create backup directory").Wrap(err)
+	}
+
+	path := filepath.Join(dir, backupPrefix+time.Now().UTC().Format("20060102-150405")+backupSuffix)
+	manifest, err := s.BackupToFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+	return path, manifest, nil
+}
+
+// BackupToFile writes a backup archive to path. The archive is written to a
+// temporary file first so an interrupted backup never leaves a partial archive.
+func (s *Service) BackupToFile(path string) (*domain.BackupManifest, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".backup-*")
+	if err != nil {
+		return nil, oops.With("path", path, "context", "failed to create backup file").Wrap(err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	manifest, err := s.Backup(tmp)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmp.Sync(); err != nil {
+		return nil, oops.With("path", path, "context", "failed to sync backup file").Wrap(err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, oops.With("path", path, "context", "failed to close backup file").Wrap(err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, oops.With("path", path, "context", "failed to move backup into place").Wrap(err)
+	}
+
+	return manifest, nil
+}
+
+// Backup writes users, channels, collections, messages and media as a
+// gzip-compressed tar archive to w. Records are read through the repositories,
+// so each one is a complete, consistent copy even while the bot is writing.
+// The manifest with the checksum of every entry is written last.
+func (s *Service) Backup(w io.Writer) (*domain.BackupManifest, error) {
+	gz := gzip.NewWriter(w)
+	archive := &archiveWriter{
+		tar:      tar.NewWriter(gz),
+		modTime:  time.Now().UTC(),
+		manifest: &domain.BackupManifest{FormatVersion: domain.BackupFormatVersion, Files: []domain.BackupFile{}},
+	}
+	archive.manifest.CreatedAt = archive.modTime
+	manifest := archive.manifest
+
+	users, err := s.userRepo.GetAllUsers()
+	if err != nil {
+		return nil, oops.With("context", "failed to load users").Wrap(err)
+	}
+	for _, user := range users {
+		if err := archive.addRecord(fmt.Sprintf("users/%d.json", user.ID), userDomain.Schema, user); err != nil {
+			return nil, err
+		}
+		manifest.Users++
+	}
+
+	collections, err := s.channelRepo.GetAllCollections()
+	if err != nil {
+		return nil, oops.With("context", "failed to load collections").Wrap(err)
+	}
+	for _, collection := range collections {
+		if err := archive.addRecord(fmt.Sprintf("collections/%s.json", collection.Name), channelDomain.CollectionSchema, collection); err != nil {
+			return nil, err
+		}
+		manifest.Collections++
+	}
+
+	channels, err := s.channelRepo.GetAllChannels()
+	if err != nil {
+		return nil, oops.With("context", "failed to load channels").Wrap(err)
+	}
+	for _, channel := range channels {
+		if err := archive.addRecord(fmt.Sprintf("channels/%s.json", channel.ID), channelDomain.ChannelSchema, channel); err != nil {
+			return nil, err
+		}
+		manifest.Channels++
+
+		var addErr error
+		err := s.messageRepo.IterateMessages(channel.ID, func(message *messageDomain.Message) bool {
+			if addErr = archive.addRecord(fmt.Sprintf("messages/%s/%d.json", channel.ID, message.ID), messageDomain.Schema, message); addErr != nil {
+				return false
+			}
+			manifest.Messages++
+			return true
+		})
+		if err != nil {
+			return nil, oops.With("channel_id", channel.ID, "context", "failed to load messages").Wrap(err)
+		}
+		if addErr != nil {
+			return nil, addErr
+		}
+	}
+
+	mediaDir := s.mediaDir()
+	err = filepath.WalkDir(mediaDir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && filePath == mediaDir {
+				return fs.SkipDir
+			}
+			return err
+		}
+		// Thumbnails are regenerated on demand
+		if entry.IsDir() && filePath == filepath.Join(mediaDir, mediaDomain.ThumbnailDir) {
+			return fs.SkipDir
+		}
+		if !entry.Type().IsRegular() || fileutil.IsTempFile(entry.Name()) {
+			return nil
+		}
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(mediaDir, filePath)
+		if err != nil {
+			return err
+		}
+		manifest.Media++
+		return archive.add(path.Join("media", filepath.ToSlash(rel)), data)
+	})
+	if err != nil {
+		return nil, oops.With("media_dir", mediaDir, "context", "failed to archive media").Wrap(err)
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, oops.With("context", "failed to marshal backup manifest").Wrap(err)
+	}
+	if err := archive.write(domain.BackupManifestName, manifestData); err != nil {
+		return nil, err
+	}
+
+	if err := archive.tar.Close(); err != nil {
+		return nil, oops.With("context", "failed to finish backup archive").Wrap(err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, oops.With("context", "failed to compress backup archive").Wrap(err)
+	}
+
+	return manifest, nil
+}
+
+// Restore replaces all stored data with the contents of a backup archive.
+// The whole archive is verified against its manifest before anything is
+// changed, and the current data is backed up to the backup directory first.
+func (s *Service) Restore(path string) (*domain.BackupManifest, error) {
+	manifest, err := VerifyBackup(path)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, _, err := s.CreateBackup()
+	if err != nil {
+		return nil, oops.With("context", "failed to back up current data before restore").Wrap(err)
+	}
+	slog.Info("Backed up current data before restore", "path", previous)
+
+	if err := s.clear(); err != nil {
+		return nil, err
+	}
+
+	err = readBackup(path, func(name string, data []byte) error {
+		if name == domain.BackupManifestName {
+			return nil
+		}
+		return s.restoreEntry(name, data)
+	})
+	if err != nil {
+		return nil, oops.With("path", path, "previous_data", previous, "context", "restore failed, previous data is in the pre-restore backup").Wrap(err)
+	}
+
+	return manifest, nil
+}
+
+// VerifyBackup checks a backup archive against its manifest without restoring
+// it. Archives written with a newer format version are rejected.
+func VerifyBackup(path string) (*domain.BackupManifest, error) {
+	var manifest *domain.BackupManifest
+	sums := make(map[string]string)
+
+	err := readBackup(path, func(name string, data []byte) error {
+		if name == domain.BackupManifestName {
+			manifest = &domain.BackupManifest{}
+			if err := json.Unmarshal(data, manifest); err != nil {
+				return oops.With("path", path, "context", "invalid manifest").Wrap(appErrors.ErrBackupCorrupt)
+			}
+			return nil
+		}
+		sum := sha256.Sum256(data)
+		sums[name] = hex.EncodeToString(sum[:])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest == nil {
+		return nil, oops.With("path", path, "context", "manifest missing").Wrap(appErrors.ErrBackupCorrupt)
+	}
+	if manifest.FormatVersion < 1 || manifest.FormatVersion > domain.BackupFormatVersion {
+		return nil, oops.With("path", path, "format_version", manifest.FormatVersion, "supported_version", domain.BackupFormatVersion).Wrap(appErrors.ErrBackupVersion)
+	}
+	if len(manifest.Files) != len(sums) {
+		return nil, oops.With("path", path, "manifest_files", len(manifest.Files), "archive_files", len(sums)).Wrap(appErrors.ErrBackupCorrupt)
+	}
+	for _, file := range manifest.Files {
+		if sums[file.Path] != file.SHA256 {
+			return nil, oops.With("path", path, "entry", file.Path, "context", "checksum mismatch").Wrap(appErrors.ErrBackupCorrupt)
+		}
+	}
+
+	return manifest, nil
+}
+
+// readBackup calls fn with the name and contents of every archive entry
+func readBackup(path string, fn func(name string, data []byte) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return oops.With("path", path, "context", "failed to open backup").Wrap(err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return oops.With("path", path, "context", "not a gzip archive").Wrap(appErrors.ErrBackupCorrupt)
+	}
+	defer gz.Close()
+
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return oops.With("path", path, "context", "failed to read archive").Wrap(appErrors.ErrBackupCorrupt)
+		}
+		if header.Typeflag == tar.TypeDir {
+			continue
+		}
+		if header.Typeflag != tar.TypeReg || !validEntryName(header.Name) {
+			return oops.With("path", path, "entry", header.Name, "context", "unexpected archive entry").Wrap(appErrors.ErrBackupCorrupt)
+		}
+
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return oops.With("path", path, "entry", header.Name, "context", "failed to read archive entry").Wrap(appErrors.ErrBackupCorrupt)
+		}
+		if err := fn(header.Name, data); err != nil {
+			return err
+		}
+	}
+}
+
+// validEntryName rejects absolute paths and paths escaping the archive root
+func validEntryName(name string) bool {
+	return name != "" && !path.IsAbs(name) && path.Clean(name) == name && !strings.HasPrefix(name, "../") && name != ".."
+}
+
+// clear removes all stored records and media ahead of a restore
+func (s *Service) clear() error {
+	channels, err := s.channelRepo.GetAllChannels()
+	if err != nil {
+		return oops.With("context", "failed to load channels").Wrap(err)
+	}
+	for _, channel := range channels {
+		if err := s.messageRepo.DeleteChannelMessages(channel.ID); err != nil {
+			return err
+		}
+		if err := s.channelRepo.DeleteChannel(channel.ID); err != nil {
+			return oops.With("channel_id", channel.ID, "context", "failed to delete channel").Wrap(err)
+		}
+	}
+
+	collections, err := s.channelRepo.GetAllCollections()
+	if err != nil {
+		return oops.With("context", "failed to load collections").Wrap(err)
+	}
+	for _, collection := range collections {
+		if err := s.channelRepo.DeleteCollection(collection.Name); err != nil {
+			return oops.With("collection", collection.Name, "context", "failed to delete collection").Wrap(err)
+		}
+	}
+
+	users, err := s.userRepo.GetAllUsers()
+	if err != nil {
+		return oops.With("context", "failed to load users").Wrap(err)
+	}
+	for _, user := range users {
+		if err := s.userRepo.DeleteUser(user.ID); err != nil {
+			return oops.With("user_id", user.ID, "context", "failed to delete user").Wrap(err)
+		}
+	}
+
+	if err := os.RemoveAll(s.mediaDir()); err != nil {
+		return oops.With("media_dir", s.mediaDir(), "context", "failed to delete media").Wrap(err)
+	}
+	return nil
+}
+
+// restoreEntry saves one archive entry through its repository
+func (s *Service) restoreEntry(name string, data []byte) error {
+	kind, rest, _ := strings.Cut(name, "/")
+	switch kind {
+	case "users":
+		var user userDomain.User
+		if err := restoreDecode(name, userDomain.Schema, data, &user); err != nil {
+			return err
+		}
+		return s.userRepo.SaveUser(&user)
+	case "channels":
+		var channel channelDomain.Channel
+		if err := restoreDecode(name, channelDomain.ChannelSchema, data, &channel); err != nil {
+			return err
+		}
+		return s.channelRepo.SaveChannel(&channel)
+	case "collections":
+		var collection channelDomain.Collection
+		if err := restoreDecode(name, channelDomain.CollectionSchema, data, &collection); err != nil {
+			return err
+		}
+		return s.channelRepo.SaveCollection(&collection)
+	case "messages":
+		var message messageDomain.Message
+		if err := restoreDecode(name, messageDomain.Schema, data, &message); err != nil {
+			return err
+		}
+		return s.messageRepo.SaveMessage(&message)
+	case "media":
+		target := filepath.Join(s.mediaDir(), filepath.FromSlash(rest))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return oops.With("entry", name, "context", "failed to create media directory").Wrap(err)
+		}
+		return fileutil.WriteFileAtomic(target, data, 0644)
+	default:
+		return oops.With("entry", name, "context", "unknown archive entry").Wrap(appErrors.ErrBackupCorrupt)
+	}
+}
+
+// restoreDecode decodes an archived record, upgrading records from older
+// archives and versions
+func restoreDecode(name string, registry *schema.Registry, data []byte, record any) error {
+	if _, err := registry.Unmarshal(data, record); err != nil {
+		if errors.Is(err, appErrors.ErrSchemaVersion) {
+			return oops.With("entry", name).Wrap(err)
+		}
+		return oops.With("entry", name).Wrapf(appErrors.ErrBackupCorrupt, "%v", err)
+	}
+	return nil
+}
+
+func (s *Service) mediaDir() string {
+	return filepath.Join(s.cfg.Get().StoragePath, mediaDomain.Dir)
+}
+
+// listBackups returns backup archives in dir, oldest first
+func listBackups(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	backups := lo.FilterMap(entries, func(entry os.DirEntry, _ int) (string, bool) {
+		name := entry.Name()
+		return filepath.Join(dir, name), !entry.IsDir() && strings.HasPrefix(name, backupPrefix) && strings.HasSuffix(name, backupSuffix)
+	})
+	// Names embed a sortable UTC timestamp
+	slices.Sort(backups)
+	return backups, nil
+}
+
+// archiveWriter writes tar entries and records their checksums in the manifest
+type archiveWriter struct {
+	tar      *tar.Writer
+	modTime  time.Time
+	manifest *domain.BackupManifest
+}
+
+// addRecord writes a record in the same versioned envelope used in storage
+func (a *archiveWriter) addRecord(name string, registry *schema.Registry, record any) error {
+	data, err := registry.Marshal(record)
+	if err != nil {
+		return oops.With("entry", name, "context", "failed to marshal backup entry").Wrap(err)
+	}
+	return a.add(name, data)
+}
+
+// add writes a checksummed entry
+func (a *archiveWriter) add(name string, data []byte) error {
+	if err := a.write(name, data); err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	a.manifest.Files = append(a.manifest.Files, domain.BackupFile{
+		Path:   name,
+		Size:   int64(len(data)),
+		SHA256: hex.EncodeToString(sum[:]),
+	})
+	return nil
+}
+
+// write writes an entry without recording it in the manifest
+func (a *archiveWriter) write(name string, data []byte) error {
+	header := &tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  a.modTime,
+		Typeflag: tar.TypeReg,
+	}
+	if err := a.tar.WriteHeader(header); err != nil {
+		return oops.With("entry", name, "context", "failed to write archive header").Wrap(err)
+	}
+	if _, err := a.tar.Write(data); err != nil {
+		return oops.With("entry", name, "context", "failed to write archive entry").Wrap(err)
+	}
+	return nil
+}
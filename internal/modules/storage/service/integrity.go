@@ -0,0 +1,121 @@
+package service
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	channelDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/domain"
+	messageDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/domain"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/storage/domain"
+	userDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/user/domain"
+	appErrors "github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/fileutil"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/schema"
+	"github.com/samber/oops"
+)
+
+// staleTempFileAge protects temporary files of a write still in progress in
+// another process from being removed by the scan
+const staleTempFileAge = time.Minute
+
+// recordDirs are the storage directories holding JSON records, with a
+// decoder for the record type in each
+var recordDirs = []struct {
+	name   string
+	decode func(data []byte) error
+}{
+	{"users", decodeWith[userDomain.User](userDomain.Schema)},
+	{"channels", decodeWith[channelDomain.Channel](channelDomain.ChannelSchema)},
+	{"collections", decodeWith[channelDomain.Collection](channelDomain.CollectionSchema)},
+	{"messages", decodeWith[messageDomain.Message](messageDomain.Schema)},
+}
+
+// decodeWith returns a decoder of records of type T through their schema
+func decodeWith[T any](registry *schema.Registry) func(data []byte) error {
+	return func(data []byte) error {
+		_, err := registry.Unmarshal(data, new(T))
+		return err
+	}
+}
+
+// CheckIntegrity decodes every stored record and logs and reports those that
+// are corrupt. With quarantine set, corrupt records are moved to the quarantine
+// directory so the rest of the data stays readable. Temporary files left
+// behind by interrupted writes are removed.
+func (s *Service) CheckIntegrity(quarantine bool) (*domain.IntegrityReport, error) {
+	root := s.cfg.Get().StoragePath
+	report := &domain.IntegrityReport{Corrupt: []domain.CorruptRecord{}}
+
+	for _, dir := range recordDirs {
+		err := filepath.WalkDir(filepath.Join(root, dir.name), func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if entry.IsDir() {
+				return nil
+			}
+
+			if fileutil.IsTempFile(entry.Name()) {
+				info, err := entry.Info()
+				if err != nil || time.Since(info.ModTime()) < staleTempFileAge {
+					return nil
+				}
+				if err := os.Remove(path); err != nil {
+					return err
+				}
+				report.TempFilesRemoved++
+				return nil
+			}
+			if filepath.Ext(entry.Name()) != ".json" {
+				return nil
+			}
+
+			report.Scanned++
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			decodeErr := dir.decode(data)
+			if decodeErr == nil {
+				return nil
+			}
+
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			report.Corrupt = append(report.Corrupt, domain.CorruptRecord{Path: rel, Error: decodeErr.Error()})
+			slog.Error("Corrupt record", "path", rel, "error", decodeErr)
+			// Records from a newer version are intact, just not readable by
+			// this one, so they are left in place
+			if !quarantine || errors.Is(decodeErr, appErrors.ErrSchemaVersion) {
+				return nil
+			}
+
+			target := filepath.Join(root, domain.QuarantineDir, rel)
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := os.Rename(path, target); err != nil {
+				return err
+			}
+			report.Quarantined++
+			slog.Warn("Moved corrupt record to quarantine", "path", rel, "quarantine", target)
+			return nil
+		})
+		if err != nil {
+			return report, oops.With("storage_path", root, "dir", dir.name, "context", "integrity scan failed").Wrap(err)
+		}
+	}
+
+	slog.Info("Storage integrity scan finished",
+		"scanned", report.Scanned, "corrupt", len(report.Corrupt), "quarantined", report.Quarantined, "temp_files_removed", report.TempFilesRemoved)
+	return report, nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	channelRepo "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/repository"
+	messageDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/domain"
+	messageRepo "github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/repository"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/storage/domain"
+	userRepo "github.com/reshetovitsme/rss-telegram-feed/internal/modules/user/repository"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/config"
+	"github.com/samber/oops"
+)
+
+// Service maintains the stored data as a whole
+type Service struct {
+	cfg         *config.Store
+	channelRepo channelRepo.Repository
+	messageRepo messageRepo.Repository
+	userRepo    userRepo.Repository
+}
+
+// New creates a new storage service
+func New(cfg *config.Store, channelRepo channelRepo.Repository, messageRepo messageRepo.Repository, userRepo userRepo.Repository) *Service {
+	return &Service{
+		cfg:         cfg,
+		channelRepo: channelRepo,
+		messageRepo: messageRepo,
+		userRepo:    userRepo,
+	}
+}
+
+// Migrate rewrites every stored record in the current schema version.
+// Repositories upgrade older records as they read them, so saving each one
+// back applies the upgrades eagerly instead of on every read.
+func (s *Service) Migrate() (*domain.MigrationReport, error) {
+	report := &domain.MigrationReport{}
+
+	users, err := s.userRepo.GetAllUsers()
+	if err != nil {
+		return report, oops.With("context", "failed to load users").Wrap(err)
+	}
+	for _, user := range users {
+		if err := s.userRepo.SaveUser(user); err != nil {
+			return report, oops.With("user_id", user.ID, "context", "failed to migrate user").Wrap(err)
+		}
+		report.Users++
+	}
+
+	collections, err := s.channelRepo.GetAllCollections()
+	if err != nil {
+		return report, oops.With("context", "failed to load collections").Wrap(err)
+	}
+	for _, collection := range collections {
+		if err := s.channelRepo.SaveCollection(collection); err != nil {
+			return report, oops.With("collection", collection.Name, "context", "failed to migrate collection").Wrap(err)
+		}
+		report.Collections++
+	}
+
+	channels, err := s.channelRepo.GetAllChannels()
+	if err != nil {
+		return report, oops.With("context", "failed to load channels").Wrap(err)
+	}
+	for _, channel := range channels {
+		if err := s.channelRepo.SaveChannel(channel); err != nil {
+			return report, oops.With("channel_id", channel.ID, "context", "failed to migrate channel").Wrap(err)
+		}
+		report.Channels++
+
+		var saveErr error
+		if err := s.messageRepo.IterateMessages(channel.ID, func(message *messageDomain.Message) bool {
+			if saveErr = s.messageRepo.SaveMessage(message); saveErr != nil {
+				saveErr = oops.With("channel_id", channel.ID, "message_id", message.ID, "context", "failed to migrate message").Wrap(saveErr)
+				return false
+			}
+			report.Messages++
+			return true
+		}); err != nil {
+			return report, oops.With("channel_id", channel.ID, "context", "failed to load messages").Wrap(err)
+		}
+		if saveErr != nil {
+			return report, saveErr
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	messageDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/domain"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/storage/domain"
+	appErrors "github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/fileutil"
+	"github.com/samber/lo"
+	"github.com/samber/oops"
+)
+
+// Transfer copies every user, collection, channel and message from src to
+// dst, then verifies counts and checksums on both sides. Progress is saved to
+// statePath after every step, so running it again after an interruption
+// resumes where it stopped. A dry run only summarizes the source.
+func Transfer(src, dst *Backend, statePath string, dryRun bool) (*domain.TransferReport, error) {
+	report := &domain.TransferReport{DryRun: dryRun, Copied: map[string]int{}}
+	if sameBackend(src.Spec, dst.Spec) {
+		return report, oops.With("backend", src.Spec.String()).Wrapf(appErrors.ErrInvalidRequest, "source and destination are the same")
+	}
+
+	if dryRun {
+		source, err := summarize(src)
+		if err != nil {
+			return report, oops.With("backend", src.Spec.String(), "context", "failed to summarize source").Wrap(err)
+		}
+		report.Source = source
+		return report, nil
+	}
+
+	state, err := loadTransferState(statePath)
+	if err != nil {
+		return report, err
+	}
+	if state != nil {
+		if state.From != src.Spec.String() || state.To != dst.Spec.String() {
+			return report, oops.With("state", statePath, "from", state.From, "to", state.To).
+				Wrapf(appErrors.ErrInvalidRequest, "state file belongs to another transfer")
+		}
+		report.Resumed = true
+		slog.Info("Resuming storage transfer", "from", state.From, "to", state.To, "channels_done", len(state.ChannelsDone))
+	} else {
+		// A fresh transfer never merges into existing data
+		existing, err := summarize(dst)
+		if err != nil {
+			return report, oops.With("backend", dst.Spec.String(), "context", "failed to summarize destination").Wrap(err)
+		}
+		if lo.SomeBy(lo.Values(existing), func(summary domain.KindSummary) bool { return summary.Count > 0 }) {
+			return report, oops.With("backend", dst.Spec.String()).Wrapf(appErrors.ErrInvalidRequest, "destination is not empty")
+		}
+		state = &domain.TransferState{From: src.Spec.String(), To: dst.Spec.String(), ChannelsDone: []string{}}
+	}
+
+	if !state.UsersDone {
+		users, err := src.Users.GetAllUsers()
+		if err != nil {
+			return report, oops.With("context", "failed to load users").Wrap(err)
+		}
+		for _, user := range users {
+			if err := dst.Users.SaveUser(user); err != nil {
+				return report, oops.With("user_id", user.ID, "context", "failed to copy user").Wrap(err)
+			}
+			report.Copied[domain.KindUsers]++
+		}
+		state.UsersDone = true
+		if err := saveTransferState(statePath, state); err != nil {
+			return report, err
+		}
+	}
+
+	if !state.CollectionsDone {
+		collections, err := src.Channels.GetAllCollections()
+		if err != nil {
+			return report, oops.With("context", "failed to load collections").Wrap(err)
+		}
+		for _, collection := range collections {
+			if err := dst.Channels.SaveCollection(collection); err != nil {
+				return report, oops.With("collection", collection.Name, "context", "failed to copy collection").Wrap(err)
+			}
+			report.Copied[domain.KindCollections]++
+		}
+		state.CollectionsDone = true
+		if err := saveTransferState(statePath, state); err != nil {
+			return report, err
+		}
+	}
+
+	channels, err := src.Channels.GetAllChannels()
+	if err != nil {
+		return report, oops.With("context", "failed to load channels").Wrap(err)
+	}
+	for _, channel := range channels {
+		if slices.Contains(state.ChannelsDone, channel.ID) {
+			report.Skipped++
+			continue
+		}
+
+		// Drop messages of a channel interrupted halfway, so it is copied whole
+		if err := dst.Messages.DeleteChannelMessages(channel.ID); err != nil {
+			return report, oops.With("channel_id", channel.ID, "context", "failed to clear partial channel").Wrap(err)
+		}
+
+		var saveErr error
+		copied := 0
+		if err := src.Messages.IterateMessages(channel.ID, func(message *messageDomain.Message) bool {
+			if saveErr = dst.Messages.SaveMessage(message); saveErr != nil {
+				saveErr = oops.With("channel_id", channel.ID, "message_id", message.ID, "context", "failed to copy message").Wrap(saveErr)
+				return false
+			}
+			report.Copied[domain.KindMessages]++
+			copied++
+			return true
+		}); err != nil {
+			return report, oops.With("channel_id", channel.ID, "context", "failed to load messages").Wrap(err)
+		}
+		if saveErr != nil {
+			return report, saveErr
+		}
+
+		// The channel is saved last, so it only exists in dst once complete
+		if err := dst.Channels.SaveChannel(channel); err != nil {
+			return report, oops.With("channel_id", channel.ID, "context", "failed to copy channel").Wrap(err)
+		}
+		report.Copied[domain.KindChannels]++
+
+		state.ChannelsDone = append(state.ChannelsDone, channel.ID)
+		if err := saveTransferState(statePath, state); err != nil {
+			return report, err
+		}
+		slog.Info("Transferred channel", "channel_id", channel.ID, "messages", copied)
+	}
+
+	if report.Source, err = summarize(src); err != nil {
+		return report, oops.With("backend", src.Spec.String(), "context", "failed to summarize source").Wrap(err)
+	}
+	if report.Destination, err = summarize(dst); err != nil {
+		return report, oops.With("backend", dst.Spec.String(), "context", "failed to summarize destination").Wrap(err)
+	}
+	for _, kind := range []string{domain.KindUsers, domain.KindCollections, domain.KindChannels, domain.KindMessages} {
+		if report.Source[kind] != report.Destination[kind] {
+			return report, oops.With("kind", kind, "source", report.Source[kind], "destination", report.Destination[kind]).
+				Wrap(appErrors.ErrTransferMismatch)
+		}
+	}
+	report.Verified = true
+
+	if err := os.Remove(statePath); err != nil && !os.IsNotExist(err) {
+		return report, oops.With("state", statePath, "context", "failed to remove transfer state").Wrap(err)
+	}
+	return report, nil
+}
+
+// sameBackend reports whether two specs point at the same storage
+func sameBackend(a, b domain.BackendSpec) bool {
+	pathA, errA := filepath.Abs(a.Path)
+	pathB, errB := filepath.Abs(b.Path)
+	return a.Backend == b.Backend && errA == nil && errB == nil && pathA == pathB
+}
+
+// summarize counts the records of each kind in a backend and checksums them.
+// The checksum covers every record's key and JSON encoding independently of
+// the order the backend returns them in.
+func summarize(backend *Backend) (map[string]domain.KindSummary, error) {
+	records := map[string][]string{}
+	add := func(kind, key string, record any) error {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return oops.With("kind", kind, "key", key).Wrap(err)
+		}
+		sum := sha256.Sum256(data)
+		records[kind] = append(records[kind], key+"\x00"+hex.EncodeToString(sum[:]))
+		return nil
+	}
+
+	users, err := backend.Users.GetAllUsers()
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		if err := add(domain.KindUsers, strconv.FormatInt(user.ID, 10), user); err != nil {
+			return nil, err
+		}
+	}
+
+	collections, err := backend.Channels.GetAllCollections()
+	if err != nil {
+		return nil, err
+	}
+	for _, collection := range collections {
+		if err := add(domain.KindCollections, collection.Name, collection); err != nil {
+			return nil, err
+		}
+	}
+
+	channels, err := backend.Channels.GetAllChannels()
+	if err != nil {
+		return nil, err
+	}
+	for _, channel := range channels {
+		if err := add(domain.KindChannels, channel.ID, channel); err != nil {
+			return nil, err
+		}
+
+		var addErr error
+		if err := backend.Messages.IterateMessages(channel.ID, func(message *messageDomain.Message) bool {
+			addErr = add(domain.KindMessages, fmt.Sprintf("%s/%d", channel.ID, message.ID), message)
+			return addErr == nil
+		}); err != nil {
+			return nil, err
+		}
+		if addErr != nil {
+			return nil, addErr
+		}
+	}
+
+	summaries := map[string]domain.KindSummary{}
+	for _, kind := range []string{domain.KindUsers, domain.KindCollections, domain.KindChannels, domain.KindMessages} {
+		lines := records[kind]
+		slices.Sort(lines)
+		hash := sha256.New()
+		for _, line := range lines {
+			hash.Write([]byte(line))
+			hash.Write([]byte{'\n'})
+		}
+		summaries[kind] = domain.KindSummary{Count: len(lines), Checksum: hex.EncodeToString(hash.Sum(nil))}
+	}
+	return summaries, nil
+}
+
+// loadTransferState reads the checkpoint of an interrupted transfer, or
+// returns nil when there is none
+func loadTransferState(path string) (*domain.TransferState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, oops.With("state", path, "context", "failed to read transfer state").Wrap(err)
+	}
+
+	var state domain.TransferState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, oops.With("state", path).Wrapf(appErrors.ErrCorruptRecord, "failed to unmarshal transfer state: %v", err)
+	}
+	return &state, nil
+}
+
+func saveTransferState(path string, state *domain.TransferState) error {
+	state.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return oops.With("state", path, "context", "failed to marshal transfer state").Wrap(err)
+	}
+	if err := fileutil.WriteFileAtomic(path, data, 0644); err != nil {
+		return oops.With("state", path, "context", "failed to save transfer state").Wrap(err)
+	}
+	return nil
+}
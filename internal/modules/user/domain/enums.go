@@ -0,0 +1,7 @@
+//go:generate go run github.com/abice/go-enum --file=$GOFILE --names --nocase
+
+package domain
+
+// Role represents a user's access level
+// ENUM(owner,admin,editor,viewer)
+type Role string
@@ -0,0 +1,88 @@
+package domain
+
+import "slices"
+
+// Permission is an action guarded by role-based access control
+type Permission string
+
+const (
+	// PermissionViewFeeds allows listing channels and getting feed links
+	PermissionViewFeeds Permission = "view_feeds"
+	// PermissionManageChannels allows adding channels and managing the
+	// filters and feeds of channels the user added
+	PermissionManageChannels Permission = "manage_channels"
+	// PermissionManageAllChannels allows managing every channel
+	PermissionManageAllChannels Permission = "manage_all_channels"
+	// PermissionSearch allows searching stored posts and subscribing to
+	// search feeds
+	PermissionSearch Permission = "search"
+	// PermissionUseDashboard allows signing in to the web dashboard
+	PermissionUseDashboard Permission = "use_dashboard"
+	// PermissionViewStatus allows viewing bot status
+	PermissionViewStatus Permission = "view_status"
+	// PermissionManageUsers allows adding, removing and changing roles of users
+	PermissionManageUsers Permission = "manage_users"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleViewer: {
+		PermissionViewFeeds,
+	},
+	RoleEditor: {
+		PermissionViewFeeds,
+		PermissionManageChannels,
+		PermissionViewStatus,
+		PermissionSearch,
+		PermissionUseDashboard,
+	},
+	RoleAdmin: {
+		PermissionViewFeeds,
+		PermissionManageChannels,
+		PermissionManageAllChannels,
+		PermissionViewStatus,
+		PermissionSearch,
+		PermissionUseDashboard,
+		PermissionManageUsers,
+	},
+	RoleOwner: {
+		PermissionViewFeeds,
+		PermissionManageChannels,
+		PermissionManageAllChannels,
+		PermissionViewStatus,
+		PermissionSearch,
+		PermissionUseDashboard,
+		PermissionManageUsers,
+	},
+}
+
+// Can reports whether the role grants a permission
+func (r Role) Can(permission Permission) bool {
+	return slices.Contains(rolePermissions[r], permission)
+}
+
+// Rank orders roles by privilege; higher is more privileged
+func (r Role) Rank() int {
+	switch r {
+	case RoleOwner:
+		return 4
+	case RoleAdmin:
+		return 3
+	case RoleEditor:
+		return 2
+	case RoleViewer:
+		return 1
+	}
+	return 0
+}
+
+// CanAssign reports whether a user with role r may grant or revoke role target.
+// Owners may assign any role; everyone else only roles below their own.
+func (r Role) CanAssign(target Role) bool {
+	if !r.Can(PermissionManageUsers) {
+		return false
+	}
+	if r == RoleOwner {
+		return true
+	}
+	return target.Rank() < r.Rank()
+}
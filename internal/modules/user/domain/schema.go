@@ -0,0 +1,21 @@
+package domain
+
+import "github.com/reshetovitsme/rss-telegram-feed/internal/shared/schema"
+
+// Schema versions stored user records. Append an upgrade whenever a field
+// change needs older records converted.
+var Schema = schema.New("user",
+	// 1 → 2: the is_admin flag is replaced by an explicit role
+	func(record map[string]any) error {
+		role, _ := record["role"].(string)
+		if _, err := ParseRole(role); err != nil {
+			role = RoleEditor.String()
+			if isAdmin, _ := record["is_admin"].(bool); isAdmin {
+				role = RoleAdmin.String()
+			}
+			record["role"] = role
+		}
+		delete(record, "is_admin")
+		return nil
+	},
+)
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	appErrors "github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture %s: %v", name, err)
+	}
+	return data
+}
+
+func TestSchemaUpgrades(t *testing.T) {
+	tests := []struct {
+		fixture     string
+		wantVersion int
+		wantID      int64
+		wantRole    Role
+	}{
+		{"user_v1_admin.json", 1, 1001, RoleAdmin},
+		{"user_v1_editor.json", 1, 1002, RoleEditor},
+		{"user_v2.json", 2, 1003, RoleViewer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			var user User
+			version, err := Schema.Unmarshal(readFixture(t, tt.fixture), &user)
+			if err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if version != tt.wantVersion {
+				t.Errorf("version = %d, want %d", version, tt.wantVersion)
+			}
+			if user.ID != tt.wantID || user.Role != tt.wantRole {
+				t.Errorf("got user %d with role %q, want %d with role %q", user.ID, user.Role, tt.wantID, tt.wantRole)
+			}
+		})
+	}
+}
+
+func TestSchemaRoundTrip(t *testing.T) {
+	want := User{ID: 1004, Username: "dave", AddedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), Role: RoleOwner}
+	data, err := Schema.Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var got User
+	version, err := Schema.Unmarshal(data, &got)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if version != Schema.Version() || got != want {
+		t.Errorf("got version %d, user %+v; want version %d, user %+v", version, got, Schema.Version(), want)
+	}
+}
+
+func TestSchemaNewerVersion(t *testing.T) {
+	data := []byte(`{"schema_version": 99, "data": {"id": 1005}}`)
+	var user User
+	if _, err := Schema.Unmarshal(data, &user); !errors.Is(err, appErrors.ErrSchemaVersion) {
+		t.Errorf("err = %v, want %v", err, appErrors.ErrSchemaVersion)
+	}
+}
@@ -7,5 +7,49 @@ type User struct {
 	ID       int64     `json:"id"`
 	Username string    `json:"username"`
 	AddedAt  time.Time `json:"added_at"`
-	IsAdmin  bool      `json:"is_admin"`
+	Role     Role      `json:"role"`
+}
+
+// EffectiveRole returns the user's role, falling back to editor for a
+// missing or unknown role. Records written before roles existed are given
+// one when they are read, see Schema.
+func (u *User) EffectiveRole() Role {
+	if u.Role.IsValid() {
+		return u.Role
+	}
+	return RoleEditor
+}
+
+// Can reports whether the user's role grants a permission
+func (u *User) Can(permission Permission) bool {
+	return u.EffectiveRole().Can(permission)
+}
+
+// Resource is owned by the user who created it and may be shared with others
+type Resource interface {
+	OwnerID() int64
+	IsSharedWith(userID int64) bool
+}
+
+// CanView reports whether the user may see a resource.
+// Admins and owners see everything, others only what they own or was shared with them.
+func (u *User) CanView(resource Resource) bool {
+	if u.Can(PermissionManageAllChannels) {
+		return true
+	}
+	return resource.OwnerID() == u.ID || resource.IsSharedWith(u.ID)
+}
+
+// CanEdit reports whether the user may change the filters and feeds of a resource
+func (u *User) CanEdit(resource Resource) bool {
+	return u.Can(PermissionManageChannels) && u.CanView(resource)
+}
+
+// CanAdminister reports whether the user may remove or share a resource.
+// Admins and owners administer everything, editors only what they own.
+func (u *User) CanAdminister(resource Resource) bool {
+	if u.Can(PermissionManageAllChannels) {
+		return true
+	}
+	return u.Can(PermissionManageChannels) && resource.OwnerID() == u.ID
 }
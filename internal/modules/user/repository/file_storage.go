@@ -1,13 +1,15 @@
 package repository
 
 import (
-	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sync"
 
 	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/user/domain"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/fileutil"
 	"github.com/samber/oops"
 )
 
@@ -32,12 +34,12 @@ func (s *FileStorage) SaveUser(user *domain.User) error {
 	defer s.mu.Unlock()
 
 	path := filepath.Join(s.basePath, fmt.Sprintf("%d.json", user.ID))
-	data, err := json.MarshalIndent(user, "", "  ")
+	data, err := domain.Schema.Marshal(user)
 	if err != nil {
 		return oops.With("user_id", user.ID, "context", "failed to marshal user").Wrap(err)
 	}
 
-	return os.WriteFile(path, data, 0644)
+	return fileutil.WriteFileAtomic(path, data, 0644)
 }
 
 func (s *FileStorage) GetUser(userID int64) (*domain.User, error) {
@@ -48,14 +50,14 @@ func (s *FileStorage) GetUser(userID int64) (*domain.User, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, oops.With("user_id", userID).New("user not found")
+			return nil, oops.With("user_id", userID).Wrap(errors.ErrUserNotFound)
 		}
 		return nil, oops.With("user_id", userID, "context", "failed to read user").Wrap(err)
 	}
 
 	var user domain.User
-	if err := json.Unmarshal(data, &user); err != nil {
-		return nil, oops.With("user_id", userID, "context", "failed to unmarshal user").Wrap(err)
+	if _, err := domain.Schema.Unmarshal(data, &user); err != nil {
+		return nil, oops.With("user_id", userID, "path", path).Wrap(err)
 	}
 
 	return &user, nil
@@ -79,11 +81,12 @@ func (s *FileStorage) GetAllUsers() ([]*domain.User, error) {
 		path := filepath.Join(s.basePath, entry.Name())
 		data, err := os.ReadFile(path)
 		if err != nil {
-			continue
+			return nil, oops.With("path", path, "context", "failed to read user").Wrap(err)
 		}
 
 		var user domain.User
-		if err := json.Unmarshal(data, &user); err != nil {
+		if _, err := domain.Schema.Unmarshal(data, &user); err != nil {
+			slog.Warn("Skipping corrupt user record", "path", path, "error", err)
 			continue
 		}
 
@@ -92,3 +95,18 @@ func (s *FileStorage) GetAllUsers() ([]*domain.User, error) {
 
 	return users, nil
 }
+
+func (s *FileStorage) DeleteUser(userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := filepath.Join(s.basePath, fmt.Sprintf("%d.json", userID))
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return oops.With("user_id", userID).Wrap(errors.ErrUserNotFound)
+		}
+		return oops.With("user_id", userID, "context", "failed to delete user").Wrap(err)
+	}
+
+	return nil
+}
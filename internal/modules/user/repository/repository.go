@@ -9,4 +9,5 @@ type Repository interface {
 	SaveUser(user *domain.User) error
 	GetUser(userID int64) (*domain.User, error)
 	GetAllUsers() ([]*domain.User, error)
+	DeleteUser(userID int64) error
 }
@@ -1,13 +1,28 @@
 package service
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/user/domain"
 	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/user/repository"
+	appErrors "github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/samber/oops"
 )
 
 // Service handles user business logic
 type Service struct {
 	repo repository.Repository
+
+	// claimCode is the pending one-time bootstrap code, empty once claimed
+	claimCode string
+	mu        sync.Mutex
 }
 
 // New creates a new user service
@@ -32,17 +47,188 @@ func (s *Service) GetAllUsers() ([]*domain.User, error) {
 	return s.repo.GetAllUsers()
 }
 
+// DeleteUser removes a user
+func (s *Service) DeleteUser(userID int64) error {
+	return s.repo.DeleteUser(userID)
+}
+
 // IsAuthorized checks if a user is authorized
 func (s *Service) IsAuthorized(userID int64, allowedUsers []int64) bool {
-	if len(allowedUsers) == 0 {
-		return true // No restrictions
+	_, ok := s.Authorize(userID, allowedUsers)
+	return ok
+}
+
+// Authorize resolves the effective user record for an incoming user.
+// Stored users keep their role. Users listed in allowedUsers without a
+// stored record are treated as admins, as they had full access before roles.
+func (s *Service) Authorize(userID int64, allowedUsers []int64) (*domain.User, bool) {
+	if user, err := s.repo.GetUser(userID); err == nil {
+		return user, true
+	}
+
+	if slices.Contains(allowedUsers, userID) {
+		return &domain.User{ID: userID, Role: domain.RoleAdmin}, true
+	}
+
+	return nil, false
+}
+
+// Bootstrap generates a one-time claim code and logs it when no owner is
+// stored yet. The code lives in memory only, so every restart before the
+// bot is claimed logs a fresh code.
+func (s *Service) Bootstrap() error {
+	users, err := s.repo.GetAllUsers()
+	if err != nil {
+		return oops.With("context", "failed to load users").Wrap(err)
+	}
+	for _, user := range users {
+		if user.EffectiveRole() == domain.RoleOwner {
+			return nil
+		}
+	}
+
+	raw := make([]byte, 10)
+	if _, err := rand.Read(raw); err != nil {
+		return oops.With("context", "failed to generate claim code").Wrap(err)
+	}
+	code := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw)
+
+	s.mu.Lock()
+	s.claimCode = code
+	s.mu.Unlock()
+
+	slog.Warn("No owner configured. Send /claim <code> to the bot in a private chat to become its owner", "claim_code", code)
+	return nil
+}
+
+// ClaimPending reports whether the bot is waiting to be claimed
+func (s *Service) ClaimPending() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.claimCode != ""
+}
+
+// Claim makes the user owner when code matches the pending claim code.
+// The code is invalidated on success.
+func (s *Service) Claim(userID int64, username string, code string) (*domain.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if s.claimCode == "" || subtle.ConstantTimeCompare([]byte(code), []byte(s.claimCode)) != 1 {
+		return nil, oops.With("user_id", userID).Wrap(appErrors.ErrInvalidClaimCode)
+	}
+
+	user := &domain.User{
+		ID:       userID,
+		Username: username,
+		AddedAt:  time.Now(),
+		Role:     domain.RoleOwner,
+	}
+	if existing, err := s.repo.GetUser(userID); err == nil {
+		user.AddedAt = existing.AddedAt
+	}
+
+	if err := s.repo.SaveUser(user); err != nil {
+		return nil, err
+	}
+	s.claimCode = ""
+
+	slog.Info("Bot claimed", "user_id", userID)
+	return user, nil
+}
+
+// AddUser stores a new user with a role on behalf of actor
+func (s *Service) AddUser(actor *domain.User, userID int64, username string, role domain.Role) (*domain.User, error) {
+	if !role.IsValid() {
+		return nil, oops.With("role", role).Wrap(appErrors.ErrInvalidRole)
+	}
+	if !actor.EffectiveRole().CanAssign(role) {
+		return nil, oops.With("actor_id", actor.ID, "role", role).Wrap(appErrors.ErrForbidden)
+	}
+
+	user := &domain.User{
+		ID:       userID,
+		Username: username,
+		AddedAt:  time.Now(),
+		Role:     role,
+	}
+
+	// Never downgrade an existing user the actor could not manage
+	if existing, err := s.repo.GetUser(userID); err == nil {
+		if !actor.EffectiveRole().CanAssign(existing.EffectiveRole()) {
+			return nil, oops.With("actor_id", actor.ID, "user_id", userID).Wrap(appErrors.ErrForbidden)
+		}
+		if err := s.ensureOwnerRemains(existing, role); err != nil {
+			return nil, err
+		}
+		user.AddedAt = existing.AddedAt
+		if username == "" {
+			user.Username = existing.Username
+		}
+	}
+
+	if err := s.repo.SaveUser(user); err != nil {
+		return nil, err
 	}
+	return user, nil
+}
 
-	for _, id := range allowedUsers {
-		if id == userID {
-			return true
+// RemoveUser deletes a user on behalf of actor
+func (s *Service) RemoveUser(actor *domain.User, userID int64) error {
+	target, err := s.repo.GetUser(userID)
+	if err != nil {
+		return err
+	}
+	if !actor.EffectiveRole().CanAssign(target.EffectiveRole()) {
+		return oops.With("actor_id", actor.ID, "user_id", userID).Wrap(appErrors.ErrForbidden)
+	}
+	if err := s.ensureOwnerRemains(target, ""); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteUser(userID)
+}
+
+// SetRole changes the role of a stored user on behalf of actor
+func (s *Service) SetRole(actor *domain.User, userID int64, role domain.Role) (*domain.User, error) {
+	if !role.IsValid() {
+		return nil, oops.With("role", role).Wrap(appErrors.ErrInvalidRole)
+	}
+
+	target, err := s.repo.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.EffectiveRole().CanAssign(target.EffectiveRole()) || !actor.EffectiveRole().CanAssign(role) {
+		return nil, oops.With("actor_id", actor.ID, "user_id", userID, "role", role).Wrap(appErrors.ErrForbidden)
+	}
+	if err := s.ensureOwnerRemains(target, role); err != nil {
+		return nil, err
+	}
+
+	target.Role = role
+	if err := s.repo.SaveUser(target); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// ensureOwnerRemains rejects removing or demoting the last owner
+func (s *Service) ensureOwnerRemains(target *domain.User, newRole domain.Role) error {
+	if target.EffectiveRole() != domain.RoleOwner || newRole == domain.RoleOwner {
+		return nil
+	}
+
+	users, err := s.repo.GetAllUsers()
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if user.ID != target.ID && user.EffectiveRole() == domain.RoleOwner {
+			return nil
 		}
 	}
 
-	return false
+	return oops.With("user_id", target.ID).Wrap(appErrors.ErrLastOwner)
 }
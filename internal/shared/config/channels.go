@@ -0,0 +1,136 @@
+package config
+
+import (
+	goerrors "errors"
+
+	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/domain"
+	"github.com/samber/oops"
+)
+
+// ChannelSpec declares a channel in the config file
+type ChannelSpec struct {
+	// ID is the numeric channel ID. Resolved from Username through the
+	// Bot API when empty.
+	ID       string `koanf:"id"`
+	Username string `koanf:"username"`
+	Title    string `koanf:"title"`
+	// Owner is the user the channel belongs to; 0 keeps the stored owner
+	Owner      int64        `koanf:"owner"`
+	SharedWith []int64      `koanf:"shared_with"`
+	Active     *bool        `koanf:"active"`
+	Filters    []FilterSpec `koanf:"filters"`
+	Feeds      []FeedSpec   `koanf:"feeds"`
+}
+
+// FilterSpec declares a content filter
+type FilterSpec struct {
+	Type     string   `koanf:"type"`
+	Keywords []string `koanf:"keywords"`
+	Enabled  *bool    `koanf:"enabled"`
+}
+
+// FeedSpec declares a named feed of a channel
+type FeedSpec struct {
+	Name        string       `koanf:"name"`
+	Title       string       `koanf:"title"`
+	Description string       `koanf:"description"`
+	Filters     []FilterSpec `koanf:"filters"`
+	Limit       int          `koanf:"limit"`
+	MaxAgeDays  int          `koanf:"max_age_days"`
+}
+
+// CollectionSpec declares a feed that merges several channels
+type CollectionSpec struct {
+	Name        string       `koanf:"name"`
+	Title       string       `koanf:"title"`
+	Description string       `koanf:"description"`
+	Channels    []string     `koanf:"channels"`
+	Filters     []FilterSpec `koanf:"filters"`
+	Limit       int          `koanf:"limit"`
+	MaxAgeDays  int          `koanf:"max_age_days"`
+	Owner       int64        `koanf:"owner"`
+	SharedWith  []int64      `koanf:"shared_with"`
+}
+
+// ToFilters converts filter specs into domain filters. Filters are enabled
+// unless explicitly disabled.
+func ToFilters(specs []FilterSpec) []domain.Filter {
+	filters := make([]domain.Filter, 0, len(specs))
+	for _, spec := range specs {
+		filterType, _ := domain.ParseFilterType(spec.Type)
+		filters = append(filters, domain.Filter{
+			Type:     filterType,
+			Keywords: spec.Keywords,
+			Enabled:  spec.Enabled == nil || *spec.Enabled,
+		})
+	}
+	return filters
+}
+
+// ValidateDeclarations checks the declared channels and collections
+func (c *Config) ValidateDeclarations() error {
+	var errs []error
+
+	channelKeys := make(map[string]bool)
+	for i, channel := range c.Channels {
+		key := channel.ID
+		if key == "" {
+			key = "@" + channel.Username
+		}
+		if channel.ID == "" && channel.Username == "" {
+			errs = append(errs, oops.With("index", i).Errorf("channels[%d]: id or username is required", i))
+			continue
+		}
+		if channelKeys[key] {
+			errs = append(errs, oops.With("channel", key).Errorf("channel %s is declared twice", key))
+		}
+		channelKeys[key] = true
+
+		errs = append(errs, validateFilterSpecs("channel "+key, channel.Filters)...)
+
+		feedNames := make(map[string]bool)
+		for _, feed := range channel.Feeds {
+			if !domain.ValidFeedName(feed.Name) {
+				errs = append(errs, oops.With("channel", key, "feed", feed.Name).Errorf("channel %s: invalid feed name %q", key, feed.Name))
+			}
+			if feedNames[feed.Name] {
+				errs = append(errs, oops.With("channel", key, "feed", feed.Name).Errorf("channel %s: feed %s is declared twice", key, feed.Name))
+			}
+			feedNames[feed.Name] = true
+			errs = append(errs, validateFilterSpecs("feed "+key+"/"+feed.Name, feed.Filters)...)
+		}
+	}
+
+	collectionNames := make(map[string]bool)
+	for _, collection := range c.Collections {
+		if !domain.ValidFeedName(collection.Name) {
+			errs = append(errs, oops.With("collection", collection.Name).Errorf("invalid collection name %q", collection.Name))
+		}
+		if collectionNames[collection.Name] {
+			errs = append(errs, oops.With("collection", collection.Name).Errorf("collection %s is declared twice", collection.Name))
+		}
+		collectionNames[collection.Name] = true
+		if len(collection.Channels) == 0 {
+			errs = append(errs, oops.With("collection", collection.Name).Errorf("collection %s has no channels", collection.Name))
+		}
+		errs = append(errs, validateFilterSpecs("collection "+collection.Name, collection.Filters)...)
+	}
+
+	return goerrors.Join(errs...)
+}
+
+func validateFilterSpecs(owner string, specs []FilterSpec) []error {
+	var errs []error
+	for i, spec := range specs {
+		filterType, err := domain.ParseFilterType(spec.Type)
+		if err != nil {
+			errs = append(errs, oops.With("filter", i+1).Errorf("%s: filter %d has unknown type %q", owner, i+1, spec.Type))
+			continue
+		}
+		filter := domain.Filter{Type: filterType, Keywords: spec.Keywords}
+		if err := filter.Validate(); err != nil {
+			errs = append(errs, oops.With("filter", i+1).Errorf("%s: filter %d: %v", owner, i+1, err))
+		}
+	}
+	return errs
+}
@@ -2,9 +2,13 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/knadh/koanf/parsers/json"
 	"github.com/knadh/koanf/parsers/toml"
@@ -13,39 +17,119 @@ import (
 	"github.com/knadh/koanf/providers/file"
 	"github.com/knadh/koanf/v2"
 	"github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/domain"
+	mediaDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/media/domain"
+	storageDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/storage/domain"
 	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
 	"github.com/samber/lo"
 	"github.com/samber/oops"
 )
 
 type Config struct {
-	TelegramBotToken string              `koanf:"telegram_bot_token"`
-	TelegramAPIURL   string              `koanf:"telegram_api_url"`
-	StoragePath      string              `koanf:"storage_path"`
-	HTTPPort         string              `koanf:"http_port"`
-	UpdateInterval   int                 `koanf:"update_interval"`
-	AllowedUsers     []int64             `koanf:"allowed_users"`
-	AppEnv           domain.AppEnv       `koanf:"app_env"`
+	TelegramBotToken string        `koanf:"telegram_bot_token"`
+	TelegramAPIURL   string        `koanf:"telegram_api_url"`
+	StoragePath      string        `koanf:"storage_path"`
+	HTTPPort         string        `koanf:"http_port"`
+	UpdateInterval   int           `koanf:"update_interval"`
+	AllowedUsers     []int64       `koanf:"allowed_users"`
+	AppEnv           domain.AppEnv `koanf:"app_env"`
+	// StorageBackend selects the storage implementation, defaults to file
+	StorageBackend storageDomain.Backend `koanf:"storage_backend"`
+	// FilterOnIngest drops messages that fail channel filters before they
+	// are stored. By default every post is stored and filters are applied
+	// when feeds are generated, so filter changes apply retroactively.
+	FilterOnIngest bool `koanf:"filter_on_ingest"`
+	// APIKeys authenticate requests to the REST admin API. The API is
+	// disabled when no keys are configured.
+	APIKeys []string `koanf:"api_keys"`
+	// PublicURL is the externally reachable base URL used in links sent by
+	// the bot, e.g. https://rss.example.com. Defaults to http://localhost:<port>.
+	PublicURL string `koanf:"public_url"`
+	// DashboardSessionSecret signs dashboard sessions. Derived from the bot
+	// token when empty.
+	DashboardSessionSecret string `koanf:"dashboard_session_secret"`
+	// DashboardSessionTTL is the dashboard session lifetime in hours
+	DashboardSessionTTL int `koanf:"dashboard_session_ttl"`
+	// DashboardBotUsername enables the Telegram Login Widget on the
+	// dashboard login page
+	DashboardBotUsername string `koanf:"dashboard_bot_username"`
+	// Channels and Collections are declared in the config file and
+	// reconciled into storage on startup and reload
+	Channels    []ChannelSpec    `koanf:"channels"`
+	Collections []CollectionSpec `koanf:"collections"`
+	// ChannelsReconcile is apply (default), drift to only report differences
+	// between declared and stored channels, or off
+	ChannelsReconcile domain.ReconcileMode `koanf:"channels_reconcile"`
+	// LogLevel is debug, info (default), warn or error
+	LogLevel string `koanf:"log_level"`
+	// RetentionDays deletes stored messages older than this many days.
+	// Zero keeps messages forever.
+	RetentionDays int `koanf:"retention_days"`
+	// BackupInterval creates a backup archive every this many hours.
+	// Zero disables scheduled backups.
+	BackupInterval int `koanf:"backup_interval"`
+	// BackupPath is the directory backup archives are written to
+	BackupPath string `koanf:"backup_path"`
+	// BackupKeep is how many scheduled backups are kept
+	BackupKeep int `koanf:"backup_keep"`
+	// CorruptRecords is what the startup integrity scan does with records
+	// that cannot be decoded: quarantine (default) or report
+	CorruptRecords storageDomain.IntegrityAction `koanf:"corrupt_records"`
+	// ThumbnailSizes are the widths in pixels images are scaled down to for
+	// feed items. An empty list serves full-size images only.
+	ThumbnailSizes []int `koanf:"thumbnail_sizes"`
+	// MediaArchive downloads the media of new posts into the storage path
+	// as they arrive, so they stay available after Telegram drops them
+	MediaArchive bool `koanf:"media_archive"`
+	// MediaQuotaMB and MediaChannelQuotaMB cap the media archive overall and
+	// per channel in megabytes. Zero means unlimited.
+	MediaQuotaMB        int `koanf:"media_quota_mb"`
+	MediaChannelQuotaMB int `koanf:"media_channel_quota_mb"`
+	// MediaEviction picks the archived files removed first once a quota is
+	// exceeded: lru (default) or age
+	MediaEviction mediaDomain.EvictionPolicy `koanf:"media_eviction"`
+	// DeletionCheck verifies that recent posts still exist by copying (copy)
+	// or forwarding (forward) them to DeletionCheckChat, or is off (default)
+	DeletionCheck domain.DeletionCheck `koanf:"deletion_check"`
+	// DeletionCheckChat is the scratch chat posts are sent to while checked
+	DeletionCheckChat int64 `koanf:"deletion_check_chat"`
+	// DeletionCheckHours is how old posts may be and still get checked
+	DeletionCheckHours int `koanf:"deletion_check_hours"`
 }
 
-func Load() (*Config, error) {
-	k := koanf.New(".")
+// maxThumbnailSize caps the configurable thumbnail width
+const maxThumbnailSize = 4096
 
-	// Try to load config file from various formats
-	configFiles := []string{
-		"config.yaml",
-		"config.yml",
-		"config.json",
-		"config.toml",
-	}
+// configFiles are looked up in the working directory, in order
+var configFiles = []string{
+	"config.yaml",
+	"config.yml",
+	"config.json",
+	"config.toml",
+}
 
-	// Use lo.Find to find the first existing config file
-	configFile, found := lo.Find(configFiles, func(file string) bool {
+// FindConfigFile returns the first config file present in the working
+// directory, or an empty string when there is none
+func FindConfigFile() string {
+	configFile, _ := lo.Find(configFiles, func(file string) bool {
 		_, err := os.Stat(file)
 		return err == nil
 	})
+	return configFile
+}
+
+// Load reads the config file found in the working directory, if any, and
+// the environment
+func Load() (*Config, error) {
+	return LoadFile(FindConfigFile())
+}
+
+// LoadFile reads configuration from configFile and the environment.
+// Environment variables override file values; an empty configFile loads
+// the environment only.
+func LoadFile(configFile string) (*Config, error) {
+	k := koanf.New(".")
 
-	if found {
+	if configFile != "" {
 		var parser koanf.Parser
 		ext := filepath.Ext(configFile)
 
@@ -88,6 +172,38 @@ func Load() (*Config, error) {
 	if !k.Exists("app_env") {
 		k.Set("app_env", "production")
 	}
+	if !k.Exists("dashboard_session_ttl") {
+		k.Set("dashboard_session_ttl", 24)
+	}
+	if !k.Exists("log_level") {
+		k.Set("log_level", "info")
+	}
+	if !k.Exists("backup_path") {
+		k.Set("backup_path", "./backups")
+	}
+	if !k.Exists("backup_keep") {
+		k.Set("backup_keep", 7)
+	}
+	if !k.Exists("deletion_check_hours") {
+		k.Set("deletion_check_hours", 48)
+	}
+	if !k.Exists("thumbnail_sizes") {
+		k.Set("thumbnail_sizes", []int{320, 640})
+	}
+
+	// Parse ThumbnailSizes from a comma-separated string before unmarshaling,
+	// which cannot convert it to integers
+	if sizes, ok := k.Get("thumbnail_sizes").(string); ok {
+		widths := []int{}
+		for _, size := range lo.Compact(strings.Split(sizes, ",")) {
+			width, err := strconv.Atoi(strings.TrimSpace(size))
+			if err != nil {
+				return nil, oops.With("thumbnail_sizes", sizes).Errorf("thumbnail_sizes must be a list of widths in pixels")
+			}
+			widths = append(widths, width)
+		}
+		k.Set("thumbnail_sizes", widths)
+	}
 
 	// Unmarshal into struct
 	var cfg Config
@@ -116,6 +232,16 @@ func Load() (*Config, error) {
 		}
 	}
 
+	// Parse APIKeys from comma-separated string if it's a string
+	if apiKeys, ok := k.Get("api_keys").(string); ok {
+		cfg.APIKeys = lo.Compact(lo.Map(strings.Split(apiKeys, ","), func(key string, _ int) string {
+			return strings.TrimSpace(key)
+		}))
+	}
+
+	slices.Sort(cfg.ThumbnailSizes)
+	cfg.ThumbnailSizes = slices.Compact(cfg.ThumbnailSizes)
+
 	// Parse AppEnv from string if needed
 	if appEnvStr := k.String("app_env"); appEnvStr != "" {
 		if env, err := domain.ParseAppEnv(appEnvStr); err == nil {
@@ -127,14 +253,121 @@ func Load() (*Config, error) {
 		cfg.AppEnv = domain.AppEnvProduction
 	}
 
+	// Parse ChannelsReconcile, defaulting to apply. Unknown modes are rejected
+	// rather than defaulted so a typo never applies changes unexpectedly.
+	cfg.ChannelsReconcile = domain.ReconcileModeApply
+	if modeStr := k.String("channels_reconcile"); modeStr != "" {
+		mode, err := domain.ParseReconcileMode(modeStr)
+		if err != nil {
+			return nil, oops.With("channels_reconcile", modeStr).Wrap(err)
+		}
+		cfg.ChannelsReconcile = mode
+	}
+
+	// Parse StorageBackend, defaulting to file
+	cfg.StorageBackend = storageDomain.BackendFile
+	if backendStr := k.String("storage_backend"); backendStr != "" {
+		backend, err := storageDomain.ParseBackend(backendStr)
+		if err != nil {
+			return nil, oops.With("storage_backend", backendStr).Wrap(err)
+		}
+		cfg.StorageBackend = backend
+	}
+
+	// Parse CorruptRecords, defaulting to quarantine
+	cfg.CorruptRecords = storageDomain.IntegrityActionQuarantine
+	if actionStr := k.String("corrupt_records"); actionStr != "" {
+		action, err := storageDomain.ParseIntegrityAction(actionStr)
+		if err != nil {
+			return nil, oops.With("corrupt_records", actionStr).Wrap(err)
+		}
+		cfg.CorruptRecords = action
+	}
+
+	// Parse MediaEviction, defaulting to lru
+	cfg.MediaEviction = mediaDomain.EvictionPolicyLru
+	if policyStr := k.String("media_eviction"); policyStr != "" {
+		policy, err := mediaDomain.ParseEvictionPolicy(policyStr)
+		if err != nil {
+			return nil, oops.With("media_eviction", policyStr).Wrap(err)
+		}
+		cfg.MediaEviction = policy
+	}
+
+	// Parse DeletionCheck, defaulting to off
+	cfg.DeletionCheck = domain.DeletionCheckOff
+	if checkStr := k.String("deletion_check"); checkStr != "" {
+		check, err := domain.ParseDeletionCheck(checkStr)
+		if err != nil {
+			return nil, oops.With("deletion_check", checkStr).Wrap(err)
+		}
+		cfg.DeletionCheck = check
+	}
+
 	// Validate required fields
 	if cfg.TelegramBotToken == "" {
 		return nil, errors.ErrMissingBotToken
 	}
+	if cfg.UpdateInterval <= 0 {
+		return nil, oops.With("update_interval", cfg.UpdateInterval).Errorf("update_interval must be positive")
+	}
+	if cfg.RetentionDays < 0 {
+		return nil, oops.With("retention_days", cfg.RetentionDays).Errorf("retention_days must not be negative")
+	}
+	if cfg.BackupInterval < 0 || cfg.BackupKeep < 0 {
+		return nil, oops.With("backup_interval", cfg.BackupInterval, "backup_keep", cfg.BackupKeep).Errorf("backup_interval and backup_keep must not be negative")
+	}
+	if cfg.MediaQuotaMB < 0 || cfg.MediaChannelQuotaMB < 0 {
+		return nil, oops.With("media_quota_mb", cfg.MediaQuotaMB, "media_channel_quota_mb", cfg.MediaChannelQuotaMB).Errorf("media quotas must not be negative")
+	}
+	if cfg.DeletionCheck != domain.DeletionCheckOff && cfg.DeletionCheckChat == 0 {
+		return nil, oops.With("deletion_check", cfg.DeletionCheck).Errorf("deletion_check_chat is required to check for deleted posts")
+	}
+	if cfg.DeletionCheckHours <= 0 {
+		return nil, oops.With("deletion_check_hours", cfg.DeletionCheckHours).Errorf("deletion_check_hours must be positive")
+	}
+	if len(cfg.ThumbnailSizes) > 0 && (cfg.ThumbnailSizes[0] <= 0 || cfg.ThumbnailSizes[len(cfg.ThumbnailSizes)-1] > maxThumbnailSize) {
+		return nil, oops.With("thumbnail_sizes", cfg.ThumbnailSizes).Errorf("thumbnail_sizes must be between 1 and %d pixels", maxThumbnailSize)
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		return nil, oops.With("log_level", cfg.LogLevel).Wrap(err)
+	}
+	if err := cfg.ValidateDeclarations(); err != nil {
+		return nil, oops.With("context", "invalid channel declarations").Wrap(err)
+	}
 
 	return &cfg, nil
 }
 
+// BaseURL returns the public base URL without a trailing slash
+func (c *Config) BaseURL() string {
+	if c.PublicURL != "" {
+		return strings.TrimRight(c.PublicURL, "/")
+	}
+	return fmt.Sprintf("http://localhost:%s", c.HTTPPort)
+}
+
+// SlogLevel returns the configured log level, falling back to info
+func (c *Config) SlogLevel() slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// MediaQuota returns the overall and per-channel media archive quotas in
+// bytes, zero meaning unlimited
+func (c *Config) MediaQuota() (int64, int64) {
+	return int64(c.MediaQuotaMB) << 20, int64(c.MediaChannelQuotaMB) << 20
+}
+
+// Retention returns how long messages are kept, or zero to keep them forever
+func (c *Config) Retention() time.Duration {
+	return time.Duration(c.RetentionDays) * 24 * time.Hour
+}
+
 // ParseAllowedUsers parses comma-separated user IDs string into []int64
 func ParseAllowedUsers(s string) []int64 {
 	if s == "" {
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/samber/oops"
+)
+
+// reloadDebounce coalesces the bursts of events editors produce when saving
+const reloadDebounce = 500 * time.Millisecond
+
+// Store holds the active configuration and swaps it atomically on reload.
+// Services keep the Store and call Get for every use so they always see a
+// complete, validated config.
+type Store struct {
+	path        string
+	current     atomic.Pointer[Config]
+	mu          sync.Mutex
+	subscribers []func(previous, current *Config)
+}
+
+// NewStore creates a store serving cfg, reloaded from path. An empty path
+// reloads from the environment only.
+func NewStore(cfg *Config, path string) *Store {
+	s := &Store{path: path}
+	s.current.Store(cfg)
+	return s
+}
+
+// Get returns the active configuration. The returned value must not be modified.
+func (s *Store) Get() *Config {
+	return s.current.Load()
+}
+
+// Path returns the config file the store reloads from
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Subscribe registers fn to run after every successful reload
+func (s *Store) Subscribe(fn func(previous, current *Config)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+// Reload reads the configuration again and swaps it in. An invalid config is
+// rejected and the previous one stays active. Settings that are only read at
+// startup keep their current values until restart.
+func (s *Store) Reload() error {
+	old, next, err := s.swap()
+	if err != nil {
+		return err
+	}
+
+	// Subscribers run unlocked: they may call the Bot API or subscribe and
+	// reload themselves without holding up other reloads
+	s.mu.Lock()
+	subscribers := slices.Clone(s.subscribers)
+	s.mu.Unlock()
+	for _, fn := range subscribers {
+		fn(old, next)
+	}
+
+	return nil
+}
+
+// swap loads the config file and makes it the active configuration
+func (s *Store) swap() (old, next *Config, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next, err = LoadFile(s.path)
+	if err != nil {
+		return nil, nil, oops.With("config_file", s.path, "context", "config reload rejected").Wrap(err)
+	}
+
+	old = s.current.Load()
+	if fields := keepStartupSettings(old, next); len(fields) > 0 {
+		slog.Warn("Config changes require a restart to take effect", "fields", fields)
+	}
+
+	s.current.Store(next)
+	slog.Info("Configuration reloaded", "config_file", s.path)
+	return old, next, nil
+}
+
+// Watch reloads the configuration when the config file changes or the
+// process receives SIGHUP, until ctx is cancelled
+func (s *Store) Watch(ctx context.Context) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var events <-chan fsnotify.Event
+	var watchErrors <-chan error
+	if s.path != "" {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return oops.With("context", "failed to create config watcher").Wrap(err)
+		}
+		defer watcher.Close()
+
+		// Watch the directory so files replaced by rename are still seen
+		if err := watcher.Add(filepath.Dir(s.path)); err != nil {
+			return oops.With("config_file", s.path, "context", "failed to watch config directory").Wrap(err)
+		}
+		events = watcher.Events
+		watchErrors = watcher.Errors
+	}
+
+	debounce := time.NewTimer(reloadDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	reload := func() {
+		if err := s.Reload(); err != nil {
+			slog.Error("Failed to reload configuration, keeping previous config", "error", err)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			slog.Info("Received SIGHUP, reloading configuration")
+			reload()
+		case event := <-events:
+			if filepath.Clean(event.Name) != filepath.Clean(s.path) {
+				continue
+			}
+			if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) || event.Has(fsnotify.Rename) {
+				debounce.Reset(reloadDebounce)
+			}
+		case <-debounce.C:
+			reload()
+		case err := <-watchErrors:
+			slog.Error("Config watcher error", "error", err)
+		}
+	}
+}
+
+// keepStartupSettings copies settings that cannot change at runtime from old
+// to next and returns the names of those that differed
+func keepStartupSettings(old, next *Config) []string {
+	var fields []string
+	if next.TelegramBotToken != old.TelegramBotToken {
+		fields = append(fields, "telegram_bot_token")
+		next.TelegramBotToken = old.TelegramBotToken
+	}
+	if next.TelegramAPIURL != old.TelegramAPIURL {
+		fields = append(fields, "telegram_api_url")
+		next.TelegramAPIURL = old.TelegramAPIURL
+	}
+	if next.StoragePath != old.StoragePath {
+		fields = append(fields, "storage_path")
+		next.StoragePath = old.StoragePath
+	}
+	if next.StorageBackend != old.StorageBackend {
+		fields = append(fields, "storage_backend")
+		next.StorageBackend = old.StorageBackend
+	}
+	if next.HTTPPort != old.HTTPPort {
+		fields = append(fields, "http_port")
+		next.HTTPPort = old.HTTPPort
+	}
+	if next.AppEnv != old.AppEnv {
+		fields = append(fields, "app_env")
+		next.AppEnv = old.AppEnv
+	}
+	if next.DashboardSessionSecret != old.DashboardSessionSecret {
+		fields = append(fields, "dashboard_session_secret")
+		next.DashboardSessionSecret = old.DashboardSessionSecret
+	}
+	return fields
+}
@@ -3,8 +3,32 @@ package errors
 import "errors"
 
 var (
-	ErrMissingBotToken = errors.New("TELEGRAM_BOT_TOKEN environment variable is required")
-	ErrUnauthorized    = errors.New("unauthorized user")
-	ErrChannelNotFound = errors.New("channel not found")
-	ErrInvalidFilter   = errors.New("invalid filter")
+	ErrMissingBotToken    = errors.New("TELEGRAM_BOT_TOKEN environment variable is required")
+	ErrUnauthorized       = errors.New("unauthorized user")
+	ErrForbidden          = errors.New("permission denied")
+	ErrInvalidRole        = errors.New("invalid role")
+	ErrLastOwner          = errors.New("cannot remove or demote the last owner")
+	ErrChannelNotFound    = errors.New("channel not found")
+	ErrCollectionNotFound = errors.New("collection not found")
+	ErrInvalidFilter      = errors.New("invalid filter")
+	ErrFeedNotFound       = errors.New("feed not found")
+	ErrFeedExists         = errors.New("feed already exists")
+	ErrInvalidFeedName    = errors.New("invalid feed name")
+	ErrChannelExists      = errors.New("channel already exists")
+	ErrMessageNotFound    = errors.New("message not found")
+	ErrMediaNotFound      = errors.New("media file not found")
+	ErrMediaUnavailable   = errors.New("media downloads are unavailable")
+	ErrUnsupportedImage   = errors.New("media file is not a supported image")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrUserExists         = errors.New("user already exists")
+	ErrInvalidRequest     = errors.New("invalid request")
+	ErrInvalidLogin       = errors.New("invalid or expired login")
+	ErrInvalidSession     = errors.New("invalid or expired session")
+	ErrInvalidFeedToken   = errors.New("invalid feed token")
+	ErrInvalidClaimCode   = errors.New("invalid or already used claim code")
+	ErrBackupVersion      = errors.New("unsupported backup format version")
+	ErrBackupCorrupt      = errors.New("backup archive is corrupt")
+	ErrCorruptRecord      = errors.New("stored record is corrupt")
+	ErrSchemaVersion      = errors.New("record was written by a newer version")
+	ErrTransferMismatch   = errors.New("transferred data does not match the source")
 )
@@ -0,0 +1,66 @@
+package fileutil
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/samber/oops"
+)
+
+// tempMarker is part of the name of every temporary file, so files left
+// behind by a crash can be recognised and removed
+const tempMarker = ".tmp-"
+
+// WriteFileAtomic writes data to path so that readers and crashes see either
+// the previous contents or the new ones, never a truncated file. The data is
+// written to a temporary file in the same directory, synced, and renamed over
+// path; the directory is synced so the rename itself is durable.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+tempMarker+"*")
+	if err != nil {
+		return oops.With("path", path, "context", "failed to create temporary file").Wrap(err)
+	}
+	// Removing fails harmlessly once the file has been renamed
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return oops.With("path", path, "context", "failed to write temporary file").Wrap(err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return oops.With("path", path, "context", "failed to set file mode").Wrap(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return oops.With("path", path, "context", "failed to sync temporary file").Wrap(err)
+	}
+	if err := tmp.Close(); err != nil {
+		return oops.With("path", path, "context", "failed to close temporary file").Wrap(err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return oops.With("path", path, "context", "failed to replace file").Wrap(err)
+	}
+
+	return syncDir(dir)
+}
+
+// IsTempFile reports whether name is a temporary file created by WriteFileAtomic
+func IsTempFile(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.Contains(name, tempMarker)
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return oops.With("dir", dir, "context", "failed to open directory").Wrap(err)
+	}
+	defer d.Close()
+
+	if err := d.Sync(); err != nil {
+		return oops.With("dir", dir, "context", "failed to sync directory").Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/samber/oops"
+)
+
+// legacyVersion is the version of records written before the envelope
+// existed, stored as the bare record
+const legacyVersion = 1
+
+// Envelope wraps a stored record with the schema version it was written in
+type Envelope struct {
+	SchemaVersion int             `json:"schema_version"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// Upgrade converts a decoded record from one schema version to the next
+type Upgrade func(record map[string]any) error
+
+// Registry holds the upgrades of one record type. Upgrades are applied in
+// order: the first upgrades version 1 to 2, the second 2 to 3, and so on, so
+// the current version is one more than the number of upgrades.
+type Registry struct {
+	kind     string
+	upgrades []Upgrade
+}
+
+// New creates the registry of a record type
+func New(kind string, upgrades ...Upgrade) *Registry {
+	return &Registry{kind: kind, upgrades: upgrades}
+}
+
+// Version returns the schema version records are written in
+func (r *Registry) Version() int {
+	return legacyVersion + len(r.upgrades)
+}
+
+// Marshal encodes a record in an envelope with the current version
+func (r *Registry) Marshal(record any) ([]byte, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, oops.With("kind", r.kind).Wrap(err)
+	}
+	return json.MarshalIndent(Envelope{SchemaVersion: r.Version(), Data: data}, "", "  ")
+}
+
+// Unmarshal decodes an enveloped or legacy bare record into record,
+// upgrading it to the current version first. It returns the version the
+// record was stored in. Undecodable data is reported as ErrCorruptRecord and
+// records from a newer version as ErrSchemaVersion.
+func (r *Registry) Unmarshal(data []byte, record any) (int, error) {
+	var envelope Envelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return 0, oops.With("kind", r.kind).Wrapf(errors.ErrCorruptRecord, "failed to unmarshal %s: %v", r.kind, err)
+	}
+
+	version, payload := envelope.SchemaVersion, []byte(envelope.Data)
+	switch {
+	case version == 0:
+		version, payload = legacyVersion, data
+	case version > r.Version():
+		return version, oops.With("kind", r.kind, "version", version, "supported", r.Version()).Wrap(errors.ErrSchemaVersion)
+	case version < legacyVersion || len(payload) == 0:
+		return version, oops.With("kind", r.kind, "version", version).Wrapf(errors.ErrCorruptRecord, "invalid %s envelope", r.kind)
+	}
+
+	if version < r.Version() {
+		upgraded, err := r.upgrade(payload, version)
+		if err != nil {
+			return version, err
+		}
+		payload = upgraded
+	}
+
+	if err := json.Unmarshal(payload, record); err != nil {
+		return version, oops.With("kind", r.kind, "version", version).Wrapf(errors.ErrCorruptRecord, "failed to unmarshal %s: %v", r.kind, err)
+	}
+	return version, nil
+}
+
+// upgrade runs the upgrades from version to the current one on the record's
+// JSON. Numbers are kept as json.Number so large IDs survive unchanged.
+func (r *Registry) upgrade(payload []byte, version int) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var fields map[string]any
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, oops.With("kind", r.kind, "version", version).Wrapf(errors.ErrCorruptRecord, "failed to unmarshal %s: %v", r.kind, err)
+	}
+
+	for v := version; v < r.Version(); v++ {
+		if err := r.upgrades[v-legacyVersion](fields); err != nil {
+			return nil, oops.With("kind", r.kind, "from", v, "to", v+1).Wrapf(errors.ErrCorruptRecord, "failed to upgrade %s: %v", r.kind, err)
+		}
+	}
+
+	return json.Marshal(fields)
+}
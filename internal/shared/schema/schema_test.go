@@ -0,0 +1,102 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	appErrors "github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+)
+
+// record is the version 2 shape of the test record type, whose name field
+// was renamed to title by the only upgrade
+type record struct {
+	Title string      `json:"title"`
+	Count json.Number `json:"count"`
+}
+
+func testRegistry() *Registry {
+	return New("record", func(fields map[string]any) error {
+		fields["title"] = fields["name"]
+		delete(fields, "name")
+		return nil
+	})
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture %s: %v", name, err)
+	}
+	return data
+}
+
+func TestUnmarshalLegacyRecord(t *testing.T) {
+	var got record
+	version, err := testRegistry().Unmarshal(readFixture(t, "legacy.json"), &got)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if version != 1 {
+		t.Errorf("version = %d, want 1", version)
+	}
+	if got.Title != "legacy" {
+		t.Errorf("title = %q, want %q", got.Title, "legacy")
+	}
+	// Numbers beyond float64 precision survive the upgrade
+	if got.Count != "9007199254740993" {
+		t.Errorf("count = %s, want 9007199254740993", got.Count)
+	}
+}
+
+func TestUnmarshalCurrentVersion(t *testing.T) {
+	var got record
+	version, err := testRegistry().Unmarshal(readFixture(t, "v2.json"), &got)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if version != 2 || got.Title != "current" || got.Count != "2" {
+		t.Errorf("got version %d, record %+v", version, got)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	registry := testRegistry()
+	want := record{Title: "round trip", Count: "7"}
+	data, err := registry.Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var got record
+	version, err := registry.Unmarshal(data, &got)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if version != registry.Version() || got != want {
+		t.Errorf("got version %d, record %+v; want version %d, record %+v", version, got, registry.Version(), want)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{"newer version", readFixture(t, "future.json"), appErrors.ErrSchemaVersion},
+		{"invalid version", readFixture(t, "invalid_version.json"), appErrors.ErrCorruptRecord},
+		{"not JSON", []byte("{"), appErrors.ErrCorruptRecord},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got record
+			if _, err := testRegistry().Unmarshal(tt.data, &got); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,640 @@
+package http
+
+import (
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	channelDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/domain"
+	userDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/user/domain"
+	appErrors "github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/samber/lo"
+	"github.com/samber/oops"
+)
+
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// maxRequestBody limits the size of admin API request bodies
+const maxRequestBody = 1 << 20
+
+type createChannelRequest struct {
+	ID         string                 `json:"id"`
+	Username   string                 `json:"username"`
+	Title      string                 `json:"title"`
+	IsActive   *bool                  `json:"is_active"`
+	AddedBy    int64                  `json:"added_by"`
+	SharedWith []int64                `json:"shared_with"`
+	Filters    []channelDomain.Filter `json:"filters"`
+}
+
+type updateChannelRequest struct {
+	Username   *string  `json:"username"`
+	Title      *string  `json:"title"`
+	IsActive   *bool    `json:"is_active"`
+	AddedBy    *int64   `json:"added_by"`
+	SharedWith *[]int64 `json:"shared_with"`
+	// Podcast replaces the podcast settings of the channel
+	Podcast *channelDomain.Podcast `json:"podcast"`
+	// TitleTemplate formats item titles, empty restores the default titles
+	TitleTemplate *string `json:"title_template"`
+	// DeletedPosts is hide or mark
+	DeletedPosts *string `json:"deleted_posts"`
+	// Rewrites replaces the rewrite rules of the channel
+	Rewrites *[]channelDomain.RewriteRule `json:"rewrites"`
+}
+
+type createUserRequest struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+type updateUserRequest struct {
+	Role string `json:"role"`
+}
+
+// apiActor is the user API key holders act as; keys are issued by the operator
+// so they carry owner rights
+var apiActor = &userDomain.User{Role: userDomain.RoleOwner}
+
+// registerAPIRoutes registers the REST admin API
+func (s *Server) registerAPIRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/openapi.yaml", s.handleOpenAPI)
+
+	mux.Handle("GET /api/v1/channels", s.requireAPIKey(s.handleAPIListChannels))
+	mux.Handle("POST /api/v1/channels", s.requireAPIKey(s.handleAPICreateChannel))
+	mux.Handle("GET /api/v1/channels/{channelID}", s.requireAPIKey(s.handleAPIGetChannel))
+	mux.Handle("PATCH /api/v1/channels/{channelID}", s.requireAPIKey(s.handleAPIUpdateChannel))
+	mux.Handle("DELETE /api/v1/channels/{channelID}", s.requireAPIKey(s.handleAPIDeleteChannel))
+
+	mux.Handle("GET /api/v1/channels/{channelID}/filters", s.requireAPIKey(s.handleAPIListFilters))
+	mux.Handle("POST /api/v1/channels/{channelID}/filters", s.requireAPIKey(s.handleAPIAddFilter))
+	mux.Handle("DELETE /api/v1/channels/{channelID}/filters/{index}", s.requireAPIKey(s.handleAPIRemoveFilter))
+
+	mux.Handle("GET /api/v1/channels/{channelID}/feeds", s.requireAPIKey(s.handleAPIListFeeds))
+	mux.Handle("PUT /api/v1/channels/{channelID}/feeds/{feedName}", s.requireAPIKey(s.handleAPIPutFeed))
+	mux.Handle("DELETE /api/v1/channels/{channelID}/feeds/{feedName}", s.requireAPIKey(s.handleAPIDeleteFeed))
+
+	mux.Handle("GET /api/v1/collections", s.requireAPIKey(s.handleAPIListCollections))
+	mux.Handle("GET /api/v1/collections/{name}", s.requireAPIKey(s.handleAPIGetCollection))
+
+	mux.Handle("GET /api/v1/drift", s.requireAPIKey(s.handleAPIDrift))
+	mux.Handle("POST /api/v1/reconcile", s.requireAPIKey(s.handleAPIReconcile))
+
+	mux.Handle("GET /api/v1/search", s.requireAPIKey(s.handleAPISearch))
+	mux.Handle("GET /api/search", s.requireAPIKey(s.handleAPISearch))
+
+	mux.Handle("GET /api/v1/users", s.requireAPIKey(s.handleAPIListUsers))
+	mux.Handle("POST /api/v1/users", s.requireAPIKey(s.handleAPICreateUser))
+	mux.Handle("GET /api/v1/users/{userID}", s.requireAPIKey(s.handleAPIGetUser))
+	mux.Handle("PATCH /api/v1/users/{userID}", s.requireAPIKey(s.handleAPIUpdateUser))
+	mux.Handle("DELETE /api/v1/users/{userID}", s.requireAPIKey(s.handleAPIDeleteUser))
+}
+
+// requireAPIKey rejects requests without a configured API key, sent either
+// as a bearer token or in the X-API-Key header
+func (s *Server) requireAPIKey(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-API-Key")
+		if key == "" {
+			key, _ = strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		}
+
+		if key == "" || !s.validAPIKey(key) {
+			s.writeAPIError(w, oops.Wrapf(appErrors.ErrUnauthorized, "missing or invalid API key"))
+			return
+		}
+
+		next(w, r)
+	})
+}
+
+func (s *Server) validAPIKey(key string) bool {
+	valid := false
+	for _, configured := range s.cfg.Get().APIKeys {
+		if configured != "" && subtle.ConstantTimeCompare([]byte(key), []byte(configured)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.WriteHeader(http.StatusOK)
+	w.Write(openAPISpec)
+}
+
+func (s *Server) handleAPIListChannels(w http.ResponseWriter, r *http.Request) {
+	var channels []*channelDomain.Channel
+	var err error
+	if value := r.URL.Query().Get("user_id"); value != "" {
+		// Scope the list to one tenant: channels the user added or that were shared with them
+		userID, parseErr := strconv.ParseInt(value, 10, 64)
+		if parseErr != nil {
+			s.writeAPIError(w, oops.Wrapf(appErrors.ErrInvalidRequest, "invalid user_id"))
+			return
+		}
+		channels, err = s.channelService.GetChannelsAccessibleBy(userID)
+	} else {
+		channels, err = s.channelService.GetAllChannels()
+	}
+	if err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, lo.CoalesceSliceOrEmpty(channels))
+}
+
+func (s *Server) handleAPICreateChannel(w http.ResponseWriter, r *http.Request) {
+	var req createChannelRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	if req.Username == "" {
+		s.writeAPIError(w, oops.Wrapf(appErrors.ErrInvalidRequest, "username is required"))
+		return
+	}
+	if req.ID != "" && !channelDomain.ValidChannelID(req.ID) {
+		s.writeAPIError(w, oops.With("channel_id", req.ID).Wrapf(appErrors.ErrInvalidRequest, "id must be a Telegram chat ID"))
+		return
+	}
+	// API keys belong to no user, so the owner must be named
+	if err := s.validateOwner(req.AddedBy); err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+	if err := validateFilters(req.Filters); err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	var channel *channelDomain.Channel
+	if req.ID == "" {
+		// Resolve the channel ID through Telegram when not provided
+		resolved, err := s.channelService.ResolveChannel(r.Context(), req.Username, req.AddedBy)
+		if err != nil {
+			s.writeAPIError(w, oops.Wrapf(appErrors.ErrInvalidRequest, "failed to resolve channel %s, provide its id", req.Username))
+			return
+		}
+		channel = resolved
+	} else {
+		username := strings.TrimPrefix(req.Username, "@")
+		channel = &channelDomain.Channel{
+			ID:         req.ID,
+			Username:   username,
+			Title:      username,
+			AddedAt:    time.Now(),
+			LastUpdate: time.Now(),
+			IsActive:   true,
+		}
+	}
+
+	if _, err := s.channelService.GetChannel(channel.ID); err == nil {
+		s.writeAPIError(w, oops.With("channel_id", channel.ID).Wrap(appErrors.ErrChannelExists))
+		return
+	}
+
+	channel.AddedBy = req.AddedBy
+	for _, userID := range req.SharedWith {
+		channel.Share(userID)
+	}
+	if req.Title != "" {
+		channel.Title = req.Title
+	}
+	if req.IsActive != nil {
+		channel.IsActive = *req.IsActive
+	}
+	channel.Filters = lo.CoalesceSliceOrEmpty(req.Filters)
+
+	if err := s.channelService.SaveChannel(channel); err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+	if channel.IsActive {
+		s.channelService.AddChannel(channel.ID)
+	}
+
+	writeJSON(w, http.StatusCreated, channel)
+}
+
+func (s *Server) handleAPIGetChannel(w http.ResponseWriter, r *http.Request) {
+	channel, err := s.channelService.GetChannel(r.PathValue("channelID"))
+	if err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, channel)
+}
+
+func (s *Server) handleAPIUpdateChannel(w http.ResponseWriter, r *http.Request) {
+	channel, err := s.channelService.GetChannel(r.PathValue("channelID"))
+	if err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	var req updateChannelRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	if req.Username != nil {
+		channel.Username = strings.TrimPrefix(*req.Username, "@")
+	}
+	if req.Title != nil {
+		channel.Title = *req.Title
+	}
+	if req.IsActive != nil {
+		channel.IsActive = *req.IsActive
+	}
+	if req.AddedBy != nil {
+		if err := s.validateOwner(*req.AddedBy); err != nil {
+			s.writeAPIError(w, err)
+			return
+		}
+		channel.AddedBy = *req.AddedBy
+	}
+	if req.SharedWith != nil {
+		channel.SharedWith = nil
+		for _, userID := range *req.SharedWith {
+			channel.Share(userID)
+		}
+	}
+	if req.Podcast != nil {
+		if err := req.Podcast.Validate(); err != nil {
+			s.writeAPIError(w, err)
+			return
+		}
+		channel.Podcast = req.Podcast
+	}
+	if req.TitleTemplate != nil {
+		if err := channelDomain.ValidateTitleTemplate(*req.TitleTemplate); err != nil {
+			s.writeAPIError(w, err)
+			return
+		}
+		channel.TitleTemplate = *req.TitleTemplate
+	}
+	if req.DeletedPosts != nil {
+		policy, err := channelDomain.ParseDeletedPostPolicy(*req.DeletedPosts)
+		if err != nil {
+			s.writeAPIError(w, oops.Wrapf(appErrors.ErrInvalidRequest, "%v", err))
+			return
+		}
+		channel.DeletedPosts = policy
+	}
+	if req.Rewrites != nil {
+		for _, rule := range *req.Rewrites {
+			if err := rule.Validate(); err != nil {
+				s.writeAPIError(w, err)
+				return
+			}
+		}
+		channel.Rewrites = *req.Rewrites
+	}
+
+	if err := s.channelService.SaveChannel(channel); err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	if channel.IsActive {
+		s.channelService.AddChannel(channel.ID)
+	} else {
+		s.channelService.RemoveChannel(channel.ID)
+	}
+
+	writeJSON(w, http.StatusOK, channel)
+}
+
+func (s *Server) handleAPIDeleteChannel(w http.ResponseWriter, r *http.Request) {
+	if err := s.channelService.DeleteChannel(r.PathValue("channelID")); err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleAPIListFilters(w http.ResponseWriter, r *http.Request) {
+	channel, err := s.channelService.GetChannel(r.PathValue("channelID"))
+	if err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, lo.CoalesceSliceOrEmpty(channel.Filters))
+}
+
+func (s *Server) handleAPIAddFilter(w http.ResponseWriter, r *http.Request) {
+	channel, err := s.channelService.GetChannel(r.PathValue("channelID"))
+	if err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	var filter channelDomain.Filter
+	if err := decodeJSON(r, &filter); err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+	if err := validateFilters([]channelDomain.Filter{filter}); err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	channel.Filters = append(channel.Filters, filter)
+	if err := s.channelService.SaveChannel(channel); err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, channel.Filters)
+}
+
+func (s *Server) handleAPIRemoveFilter(w http.ResponseWriter, r *http.Request) {
+	channel, err := s.channelService.GetChannel(r.PathValue("channelID"))
+	if err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	// Filter indexes are 1-based, matching the bot commands
+	index, err := strconv.Atoi(r.PathValue("index"))
+	if err != nil || index < 1 || index > len(channel.Filters) {
+		s.writeAPIError(w, oops.Wrapf(appErrors.ErrInvalidFilter, "filter index out of range"))
+		return
+	}
+
+	channel.Filters = append(channel.Filters[:index-1], channel.Filters[index:]...)
+	if err := s.channelService.SaveChannel(channel); err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, lo.CoalesceSliceOrEmpty(channel.Filters))
+}
+
+func (s *Server) handleAPIListFeeds(w http.ResponseWriter, r *http.Request) {
+	channel, err := s.channelService.GetChannel(r.PathValue("channelID"))
+	if err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, lo.CoalesceSliceOrEmpty(channel.Feeds))
+}
+
+// handleAPIPutFeed creates or replaces a named feed definition
+func (s *Server) handleAPIPutFeed(w http.ResponseWriter, r *http.Request) {
+	channel, err := s.channelService.GetChannel(r.PathValue("channelID"))
+	if err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	feedName := r.PathValue("feedName")
+	if !channelDomain.ValidFeedName(feedName) {
+		s.writeAPIError(w, oops.With("feed_name", feedName).Wrap(appErrors.ErrInvalidFeedName))
+		return
+	}
+
+	var definition channelDomain.FeedDefinition
+	if err := decodeJSON(r, &definition); err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+	if err := validateFilters(definition.Filters); err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+	if definition.Limit < 0 || definition.MaxAgeDays < 0 {
+		s.writeAPIError(w, oops.Wrapf(appErrors.ErrInvalidRequest, "limit and max_age_days must not be negative"))
+		return
+	}
+	definition.Name = feedName
+	definition.Filters = lo.CoalesceSliceOrEmpty(definition.Filters)
+
+	status := http.StatusOK
+	if existing, ok := channel.FindFeed(feedName); ok {
+		*existing = definition
+	} else {
+		channel.Feeds = append(channel.Feeds, definition)
+		status = http.StatusCreated
+	}
+
+	if err := s.channelService.SaveChannel(channel); err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	writeJSON(w, status, definition)
+}
+
+func (s *Server) handleAPIDeleteFeed(w http.ResponseWriter, r *http.Request) {
+	channel, err := s.channelService.GetChannel(r.PathValue("channelID"))
+	if err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	feedName := r.PathValue("feedName")
+	if !channel.RemoveFeed(feedName) {
+		s.writeAPIError(w, oops.With("feed_name", feedName).Wrap(appErrors.ErrFeedNotFound))
+		return
+	}
+
+	if err := s.channelService.SaveChannel(channel); err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleAPIListCollections(w http.ResponseWriter, r *http.Request) {
+	collections, err := s.channelService.GetAllCollections()
+	if err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, lo.CoalesceSliceOrEmpty(collections))
+}
+
+func (s *Server) handleAPIGetCollection(w http.ResponseWriter, r *http.Request) {
+	collection, err := s.channelService.GetCollection(r.PathValue("name"))
+	if err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, collection)
+}
+
+// handleAPIDrift reports differences between the declared and stored channels
+// without changing anything
+func (s *Server) handleAPIDrift(w http.ResponseWriter, r *http.Request) {
+	cfg := s.cfg.Get()
+	report := s.channelService.Reconcile(r.Context(), cfg.Channels, cfg.Collections, false)
+	writeJSON(w, http.StatusOK, report)
+}
+
+// handleAPIReconcile applies the declared channels to storage
+func (s *Server) handleAPIReconcile(w http.ResponseWriter, r *http.Request) {
+	cfg := s.cfg.Get()
+	report := s.channelService.Reconcile(r.Context(), cfg.Channels, cfg.Collections, true)
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (s *Server) handleAPIListUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := s.userService.GetAllUsers()
+	if err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, lo.CoalesceSliceOrEmpty(users))
+}
+
+func (s *Server) handleAPICreateUser(w http.ResponseWriter, r *http.Request) {
+	var req createUserRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+	if req.ID == 0 {
+		s.writeAPIError(w, oops.Wrapf(appErrors.ErrInvalidRequest, "id is required"))
+		return
+	}
+
+	// Roles of stored users are changed with PATCH, which guards the last owner
+	if _, err := s.userService.GetUser(req.ID); err == nil {
+		s.writeAPIError(w, oops.With("user_id", req.ID).Wrap(appErrors.ErrUserExists))
+		return
+	}
+
+	role := userDomain.RoleViewer
+	if req.Role != "" {
+		parsed, err := userDomain.ParseRole(req.Role)
+		if err != nil {
+			s.writeAPIError(w, oops.With("role", req.Role).Wrap(appErrors.ErrInvalidRole))
+			return
+		}
+		role = parsed
+	}
+
+	user, err := s.userService.AddUser(apiActor, req.ID, strings.TrimPrefix(req.Username, "@"), role)
+	if err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, user)
+}
+
+func (s *Server) handleAPIGetUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseUserID(r)
+	if err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	user, err := s.userService.GetUser(userID)
+	if err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, user)
+}
+
+func (s *Server) handleAPIUpdateUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseUserID(r)
+	if err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	var req updateUserRequest
+	if err := decodeJSON(r, &req); err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+	role, err := userDomain.ParseRole(req.Role)
+	if err != nil {
+		s.writeAPIError(w, oops.With("role", req.Role).Wrap(appErrors.ErrInvalidRole))
+		return
+	}
+
+	user, err := s.userService.SetRole(apiActor, userID, role)
+	if err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, user)
+}
+
+func (s *Server) handleAPIDeleteUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseUserID(r)
+	if err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	if err := s.userService.RemoveUser(apiActor, userID); err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeJSON strictly decodes a JSON request body into v
+func decodeJSON(r *http.Request, v any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxRequestBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return oops.Wrapf(appErrors.ErrInvalidRequest, "malformed JSON body: %v", err)
+	}
+	return nil
+}
+
+func validateFilters(filters []channelDomain.Filter) error {
+	for i, filter := range filters {
+		if err := filter.Validate(); err != nil {
+			return oops.With("index", i).Wrap(err)
+		}
+	}
+	return nil
+}
+
+// validateOwner checks that a channel owner given in a request is a stored user
+func (s *Server) validateOwner(userID int64) error {
+	if userID == 0 {
+		return oops.Wrapf(appErrors.ErrInvalidRequest, "added_by is required")
+	}
+	if _, err := s.userService.GetUser(userID); err != nil {
+		return oops.With("user_id", userID).Wrapf(appErrors.ErrInvalidRequest, "added_by is not a user")
+	}
+	return nil
+}
+
+func parseUserID(r *http.Request) (int64, error) {
+	userID, err := strconv.ParseInt(r.PathValue("userID"), 10, 64)
+	if err != nil {
+		return 0, oops.Wrapf(appErrors.ErrInvalidRequest, "invalid user id")
+	}
+	return userID, nil
+}
@@ -0,0 +1,489 @@
+package http
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	authDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/auth/domain"
+	channelDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/domain"
+	feedDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/feed/domain"
+	userDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/user/domain"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+//go:embed static
+var staticFS embed.FS
+
+// sessionCookie is the name of the dashboard session cookie
+const sessionCookie = "rss_dashboard_session"
+
+var dashboardTemplates = parseDashboardTemplates("login", "channels", "channel", "preview")
+
+// parseDashboardTemplates parses every page together with the shared layout
+func parseDashboardTemplates(pages ...string) map[string]*template.Template {
+	funcs := template.FuncMap{
+		"formatTime": func(t time.Time) string {
+			if t.IsZero() {
+				return "never"
+			}
+			return t.Format("2006-01-02 15:04")
+		},
+		"join": strings.Join,
+		"add":  func(a, b int) int { return a + b },
+		// dict builds a map from key/value pairs for passing to sub-templates
+		"dict": func(pairs ...any) map[string]any {
+			values := make(map[string]any, len(pairs)/2)
+			for i := 0; i+1 < len(pairs); i += 2 {
+				values[fmt.Sprint(pairs[i])] = pairs[i+1]
+			}
+			return values
+		},
+	}
+
+	templates := make(map[string]*template.Template, len(pages))
+	for _, page := range pages {
+		templates[page] = template.Must(template.New("layout.html").Funcs(funcs).ParseFS(templateFS,
+			"templates/layout.html", "templates/"+page+".html"))
+	}
+	return templates
+}
+
+// dashboardPage is the data passed to every dashboard template
+type dashboardPage struct {
+	Title  string
+	UserID int64
+	User   *userDomain.User
+	CSRF   string
+	Error  string
+	Data   any
+}
+
+// feedLink is a named feed URL shown with a copy button
+type feedLink struct {
+	Name string
+	URL  string
+}
+
+type channelRow struct {
+	Channel *channelDomain.Channel
+	Feeds   []feedLink
+}
+
+type channelsPage struct {
+	Rows      []channelRow
+	ShowAll   bool
+	CanToggle bool
+}
+
+type channelPage struct {
+	Channel     *channelDomain.Channel
+	Feeds       []feedLink
+	FilterTypes []string
+	CanEdit     bool
+}
+
+type previewPage struct {
+	Channel  *channelDomain.Channel
+	FeedName string
+	Feed     *feedDomain.Feed
+	Items    []previewItem
+}
+
+type previewItem struct {
+	Title   string
+	Link    string
+	Created time.Time
+	Content template.HTML
+}
+
+// dashboardHandler is a handler that runs with a verified session
+type dashboardHandler func(w http.ResponseWriter, r *http.Request, session *authDomain.Session, page *dashboardPage)
+
+// registerDashboardRoutes registers the server-rendered admin dashboard
+func (s *Server) registerDashboardRoutes(mux *http.ServeMux) {
+	static, _ := fs.Sub(staticFS, "static")
+	mux.Handle("GET /dashboard/static/", http.StripPrefix("/dashboard/static/", http.FileServerFS(static)))
+
+	mux.HandleFunc("GET /dashboard/login", s.handleDashboardLogin)
+	mux.HandleFunc("GET /dashboard/login/telegram", s.handleDashboardTelegramLogin)
+	mux.Handle("POST /dashboard/logout", s.requireSession(s.handleDashboardLogout))
+
+	mux.Handle("GET /dashboard", s.requireSession(s.handleDashboardChannels))
+	mux.Handle("GET /dashboard/channels/{channelID}", s.requireSession(s.handleDashboardChannel))
+	mux.Handle("GET /dashboard/channels/{channelID}/preview", s.requireSession(s.handleDashboardPreview))
+	mux.Handle("POST /dashboard/channels/{channelID}/filters", s.requireSession(s.handleDashboardAddFilter))
+	mux.Handle("POST /dashboard/channels/{channelID}/filters/toggle", s.requireSession(s.handleDashboardToggleFilter))
+	mux.Handle("POST /dashboard/channels/{channelID}/filters/delete", s.requireSession(s.handleDashboardDeleteFilter))
+}
+
+// requireSession redirects to the login page unless the request carries a
+// valid session of an authorized user. State-changing requests must also
+// carry the CSRF token bound to the session.
+func (s *Server) requireSession(next dashboardHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(sessionCookie)
+		if err != nil {
+			http.Redirect(w, r, "/dashboard/login", http.StatusSeeOther)
+			return
+		}
+
+		session, err := s.authService.ParseSession(cookie.Value)
+		if err != nil {
+			clearSessionCookie(w)
+			http.Redirect(w, r, "/dashboard/login", http.StatusSeeOther)
+			return
+		}
+
+		// Re-check on every request so removed users and role changes apply immediately
+		user, ok := s.userService.Authorize(session.UserID, s.cfg.Get().AllowedUsers)
+		if !ok || !user.Can(userDomain.PermissionUseDashboard) {
+			clearSessionCookie(w)
+			http.Redirect(w, r, "/dashboard/login", http.StatusSeeOther)
+			return
+		}
+
+		csrf := s.authService.CSRFToken(cookie.Value)
+		if r.Method == http.MethodPost && r.PostFormValue("csrf") != csrf {
+			http.Error(w, "Invalid CSRF token", http.StatusForbidden)
+			return
+		}
+
+		next(w, r, session, &dashboardPage{UserID: session.UserID, User: user, CSRF: csrf})
+	})
+}
+
+func (s *Server) handleDashboardLogin(w http.ResponseWriter, r *http.Request) {
+	// One-time link sent by the bot's /weblogin command
+	if token := r.URL.Query().Get("token"); token != "" {
+		userID, err := s.authService.ConsumeLoginToken(token)
+		if err != nil {
+			s.renderDashboard(w, http.StatusUnauthorized, "login", &dashboardPage{
+				Title: "Sign in",
+				Error: "This login link is invalid or has expired. Send /weblogin to the bot for a new one.",
+				Data:  s.cfg.Get().DashboardBotUsername,
+			})
+			return
+		}
+		s.startSession(w, r, userID)
+		return
+	}
+
+	s.renderDashboard(w, http.StatusOK, "login", &dashboardPage{
+		Title: "Sign in",
+		Data:  s.cfg.Get().DashboardBotUsername,
+	})
+}
+
+// handleDashboardTelegramLogin accepts data signed by the Telegram Login Widget
+func (s *Server) handleDashboardTelegramLogin(w http.ResponseWriter, r *http.Request) {
+	identity, err := s.authService.VerifyTelegramLogin(r.URL.Query())
+	if err != nil {
+		s.logger.Warn("Rejected Telegram login", "error", err)
+		s.renderDashboard(w, http.StatusUnauthorized, "login", &dashboardPage{
+			Title: "Sign in",
+			Error: "Telegram login could not be verified.",
+			Data:  s.cfg.Get().DashboardBotUsername,
+		})
+		return
+	}
+
+	s.startSession(w, r, identity.ID)
+}
+
+func (s *Server) startSession(w http.ResponseWriter, r *http.Request, userID int64) {
+	if user, ok := s.userService.Authorize(userID, s.cfg.Get().AllowedUsers); !ok || !user.Can(userDomain.PermissionUseDashboard) {
+		s.renderDashboard(w, http.StatusForbidden, "login", &dashboardPage{
+			Title: "Sign in",
+			Error: "You are not authorized to use this dashboard.",
+			Data:  s.cfg.Get().DashboardBotUsername,
+		})
+		return
+	}
+
+	value, session := s.authService.NewSession(userID)
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    value,
+		Path:     "/dashboard",
+		Expires:  session.ExpiresAt,
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	s.logger.Info("Dashboard login", "user_id", userID)
+	http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+}
+
+// handleDashboardLogout signs the user out of every session, not just this
+// browser, so a leaked session cookie stops working too
+func (s *Server) handleDashboardLogout(w http.ResponseWriter, r *http.Request, session *authDomain.Session, page *dashboardPage) {
+	clearSessionCookie(w)
+	if err := s.authService.RevokeSessions(session.UserID); err != nil {
+		s.logger.Error("Failed to revoke dashboard sessions", "user_id", session.UserID, "error", err)
+		http.Error(w, "Failed to sign out of other sessions", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/dashboard/login", http.StatusSeeOther)
+}
+
+func (s *Server) handleDashboardChannels(w http.ResponseWriter, r *http.Request, session *authDomain.Session, page *dashboardPage) {
+	// Admins see only their own channels unless they ask for all of them
+	showAll := page.User.Can(userDomain.PermissionManageAllChannels) && r.URL.Query().Get("all") == "1"
+
+	var channels []*channelDomain.Channel
+	var err error
+	if showAll {
+		channels, err = s.channelService.GetAllChannels()
+	} else {
+		channels, err = s.channelService.GetChannelsAccessibleBy(page.User.ID)
+	}
+	if err != nil {
+		s.logger.Error("Failed to list channels", "error", err)
+		http.Error(w, "Failed to list channels", http.StatusInternalServerError)
+		return
+	}
+
+	rows := make([]channelRow, 0, len(channels))
+	for _, channel := range channels {
+		rows = append(rows, channelRow{Channel: channel, Feeds: s.feedLinks(r, channel)})
+	}
+
+	page.Title = "Channels"
+	page.Data = channelsPage{
+		Rows:      rows,
+		ShowAll:   showAll,
+		CanToggle: page.User.Can(userDomain.PermissionManageAllChannels),
+	}
+	s.renderDashboard(w, http.StatusOK, "channels", page)
+}
+
+func (s *Server) handleDashboardChannel(w http.ResponseWriter, r *http.Request, session *authDomain.Session, page *dashboardPage) {
+	channel, ok := s.dashboardChannel(w, r, page)
+	if !ok {
+		return
+	}
+
+	page.Title = channel.Title
+	page.Error = r.URL.Query().Get("error")
+	page.Data = channelPage{
+		Channel:     channel,
+		Feeds:       s.feedLinks(r, channel),
+		FilterTypes: channelDomain.FilterTypeNames(),
+		CanEdit:     page.User.CanEdit(channel),
+	}
+	s.renderDashboard(w, http.StatusOK, "channel", page)
+}
+
+func (s *Server) handleDashboardPreview(w http.ResponseWriter, r *http.Request, session *authDomain.Session, page *dashboardPage) {
+	channel, ok := s.dashboardChannel(w, r, page)
+	if !ok {
+		return
+	}
+
+	feedName := r.URL.Query().Get("feed")
+	baseURL := fmt.Sprintf("%s://%s", getScheme(r), r.Host)
+
+	var feed *feedDomain.Feed
+	var err error
+	if feedName == "" {
+		feed, err = s.feedService.GenerateFeed(channel.ID, baseURL)
+	} else {
+		feed, err = s.feedService.GenerateNamedFeed(channel.ID, feedName, baseURL)
+	}
+	if err != nil {
+		s.writeFeedError(w, err, "channel_id", channel.ID, "feed_name", feedName)
+		return
+	}
+
+	items := make([]previewItem, 0, len(feed.Items))
+	for _, item := range feed.Items {
+		preview := previewItem{
+			Title:   item.Title,
+			Created: item.Created,
+			Content: sanitizePreview(item.Content),
+		}
+		if item.Link != nil {
+			preview.Link = item.Link.Href
+		}
+		items = append(items, preview)
+	}
+
+	page.Title = "Preview: " + feed.Title
+	page.Data = previewPage{Channel: channel, FeedName: feedName, Feed: feed, Items: items}
+	// The feed HTML carries links from posts; should one slip through
+	// sanitizePreview, no script may run on the dashboard origin
+	w.Header().Set("Content-Security-Policy", previewPolicy)
+	s.renderDashboard(w, http.StatusOK, "preview", page)
+}
+
+func (s *Server) handleDashboardAddFilter(w http.ResponseWriter, r *http.Request, session *authDomain.Session, page *dashboardPage) {
+	channel, ok := s.dashboardEditableChannel(w, r, page)
+	if !ok {
+		return
+	}
+
+	filterType, err := channelDomain.ParseFilterType(r.PostFormValue("type"))
+	if err != nil {
+		s.redirectToChannel(w, r, channel.ID, "Unknown filter type")
+		return
+	}
+
+	var keywords []string
+	for _, keyword := range strings.Split(r.PostFormValue("keywords"), ",") {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			keywords = append(keywords, keyword)
+		}
+	}
+	filter := channelDomain.Filter{Type: filterType, Keywords: keywords, Enabled: true}
+	if err := filter.Validate(); err != nil {
+		s.redirectToChannel(w, r, channel.ID, err.Error())
+		return
+	}
+
+	filters, ok := s.dashboardFilters(w, r, channel)
+	if !ok {
+		return
+	}
+	*filters = append(*filters, filter)
+
+	s.saveDashboardChannel(w, r, channel)
+}
+
+func (s *Server) handleDashboardToggleFilter(w http.ResponseWriter, r *http.Request, session *authDomain.Session, page *dashboardPage) {
+	channel, ok := s.dashboardEditableChannel(w, r, page)
+	if !ok {
+		return
+	}
+
+	filters, ok := s.dashboardFilters(w, r, channel)
+	if !ok {
+		return
+	}
+
+	index, err := strconv.Atoi(r.PostFormValue("index"))
+	if err != nil || index < 0 || index >= len(*filters) {
+		s.redirectToChannel(w, r, channel.ID, "Filter not found")
+		return
+	}
+	(*filters)[index].Enabled = !(*filters)[index].Enabled
+
+	s.saveDashboardChannel(w, r, channel)
+}
+
+func (s *Server) handleDashboardDeleteFilter(w http.ResponseWriter, r *http.Request, session *authDomain.Session, page *dashboardPage) {
+	channel, ok := s.dashboardEditableChannel(w, r, page)
+	if !ok {
+		return
+	}
+
+	filters, ok := s.dashboardFilters(w, r, channel)
+	if !ok {
+		return
+	}
+
+	index, err := strconv.Atoi(r.PostFormValue("index"))
+	if err != nil || index < 0 || index >= len(*filters) {
+		s.redirectToChannel(w, r, channel.ID, "Filter not found")
+		return
+	}
+	*filters = append((*filters)[:index], (*filters)[index+1:]...)
+
+	s.saveDashboardChannel(w, r, channel)
+}
+
+// dashboardChannel loads the channel named in the path or writes a 404.
+// Channels the user cannot see are reported as missing.
+func (s *Server) dashboardChannel(w http.ResponseWriter, r *http.Request, page *dashboardPage) (*channelDomain.Channel, bool) {
+	channel, err := s.channelService.GetChannel(r.PathValue("channelID"))
+	if err != nil || !page.User.CanView(channel) {
+		http.Error(w, "Channel not found", http.StatusNotFound)
+		return nil, false
+	}
+	return channel, true
+}
+
+// dashboardEditableChannel loads the channel named in the path and checks
+// that the session user may change it
+func (s *Server) dashboardEditableChannel(w http.ResponseWriter, r *http.Request, page *dashboardPage) (*channelDomain.Channel, bool) {
+	channel, ok := s.dashboardChannel(w, r, page)
+	if !ok {
+		return nil, false
+	}
+	if !page.User.CanEdit(channel) {
+		http.Error(w, "You cannot change this channel", http.StatusForbidden)
+		return nil, false
+	}
+	return channel, true
+}
+
+// dashboardFilters returns the filter list targeted by the "feed" form field:
+// the channel filters for the default feed or those of a named feed
+func (s *Server) dashboardFilters(w http.ResponseWriter, r *http.Request, channel *channelDomain.Channel) (*[]channelDomain.Filter, bool) {
+	feedName := r.PostFormValue("feed")
+	if feedName == "" {
+		return &channel.Filters, true
+	}
+
+	feed, ok := channel.FindFeed(feedName)
+	if !ok {
+		s.redirectToChannel(w, r, channel.ID, "Feed not found")
+		return nil, false
+	}
+	return &feed.Filters, true
+}
+
+func (s *Server) saveDashboardChannel(w http.ResponseWriter, r *http.Request, channel *channelDomain.Channel) {
+	if err := s.channelService.SaveChannel(channel); err != nil {
+		s.logger.Error("Failed to save channel", "channel_id", channel.ID, "error", err)
+		s.redirectToChannel(w, r, channel.ID, "Failed to save changes")
+		return
+	}
+	s.redirectToChannel(w, r, channel.ID, "")
+}
+
+func (s *Server) redirectToChannel(w http.ResponseWriter, r *http.Request, channelID string, errorMessage string) {
+	target := "/dashboard/channels/" + channelID
+	if errorMessage != "" {
+		target += "?error=" + template.URLQueryEscaper(errorMessage)
+	}
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+func (s *Server) feedLinks(r *http.Request, channel *channelDomain.Channel) []feedLink {
+	baseURL := fmt.Sprintf("%s://%s", getScheme(r), r.Host)
+	links := []feedLink{{Name: "default", URL: fmt.Sprintf("%s/rss/%s", baseURL, channel.ID)}}
+	for _, feed := range channel.Feeds {
+		links = append(links, feedLink{Name: feed.Name, URL: fmt.Sprintf("%s/rss/%s/%s", baseURL, channel.ID, feed.Name)})
+	}
+	return links
+}
+
+func (s *Server) renderDashboard(w http.ResponseWriter, status int, name string, page *dashboardPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.WriteHeader(status)
+	if err := dashboardTemplates[name].Execute(w, page); err != nil {
+		s.logger.Error("Failed to render dashboard", "template", name, "error", err)
+	}
+}
+
+func clearSessionCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    "",
+		Path:     "/dashboard",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	mediaDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/media/domain"
+	appErrors "github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+)
+
+// mediaWriteTimeout replaces the server write timeout for media downloads,
+// which can be far larger than a feed
+const mediaWriteTimeout = 10 * time.Minute
+
+// handleMedia streams a media file of a feed item from Telegram, so that
+// readers can show photos and play videos without a Telegram account
+func (s *Server) handleMedia(w http.ResponseWriter, r *http.Request) {
+	fileID := r.PathValue("fileID")
+	if !s.storedMedia(w, fileID) {
+		return
+	}
+
+	file, err := s.mediaService.Open(r.Context(), fileID)
+	if err != nil {
+		s.writeMediaError(w, err, "file_id", fileID)
+		return
+	}
+	s.writeMedia(w, file)
+}
+
+// handleThumbnail serves an image of a feed item scaled down to one of the
+// configured thumbnail widths
+func (s *Server) handleThumbnail(w http.ResponseWriter, r *http.Request) {
+	fileID := r.PathValue("fileID")
+	width, err := strconv.Atoi(r.PathValue("width"))
+	if err != nil {
+		http.Error(w, "Media not found", http.StatusNotFound)
+		return
+	}
+	if !s.storedMedia(w, fileID) {
+		return
+	}
+
+	file, err := s.mediaService.Thumbnail(r.Context(), fileID, width)
+	if err != nil {
+		s.writeMediaError(w, err, "file_id", fileID, "width", width)
+		return
+	}
+	s.writeMedia(w, file)
+}
+
+// storedMedia reports whether a stored message refers to the file, writing a
+// 404 otherwise. The proxy downloads with the bot's access, so without the
+// check it would hand out any file the bot can reach to anyone knowing its ID.
+func (s *Server) storedMedia(w http.ResponseWriter, fileID string) bool {
+	stored, err := s.searchService.HasMedia(fileID)
+	if err != nil {
+		s.logger.Error("Error looking up media", "file_id", fileID, "error", err)
+		http.Error(w, "Failed to fetch media", http.StatusInternalServerError)
+		return false
+	}
+	if !stored {
+		http.Error(w, "Media not found", http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+func (s *Server) writeMediaError(w http.ResponseWriter, err error, attrs ...any) {
+	switch {
+	case errors.Is(err, appErrors.ErrMediaNotFound):
+		http.Error(w, "Media not found", http.StatusNotFound)
+	case errors.Is(err, appErrors.ErrUnsupportedImage):
+		http.Error(w, "Unsupported image", http.StatusUnsupportedMediaType)
+	case errors.Is(err, appErrors.ErrMediaUnavailable):
+		http.Error(w, "Media unavailable", http.StatusServiceUnavailable)
+	default:
+		s.logger.Error("Error fetching media", append(attrs, "error", err)...)
+		http.Error(w, "Failed to fetch media", http.StatusBadGateway)
+	}
+}
+
+func (s *Server) writeMedia(w http.ResponseWriter, file *mediaDomain.File) {
+	defer file.Body.Close()
+
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(mediaWriteTimeout)); err != nil {
+		s.logger.Debug("Cannot extend media write deadline", "error", err)
+	}
+
+	if file.ContentType != "" {
+		w.Header().Set("Content-Type", file.ContentType)
+	}
+	if file.Size > 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(file.Size, 10))
+	}
+	// The content behind a file ID never changes
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, file.Body)
+}
@@ -0,0 +1,165 @@
+package http
+
+import (
+	"html"
+	"html/template"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// previewPolicy only allows the dashboard's own scripts and styles on the
+// preview page, while media may come from the feed's media links
+const previewPolicy = "default-src 'self'; script-src 'self'; style-src 'self'; img-src 'self' https:; media-src 'self' https:; object-src 'none'; base-uri 'none'; form-action 'self'; frame-ancestors 'none'"
+
+// previewElements lists the elements feed item content may use on the
+// preview page along with the attributes each of them may carry. Anything
+// else is dropped, keeping only its text.
+var previewElements = map[string][]string{
+	"a":          {"href", "title"},
+	"audio":      {"src", "controls", "preload"},
+	"b":          nil,
+	"blockquote": nil,
+	"br":         nil,
+	"code":       nil,
+	"div":        {"class"},
+	"em":         nil,
+	"figcaption": nil,
+	"figure":     nil,
+	"i":          nil,
+	"img":        {"src", "srcset", "sizes", "alt", "title", "width", "height", "loading"},
+	"li":         nil,
+	"ol":         nil,
+	"p":          nil,
+	"pre":        nil,
+	"s":          nil,
+	"span":       {"class"},
+	"strong":     nil,
+	"u":          nil,
+	"ul":         nil,
+	"video":      {"src", "poster", "controls", "preload", "autoplay", "loop", "muted", "playsinline"},
+}
+
+// voidElements have no closing tag
+var voidElements = []string{"br", "img"}
+
+// previewLinkAttributes hold a single link
+var previewLinkAttributes = []string{"href", "src", "poster"}
+
+// previewSchemes are the link schemes the preview keeps, besides relative
+// links
+var previewSchemes = []string{"http", "https", "tg", "tel"}
+
+var (
+	// previewTag matches a tag whose attribute values are all double
+	// quoted, the way the feed service writes them. A "<" that starts
+	// anything else is escaped as text.
+	previewTag = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9]*)((?:\s+[a-zA-Z][a-zA-Z-]*(?:="[^"]*")?)*)\s*/?>`)
+	// previewAttribute matches one attribute of a tag
+	previewAttribute = regexp.MustCompile(`([a-zA-Z][a-zA-Z-]*)(?:="([^"]*)")?`)
+)
+
+// sanitizePreview renders the HTML of a feed item on the dashboard. Only
+// the elements and attributes of previewElements are kept, links must be
+// relative or use one of previewSchemes, and all text is escaped again, so
+// the preview stays safe whatever the feed content holds.
+func sanitizePreview(content string) template.HTML {
+	var out strings.Builder
+	var open []string
+	for content != "" {
+		match := previewTag.FindStringSubmatchIndex(content)
+		if match == nil {
+			out.WriteString(escapePreviewText(content))
+			break
+		}
+		out.WriteString(escapePreviewText(content[:match[0]]))
+
+		closing := match[3] > match[2]
+		name := strings.ToLower(content[match[4]:match[5]])
+		attributes := content[match[6]:match[7]]
+		content = content[match[1]:]
+
+		allowed, ok := previewElements[name]
+		switch {
+		case !ok:
+			continue
+		case closing:
+			// Close the element along with any left open inside it, and
+			// drop closing tags that match nothing
+			if i := slices.Index(open, name); i >= 0 {
+				for j := len(open) - 1; j >= i; j-- {
+					out.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+			}
+		default:
+			out.WriteString("<" + name + sanitizePreviewAttributes(attributes, allowed) + ">")
+			if !slices.Contains(voidElements, name) {
+				open = append(open, name)
+			}
+		}
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		out.WriteString("</" + open[i] + ">")
+	}
+	return template.HTML(out.String())
+}
+
+// sanitizePreviewAttributes keeps the allowed attributes of a tag whose
+// links are safe, quoting their values again
+func sanitizePreviewAttributes(attributes string, allowed []string) string {
+	var out strings.Builder
+	for _, match := range previewAttribute.FindAllStringSubmatch(attributes, -1) {
+		name := strings.ToLower(match[1])
+		value := html.UnescapeString(match[2])
+		if !slices.Contains(allowed, name) {
+			continue
+		}
+		if slices.Contains(previewLinkAttributes, name) && !safePreviewLink(value) {
+			continue
+		}
+		if name == "srcset" && !safePreviewSrcset(value) {
+			continue
+		}
+		out.WriteString(" " + name)
+		if match[2] != "" {
+			out.WriteString(`="` + html.EscapeString(value) + `"`)
+		}
+	}
+	return out.String()
+}
+
+// safePreviewLink reports whether a link is relative or uses one of
+// previewSchemes. Links with whitespace or control characters, which
+// browsers strip to find the scheme, are never safe.
+func safePreviewLink(link string) bool {
+	if strings.IndexFunc(link, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsControl(r)
+	}) >= 0 {
+		return false
+	}
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	return parsed.Scheme == "" || slices.Contains(previewSchemes, strings.ToLower(parsed.Scheme))
+}
+
+// safePreviewSrcset reports whether every image of a srcset is a safe link
+func safePreviewSrcset(srcset string) bool {
+	for _, candidate := range strings.Split(srcset, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 || !safePreviewLink(fields[0]) {
+			return false
+		}
+	}
+	return true
+}
+
+// escapePreviewText escapes text between tags, leaving entities the feed
+// service already escaped as they were
+func escapePreviewText(text string) string {
+	return html.EscapeString(html.UnescapeString(text))
+}
@@ -0,0 +1,72 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	appErrors "github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+)
+
+// apiError is the JSON body returned by the admin API on failure
+type apiError struct {
+	Error apiErrorBody `json:"error"`
+}
+
+type apiErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// errorMapping maps a shared error to an HTTP status and a stable error code
+type errorMapping struct {
+	err    error
+	status int
+	code   string
+}
+
+var apiErrorMappings = []errorMapping{
+	{appErrors.ErrUnauthorized, http.StatusUnauthorized, "unauthorized"},
+	{appErrors.ErrLastOwner, http.StatusConflict, "last_owner"},
+	{appErrors.ErrForbidden, http.StatusForbidden, "forbidden"},
+	{appErrors.ErrChannelNotFound, http.StatusNotFound, "channel_not_found"},
+	{appErrors.ErrCollectionNotFound, http.StatusNotFound, "collection_not_found"},
+	{appErrors.ErrFeedNotFound, http.StatusNotFound, "feed_not_found"},
+	{appErrors.ErrUserNotFound, http.StatusNotFound, "user_not_found"},
+	{appErrors.ErrChannelExists, http.StatusConflict, "channel_exists"},
+	{appErrors.ErrFeedExists, http.StatusConflict, "feed_exists"},
+	{appErrors.ErrUserExists, http.StatusConflict, "user_exists"},
+	{appErrors.ErrInvalidFilter, http.StatusBadRequest, "invalid_filter"},
+	{appErrors.ErrInvalidFeedName, http.StatusBadRequest, "invalid_feed_name"},
+	{appErrors.ErrInvalidRole, http.StatusBadRequest, "invalid_role"},
+	{appErrors.ErrInvalidRequest, http.StatusBadRequest, "invalid_request"},
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if v != nil {
+		json.NewEncoder(w).Encode(v)
+	}
+}
+
+// writeAPIError writes a structured error response. Known errors from
+// shared/errors keep their message; anything else is logged and reported
+// as an internal error without leaking details.
+func (s *Server) writeAPIError(w http.ResponseWriter, err error) {
+	for _, mapping := range apiErrorMappings {
+		if errors.Is(err, mapping.err) {
+			writeJSON(w, mapping.status, apiError{Error: apiErrorBody{
+				Code:    mapping.code,
+				Message: err.Error(),
+			}})
+			return
+		}
+	}
+
+	s.logger.Error("API request failed", "error", err)
+	writeJSON(w, http.StatusInternalServerError, apiError{Error: apiErrorBody{
+		Code:    "internal_error",
+		Message: "internal server error",
+	}})
+}
@@ -0,0 +1,148 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	channelDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/domain"
+	messageDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/domain"
+	searchDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/search/domain"
+	userDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/user/domain"
+	appErrors "github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+	"github.com/samber/lo"
+	"github.com/samber/oops"
+)
+
+const (
+	// searchFeedLimit is the number of best hits in a search results feed
+	searchFeedLimit = 50
+	// defaultSearchLimit and maxSearchLimit bound a page of API search results
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// handleSearchRSSFeed serves the best matches of a query as a feed. The
+// token parameter identifies the user the bot gave the link to, and only
+// the channels that user may see are searched.
+func (s *Server) handleSearchRSSFeed(w http.ResponseWriter, r *http.Request) {
+	query, err := searchDomain.ParseQuery(r.URL.Query().Get("q"))
+	if err != nil {
+		http.Error(w, "A search query with at least one word is required in q", http.StatusBadRequest)
+		return
+	}
+
+	channels, ok := s.feedTokenChannels(w, r, userDomain.PermissionSearch, "/search")
+	if !ok {
+		return
+	}
+	results, err := s.searchService.Search(query, channels, 0, searchFeedLimit)
+	if err != nil {
+		s.writeFeedError(w, err, "query", query.Raw)
+		return
+	}
+
+	messages := lo.Map(results.Hits, func(hit searchDomain.Hit, _ int) *messageDomain.Message { return hit.Message })
+	baseURL := fmt.Sprintf("%s://%s", getScheme(r), r.Host)
+	s.writeRSS(w, s.feedService.GenerateSearchFeed(query.Raw, messages, baseURL))
+}
+
+// feedTokenChannels returns the channels the user named by the token
+// parameter may see, all of them for users who manage every channel. It
+// writes a 403 when the token is invalid or the user lacks the permission;
+// command is the bot command that hands out links with a token.
+func (s *Server) feedTokenChannels(w http.ResponseWriter, r *http.Request, permission userDomain.Permission, command string) ([]*channelDomain.Channel, bool) {
+	userID, err := s.authService.ParseFeedToken(r.URL.Query().Get("token"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("A valid feed token is required in token, copy the feed link from %s in the bot", command), http.StatusForbidden)
+		return nil, false
+	}
+	user, ok := s.userService.Authorize(userID, s.cfg.Get().AllowedUsers)
+	if !ok || !user.Can(permission) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return nil, false
+	}
+
+	var channels []*channelDomain.Channel
+	if user.Can(userDomain.PermissionManageAllChannels) {
+		channels, err = s.channelService.GetAllChannels()
+	} else {
+		channels, err = s.channelService.GetChannelsAccessibleBy(user.ID)
+	}
+	if err != nil {
+		s.writeFeedError(w, err, "user_id", user.ID)
+		return nil, false
+	}
+	return channels, true
+}
+
+// handleAPISearch searches stored messages. The channel, since and until
+// parameters add to the operators in q.
+func (s *Server) handleAPISearch(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	query, err := searchDomain.ParseQuery(params.Get("q"))
+	if err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+	query.Channels = append(query.Channels, params["channel"]...)
+	for name, bound := range map[string]*time.Time{"since": &query.Since, "until": &query.Until} {
+		value := params.Get(name)
+		if value == "" {
+			continue
+		}
+		date, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			s.writeAPIError(w, oops.Wrapf(appErrors.ErrInvalidRequest, "invalid %s, expected YYYY-MM-DD", name))
+			return
+		}
+		if name == "until" {
+			date = date.AddDate(0, 0, 1)
+		}
+		*bound = date
+	}
+
+	offset, err := queryInt(params.Get("offset"), 0)
+	if err != nil || offset < 0 {
+		s.writeAPIError(w, oops.Wrapf(appErrors.ErrInvalidRequest, "invalid offset"))
+		return
+	}
+	limit, err := queryInt(params.Get("limit"), defaultSearchLimit)
+	if err != nil || limit <= 0 {
+		s.writeAPIError(w, oops.Wrapf(appErrors.ErrInvalidRequest, "invalid limit"))
+		return
+	}
+
+	var channels []*channelDomain.Channel
+	if value := params.Get("user_id"); value != "" {
+		// Scope the search to the channels one tenant may see
+		userID, parseErr := strconv.ParseInt(value, 10, 64)
+		if parseErr != nil {
+			s.writeAPIError(w, oops.Wrapf(appErrors.ErrInvalidRequest, "invalid user_id"))
+			return
+		}
+		channels, err = s.channelService.GetChannelsAccessibleBy(userID)
+	} else {
+		channels, err = s.channelService.GetAllChannels()
+	}
+	if err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+
+	results, err := s.searchService.Search(query, channels, offset, min(limit, maxSearchLimit))
+	if err != nil {
+		s.writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+// queryInt parses an optional integer query parameter
+func queryInt(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
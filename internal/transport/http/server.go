@@ -1,29 +1,48 @@
 package http
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"time"
 
+	authService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/auth/service"
+	channelService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/service"
+	feedDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/feed/domain"
 	feedService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/feed/service"
+	mediaService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/media/service"
+	searchService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/search/service"
+	userDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/user/domain"
+	userService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/user/service"
 	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/config"
+	appErrors "github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
 	sloghttp "github.com/samber/slog-http"
 )
 
-// Server handles HTTP requests for RSS feeds
+// Server handles HTTP requests for RSS feeds and the admin API
 type Server struct {
-	cfg         *config.Config
-	feedService *feedService.Service
-	logger      *slog.Logger
+	cfg            *config.Store
+	feedService    *feedService.Service
+	channelService *channelService.Service
+	userService    *userService.Service
+	authService    *authService.Service
+	searchService  *searchService.Service
+	mediaService   *mediaService.Service
+	logger         *slog.Logger
 }
 
 // New creates a new HTTP server
-func New(cfg *config.Config, feedService *feedService.Service) *Server {
+func New(cfg *config.Store, feedService *feedService.Service, channelService *channelService.Service, userService *userService.Service, authService *authService.Service, searchService *searchService.Service, mediaService *mediaService.Service) *Server {
 	return &Server{
-		cfg:         cfg,
-		feedService: feedService,
-		logger:      slog.Default(),
+		cfg:            cfg,
+		feedService:    feedService,
+		channelService: channelService,
+		userService:    userService,
+		authService:    authService,
+		searchService:  searchService,
+		mediaService:   mediaService,
+		logger:         slog.Default(),
 	}
 }
 
@@ -32,12 +51,27 @@ func (s *Server) SetLogger(logger *slog.Logger) {
 	s.logger = logger
 }
 
-// Start starts the HTTP server
-func (s *Server) Start() error {
+// Handler builds the HTTP handler with all routes and middleware
+func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 
 	// RSS feed endpoint
+	mux.HandleFunc("GET /rss/search", s.handleSearchRSSFeed)
 	mux.HandleFunc("GET /rss/{channelID}", s.handleRSSFeed)
+	mux.HandleFunc("GET /rss/{channelID}/{feedName}", s.handleNamedRSSFeed)
+	mux.HandleFunc("GET /rss/collection/{name}", s.handleCollectionRSSFeed)
+	mux.HandleFunc("GET /rss/{channelID}/tag/{tag}", s.handleTagRSSFeed)
+	mux.HandleFunc("GET /rss/tag/{tag}", s.handleCrossTagRSSFeed)
+
+	// Media of feed items, fetched from Telegram
+	mux.HandleFunc("GET /media/{fileID}", s.handleMedia)
+	mux.HandleFunc("GET /media/{fileID}/thumbnail/{width}", s.handleThumbnail)
+
+	// REST admin API
+	s.registerAPIRoutes(mux)
+
+	// Web dashboard
+	s.registerDashboardRoutes(mux)
 
 	// Health check endpoint
 	mux.HandleFunc("GET /health", s.handleHealth)
@@ -45,16 +79,21 @@ func (s *Server) Start() error {
 	// Root endpoint with instructions
 	mux.HandleFunc("GET /", s.handleRoot)
 
-	addr := fmt.Sprintf(":%s", s.cfg.HTTPPort)
-	s.logger.Info("RSS server starting", "addr", addr)
-
 	// Use slog-http middleware with recovery
 	handler := sloghttp.Recovery(mux)
 	handler = sloghttp.New(s.logger)(handler)
 
+	return handler
+}
+
+// Start starts the HTTP server
+func (s *Server) Start() error {
+	addr := fmt.Sprintf(":%s", s.cfg.Get().HTTPPort)
+	s.logger.Info("RSS server starting", "addr", addr)
+
 	server := &http.Server{
 		Addr:         addr,
-		Handler:      handler,
+		Handler:      s.Handler(),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -75,11 +114,90 @@ func (s *Server) handleRSSFeed(w http.ResponseWriter, r *http.Request) {
 
 	feed, err := s.feedService.GenerateFeed(channelID, baseURL)
 	if err != nil {
-		s.logger.Error("Error generating feed", "channel_id", channelID, "error", err)
-		http.Error(w, "Failed to generate feed", http.StatusInternalServerError)
+		s.writeFeedError(w, err, "channel_id", channelID)
+		return
+	}
+
+	s.writeRSS(w, feed)
+}
+
+func (s *Server) handleNamedRSSFeed(w http.ResponseWriter, r *http.Request) {
+	channelID := r.PathValue("channelID")
+	feedName := r.PathValue("feedName")
+	if channelID == "" || feedName == "" {
+		http.Error(w, "Channel ID and feed name are required", http.StatusBadRequest)
+		return
+	}
+
+	baseURL := fmt.Sprintf("%s://%s", getScheme(r), r.Host)
+
+	feed, err := s.feedService.GenerateNamedFeed(channelID, feedName, baseURL)
+	if err != nil {
+		s.writeFeedError(w, err, "channel_id", channelID, "feed_name", feedName)
 		return
 	}
 
+	s.writeRSS(w, feed)
+}
+
+func (s *Server) handleCollectionRSSFeed(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	baseURL := fmt.Sprintf("%s://%s", getScheme(r), r.Host)
+
+	feed, err := s.feedService.GenerateCollectionFeed(name, baseURL)
+	if err != nil {
+		s.writeFeedError(w, err, "collection", name)
+		return
+	}
+
+	s.writeRSS(w, feed)
+}
+
+func (s *Server) handleTagRSSFeed(w http.ResponseWriter, r *http.Request) {
+	channelID := r.PathValue("channelID")
+	tag := r.PathValue("tag")
+	baseURL := fmt.Sprintf("%s://%s", getScheme(r), r.Host)
+
+	feed, err := s.feedService.GenerateTagFeed(channelID, tag, baseURL)
+	if err != nil {
+		s.writeFeedError(w, err, "channel_id", channelID, "tag", tag)
+		return
+	}
+
+	s.writeRSS(w, feed)
+}
+
+// handleCrossTagRSSFeed serves the posts tagged with a tag across channels.
+// Like the search feed, the token parameter identifies the user the bot gave
+// the link to, and only the channels that user may see are included.
+func (s *Server) handleCrossTagRSSFeed(w http.ResponseWriter, r *http.Request) {
+	tag := r.PathValue("tag")
+	channels, ok := s.feedTokenChannels(w, r, userDomain.PermissionViewFeeds, "/tagfeed")
+	if !ok {
+		return
+	}
+	baseURL := fmt.Sprintf("%s://%s", getScheme(r), r.Host)
+
+	feed, err := s.feedService.GenerateCrossTagFeed(tag, channels, baseURL)
+	if err != nil {
+		s.writeFeedError(w, err, "tag", tag)
+		return
+	}
+
+	s.writeRSS(w, feed)
+}
+
+func (s *Server) writeFeedError(w http.ResponseWriter, err error, attrs ...any) {
+	if errors.Is(err, appErrors.ErrChannelNotFound) || errors.Is(err, appErrors.ErrFeedNotFound) || errors.Is(err, appErrors.ErrCollectionNotFound) {
+		http.Error(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
+	s.logger.Error("Error generating feed", append(attrs, "error", err)...)
+	http.Error(w, "Failed to generate feed", http.StatusInternalServerError)
+}
+
+func (s *Server) writeRSS(w http.ResponseWriter, feed *feedDomain.Feed) {
 	// Generate RSS XML
 	rss, err := feed.ToRss()
 	if err != nil {
@@ -118,6 +236,12 @@ func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
         <p>This service provides RSS feeds from Telegram channels.</p>
         <p>To access a feed, use: <code>/rss/{channelID}</code></p>
         <p>Example: <code>/rss/123456789</code></p>
+        <p>Named feeds: <code>/rss/{channelID}/{feedName}</code></p>
+        <p>Collections: <code>/rss/collection/{name}</code></p>
+        <p>Hashtags: <code>/rss/{channelID}/tag/{tag}</code> or across channels <code>/rss/tag/{tag}?token={token}</code>, linked from <code>/tagfeed</code> in the bot</p>
+        <p>Search results: <code>/rss/search?q={query}&amp;token={token}</code>, linked from <code>/search</code> in the bot</p>
+        <p>Admin API: <a href="/api/openapi.yaml">OpenAPI document</a></p>
+        <p>Manage channels in the <a href="/dashboard">dashboard</a>.</p>
     </div>
     <p><a href="/health">Health Check</a></p>
 </body>
@@ -0,0 +1,16 @@
+// Copy feed URLs to the clipboard from buttons with a data-copy attribute
+document.addEventListener("click", function (event) {
+    var button = event.target.closest("button[data-copy]");
+    if (!button) {
+        return;
+    }
+    navigator.clipboard.writeText(button.dataset.copy).then(function () {
+        var label = button.textContent;
+        button.textContent = "Copied";
+        button.classList.add("copied");
+        setTimeout(function () {
+            button.textContent = label;
+            button.classList.remove("copied");
+        }, 1500);
+    });
+});
@@ -0,0 +1,126 @@
+package telegram
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// albumWindow is how long an album is collected after its latest part
+	albumWindow = 2 * time.Second
+	// albumRetention is how long a stored album is remembered, so that a
+	// part arriving late is merged into it rather than stored on its own
+	albumRetention = time.Minute
+)
+
+// albumBuffer collects the posts of a media group. Telegram delivers every
+// photo or video of an album as its own post sharing a media_group_id;
+// once no part has arrived for the window, the parts are stored as one.
+type albumBuffer struct {
+	mu     sync.Mutex
+	window time.Duration
+	albums map[string]*album
+	store  func(parts ...*models.Message)
+}
+
+type album struct {
+	parts   []*models.Message
+	timer   *time.Timer
+	pending bool
+	stored  time.Time
+	// storing serializes the stores of the album, so that a flush of
+	// fewer parts cannot overwrite a later one that merged a late part
+	storing sync.Mutex
+}
+
+func newAlbumBuffer(window time.Duration, store func(parts ...*models.Message)) *albumBuffer {
+	return &albumBuffer{
+		window: window,
+		albums: map[string]*album{},
+		store:  store,
+	}
+}
+
+// add buffers a part of an album and restarts its window
+func (b *albumBuffer) add(msg *models.Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.forgetStored()
+
+	key := fmt.Sprintf("%d:%s", msg.Chat.ID, msg.MediaGroupID)
+	a, ok := b.albums[key]
+	if !ok {
+		a = &album{}
+		a.timer = time.AfterFunc(b.window, func() { b.flush(key) })
+		b.albums[key] = a
+	} else {
+		a.timer.Reset(b.window)
+	}
+
+	// A late part re-stores the whole album under the same message ID
+	a.pending = true
+	if !slices.ContainsFunc(a.parts, func(part *models.Message) bool { return part.ID == msg.ID }) {
+		a.parts = append(a.parts, msg)
+	}
+}
+
+// flush stores an album whose window has passed. The parts are taken only
+// once any earlier store of the album has finished, so every store holds
+// at least the parts of the one before it.
+func (b *albumBuffer) flush(key string) {
+	b.mu.Lock()
+	a, ok := b.albums[key]
+	b.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	a.storing.Lock()
+	defer a.storing.Unlock()
+
+	b.mu.Lock()
+	if !a.pending {
+		b.mu.Unlock()
+		return
+	}
+	a.pending = false
+	a.stored = time.Now()
+	parts := slices.Clone(a.parts)
+	b.mu.Unlock()
+
+	// The first part gives the album its ID, date and link
+	slices.SortFunc(parts, func(x, y *models.Message) int { return x.ID - y.ID })
+	b.store(parts...)
+}
+
+// flushAll stores every album still being collected
+func (b *albumBuffer) flushAll() {
+	b.mu.Lock()
+	var keys []string
+	for key, a := range b.albums {
+		if a.pending {
+			a.timer.Stop()
+			keys = append(keys, key)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, key := range keys {
+		b.flush(key)
+	}
+}
+
+// forgetStored drops albums stored longer than albumRetention ago. The
+// caller holds the lock.
+func (b *albumBuffer) forgetStored() {
+	for key, a := range b.albums {
+		if !a.pending && time.Since(a.stored) > albumRetention {
+			delete(b.albums, key)
+		}
+	}
+}
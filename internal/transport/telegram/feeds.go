@@ -0,0 +1,576 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	channelDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/domain"
+	userDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/user/domain"
+)
+
+// registerFeedCommands registers commands that manage named feeds
+func (h *Handler) registerFeedCommands(b *bot.Bot) {
+	b.RegisterHandler(bot.HandlerTypeMessageText, "addfeed", bot.MatchTypeCommandStartOnly, h.handleAddFeed)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "removefeed", bot.MatchTypeCommandStartOnly, h.handleRemoveFeed)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "listfeeds", bot.MatchTypeCommandStartOnly, h.handleListFeeds)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "addfeedfilter", bot.MatchTypeCommandStartOnly, h.handleAddFeedFilter)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "removefeedfilter", bot.MatchTypeCommandStartOnly, h.handleRemoveFeedFilter)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "setfeedlimit", bot.MatchTypeCommandStartOnly, h.handleSetFeedLimit)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "settitle", bot.MatchTypeCommandStartOnly, h.handleSetTitle)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "setdeleted", bot.MatchTypeCommandStartOnly, h.handleSetDeleted)
+}
+
+func (h *Handler) handleAddFeed(ctx context.Context, b *bot.Bot, update *models.Update) {
+	user, ok := h.authorize(ctx, b, update, userDomain.PermissionManageChannels)
+	if !ok {
+		return
+	}
+
+	parts := strings.Fields(update.Message.Text)
+	if len(parts) < 3 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Usage: /addfeed <channel_id> <feed_name> [title]\nExample: /addfeed 123456789 releases Releases only",
+		})
+		return
+	}
+
+	channelID := parts[1]
+	feedName := channelDomain.NormalizeFeedName(parts[2])
+	if !channelDomain.ValidFeedName(feedName) {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "❌ Invalid feed name. Use lowercase letters, digits, '-' or '_'.",
+		})
+		return
+	}
+
+	channel, err := h.channelService.GetChannel(channelID)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Channel not found: %s", channelID),
+		})
+		return
+	}
+
+	if !h.authorizeChannel(ctx, b, update, user, channel) {
+		return
+	}
+
+	if _, exists := channel.FindFeed(feedName); exists {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Feed %s already exists for channel %s", feedName, channelID),
+		})
+		return
+	}
+
+	channel.Feeds = append(channel.Feeds, channelDomain.FeedDefinition{
+		Name:    feedName,
+		Title:   strings.Join(parts[3:], " "),
+		Filters: []channelDomain.Filter{},
+	})
+
+	if err := h.channelService.SaveChannel(channel); err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Failed to save feed: %v", err),
+		})
+		return
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text: fmt.Sprintf("✅ Feed %s added to channel %s\n%s\n\nAdd filters with /addfeedfilter %s %s <keywords>",
+			feedName, channelID, h.feedLink(channel.ID, feedName), channelID, feedName),
+	})
+}
+
+func (h *Handler) handleRemoveFeed(ctx context.Context, b *bot.Bot, update *models.Update) {
+	user, ok := h.authorize(ctx, b, update, userDomain.PermissionManageChannels)
+	if !ok {
+		return
+	}
+
+	parts := strings.Fields(update.Message.Text)
+	if len(parts) < 3 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Usage: /removefeed <channel_id> <feed_name>",
+		})
+		return
+	}
+
+	channelID, feedName := parts[1], channelDomain.NormalizeFeedName(parts[2])
+
+	channel, err := h.channelService.GetChannel(channelID)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Channel not found: %s", channelID),
+		})
+		return
+	}
+
+	if !h.authorizeChannel(ctx, b, update, user, channel) {
+		return
+	}
+
+	if !channel.RemoveFeed(feedName) {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Feed not found: %s", feedName),
+		})
+		return
+	}
+
+	if err := h.channelService.SaveChannel(channel); err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Failed to remove feed: %v", err),
+		})
+		return
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   fmt.Sprintf("✅ Feed %s removed from channel %s", feedName, channelID),
+	})
+}
+
+func (h *Handler) handleListFeeds(ctx context.Context, b *bot.Bot, update *models.Update) {
+	_, ok := h.authorize(ctx, b, update, userDomain.PermissionManageChannels)
+	if !ok {
+		return
+	}
+
+	parts := strings.Fields(update.Message.Text)
+	if len(parts) < 2 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Usage: /listfeeds <channel_id>",
+		})
+		return
+	}
+
+	channelID := parts[1]
+	channel, err := h.channelService.GetChannel(channelID)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Channel not found: %s", channelID),
+		})
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("📰 Feeds for @%s:\n\n", channel.Username))
+	text.WriteString(fmt.Sprintf("• default (%d filters)\n  %s\n\n", len(channel.Filters), h.feedLink(channel.ID, "")))
+	for _, feed := range channel.Feeds {
+		text.WriteString(fmt.Sprintf("• %s (%d filters)\n", feed.Name, len(feed.Filters)))
+		if feed.Title != "" {
+			text.WriteString(fmt.Sprintf("  Title: %s\n", feed.Title))
+		}
+		if feed.Limit > 0 || feed.MaxAgeDays > 0 {
+			text.WriteString(fmt.Sprintf("  Limit: %d items, %d days\n", feed.Limit, feed.MaxAgeDays))
+		}
+		for i, filter := range feed.Filters {
+			text.WriteString(fmt.Sprintf("  %d. %s: %s\n", i+1, filter.Type, strings.Join(filter.Keywords, ",")))
+		}
+		text.WriteString(fmt.Sprintf("  %s\n\n", h.feedLink(channel.ID, feed.Name)))
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   text.String(),
+	})
+}
+
+func (h *Handler) handleAddFeedFilter(ctx context.Context, b *bot.Bot, update *models.Update) {
+	user, ok := h.authorize(ctx, b, update, userDomain.PermissionManageChannels)
+	if !ok {
+		return
+	}
+
+	parts := strings.Fields(update.Message.Text)
+	if len(parts) < 4 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text: "Usage: /addfeedfilter <channel_id> <feed_name> <keyword1,keyword2,...>\n" +
+				"Prefix the keywords with '-' to exclude them, use media:photo,video to filter by media type,\n" +
+				"tags:news,$TON to filter by hashtags and cashtags\n" +
+				"and -forwards:all or -forwards:@channel to drop forwarded posts.\n" +
+				"Example: /addfeedfilter 123456789 noads -advertisement,promo",
+		})
+		return
+	}
+
+	channelID, feedName := parts[1], channelDomain.NormalizeFeedName(parts[2])
+
+	channel, err := h.channelService.GetChannel(channelID)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Channel not found: %s", channelID),
+		})
+		return
+	}
+
+	if !h.authorizeChannel(ctx, b, update, user, channel) {
+		return
+	}
+
+	feed, ok := channel.FindFeed(feedName)
+	if !ok {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Feed not found: %s", feedName),
+		})
+		return
+	}
+
+	filter, err := parseKeywordFilter(parts[3])
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ %v", err),
+		})
+		return
+	}
+	feed.Filters = append(feed.Filters, filter)
+
+	if err := h.channelService.SaveChannel(channel); err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Failed to save filter: %v", err),
+		})
+		return
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   fmt.Sprintf("✅ Filter added to feed %s\nType: %s\nKeywords: %v", feedName, filter.Type, filter.Keywords),
+	})
+}
+
+func (h *Handler) handleRemoveFeedFilter(ctx context.Context, b *bot.Bot, update *models.Update) {
+	user, ok := h.authorize(ctx, b, update, userDomain.PermissionManageChannels)
+	if !ok {
+		return
+	}
+
+	parts := strings.Fields(update.Message.Text)
+	if len(parts) < 4 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Usage: /removefeedfilter <channel_id> <feed_name> <filter_index>",
+		})
+		return
+	}
+
+	channelID, feedName := parts[1], channelDomain.NormalizeFeedName(parts[2])
+	index, err := strconv.Atoi(parts[3])
+	if err != nil || index < 1 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "❌ Invalid filter index",
+		})
+		return
+	}
+
+	channel, err := h.channelService.GetChannel(channelID)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Channel not found: %s", channelID),
+		})
+		return
+	}
+
+	if !h.authorizeChannel(ctx, b, update, user, channel) {
+		return
+	}
+
+	feed, ok := channel.FindFeed(feedName)
+	if !ok {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Feed not found: %s", feedName),
+		})
+		return
+	}
+
+	if index > len(feed.Filters) {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "❌ Filter index out of range",
+		})
+		return
+	}
+
+	feed.Filters = append(feed.Filters[:index-1], feed.Filters[index:]...)
+
+	if err := h.channelService.SaveChannel(channel); err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Failed to remove filter: %v", err),
+		})
+		return
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   fmt.Sprintf("✅ Filter %d removed from feed %s", index, feedName),
+	})
+}
+
+func (h *Handler) handleSetFeedLimit(ctx context.Context, b *bot.Bot, update *models.Update) {
+	user, ok := h.authorize(ctx, b, update, userDomain.PermissionManageChannels)
+	if !ok {
+		return
+	}
+
+	parts := strings.Fields(update.Message.Text)
+	if len(parts) < 4 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Usage: /setfeedlimit <channel_id> <feed_name> <max_items> [max_age_days]\nUse 0 to remove a limit.",
+		})
+		return
+	}
+
+	channelID, feedName := parts[1], channelDomain.NormalizeFeedName(parts[2])
+	limit, err := strconv.Atoi(parts[3])
+	if err != nil || limit < 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "❌ Invalid item limit",
+		})
+		return
+	}
+
+	maxAgeDays := 0
+	if len(parts) >= 5 {
+		maxAgeDays, err = strconv.Atoi(parts[4])
+		if err != nil || maxAgeDays < 0 {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: update.Message.Chat.ID,
+				Text:   "❌ Invalid max age",
+			})
+			return
+		}
+	}
+
+	channel, err := h.channelService.GetChannel(channelID)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Channel not found: %s", channelID),
+		})
+		return
+	}
+
+	if !h.authorizeChannel(ctx, b, update, user, channel) {
+		return
+	}
+
+	feed, ok := channel.FindFeed(feedName)
+	if !ok {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Feed not found: %s", feedName),
+		})
+		return
+	}
+
+	feed.Limit = limit
+	feed.MaxAgeDays = maxAgeDays
+
+	if err := h.channelService.SaveChannel(channel); err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Failed to save feed: %v", err),
+		})
+		return
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   fmt.Sprintf("✅ Feed %s limited to %d items and %d days", feedName, limit, maxAgeDays),
+	})
+}
+
+func (h *Handler) handleSetTitle(ctx context.Context, b *bot.Bot, update *models.Update) {
+	user, ok := h.authorize(ctx, b, update, userDomain.PermissionManageChannels)
+	if !ok {
+		return
+	}
+
+	parts := strings.Fields(update.Message.Text)
+	if len(parts) < 3 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text: fmt.Sprintf("Usage: /settitle <channel_id> <template>\nPlaceholders: %s\nExample: /settitle 123456789 [{channel}] {title}\nUse - to restore the default titles.",
+				strings.Join(channelDomain.TitlePlaceholders, ", ")),
+		})
+		return
+	}
+
+	channelID := parts[1]
+	template := strings.Join(parts[2:], " ")
+	if template == "-" {
+		template = ""
+	}
+	if err := channelDomain.ValidateTitleTemplate(template); err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ %v", err),
+		})
+		return
+	}
+
+	channel, err := h.channelService.GetChannel(channelID)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Channel not found: %s", channelID),
+		})
+		return
+	}
+
+	if !h.authorizeChannel(ctx, b, update, user, channel) {
+		return
+	}
+
+	channel.TitleTemplate = template
+	if err := h.channelService.SaveChannel(channel); err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Failed to save title template: %v", err),
+		})
+		return
+	}
+
+	text := fmt.Sprintf("✅ Item titles of %s use the template: %s", channel.ID, template)
+	if template == "" {
+		text = fmt.Sprintf("✅ Item titles of %s are derived from the posts again", channel.ID)
+	}
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   text,
+	})
+}
+
+func (h *Handler) handleSetDeleted(ctx context.Context, b *bot.Bot, update *models.Update) {
+	user, ok := h.authorize(ctx, b, update, userDomain.PermissionManageChannels)
+	if !ok {
+		return
+	}
+
+	parts := strings.Fields(update.Message.Text)
+	if len(parts) != 3 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Usage: /setdeleted <channel_id> hide|mark\nHide posts deleted from the channel, or keep them in feeds marked as deleted.",
+		})
+		return
+	}
+
+	channelID := parts[1]
+	policy, err := channelDomain.ParseDeletedPostPolicy(parts[2])
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ %v", err),
+		})
+		return
+	}
+
+	channel, err := h.channelService.GetChannel(channelID)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Channel not found: %s", channelID),
+		})
+		return
+	}
+
+	if !h.authorizeChannel(ctx, b, update, user, channel) {
+		return
+	}
+
+	channel.DeletedPosts = policy
+	if err := h.channelService.SaveChannel(channel); err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Failed to save channel: %v", err),
+		})
+		return
+	}
+
+	text := fmt.Sprintf("✅ Posts deleted from %s are hidden from its feeds", channel.ID)
+	if policy == channelDomain.DeletedPostPolicyMark {
+		text = fmt.Sprintf("✅ Posts deleted from %s stay in its feeds marked as deleted", channel.ID)
+	}
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   text,
+	})
+}
+
+// feedLink builds the public RSS link of a channel feed; an empty feedName
+// refers to the default feed
+func (h *Handler) feedLink(channelID string, feedName string) string {
+	link := fmt.Sprintf("%s/rss/%s", h.cfg.Get().BaseURL(), channelID)
+	if feedName != "" {
+		link += "/" + feedName
+	}
+	return link
+}
+
+// parseKeywordFilter builds a keyword filter from a comma-separated list.
+// A leading '-' turns it into an exclude filter, a "media:" prefix into a
+// filter on media types, such as media:photo,video, and a "tags:" prefix
+// into one on hashtags and cashtags, such as tags:news,$TON.
+// -forwards:all drops forwarded posts and -forwards:@a,@b those of a and b.
+func parseKeywordFilter(arg string) (channelDomain.Filter, error) {
+	filterType := channelDomain.FilterTypeKeywords
+	exclude := strings.HasPrefix(arg, "-")
+	arg = strings.TrimPrefix(arg, "-")
+	if mediaTypes, ok := strings.CutPrefix(arg, "media:"); ok {
+		filterType = channelDomain.FilterTypeMedia
+		arg = mediaTypes
+	}
+	if tags, ok := strings.CutPrefix(arg, "tags:"); ok {
+		filterType = channelDomain.FilterTypeTags
+		arg = tags
+	}
+	if exclude {
+		filterType = map[channelDomain.FilterType]channelDomain.FilterType{
+			channelDomain.FilterTypeKeywords: channelDomain.FilterTypeExcludeKeywords,
+			channelDomain.FilterTypeMedia:    channelDomain.FilterTypeExcludeMedia,
+			channelDomain.FilterTypeTags:     channelDomain.FilterTypeExcludeTags,
+		}[filterType]
+	}
+
+	keywords := strings.Split(arg, ",")
+	if sources, ok := strings.CutPrefix(arg, "forwards:"); ok && exclude {
+		filterType = channelDomain.FilterTypeExcludeForwards
+		keywords = strings.Split(sources, ",")
+		if sources == "all" {
+			keywords = nil
+		}
+	}
+
+	filter := channelDomain.Filter{
+		Type:     filterType,
+		Keywords: keywords,
+		Enabled:  true,
+	}
+	return filter, filter.Validate()
+}
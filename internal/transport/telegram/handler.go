@@ -4,50 +4,83 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
+	authService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/auth/service"
 	channelDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/domain"
 	channelService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/service"
 	feedService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/feed/service"
+	mediaDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/media/domain"
+	mediaService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/media/service"
 	messageDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/domain"
+	searchService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/search/service"
 	userDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/user/domain"
 	userService "github.com/reshetovitsme/rss-telegram-feed/internal/modules/user/service"
 	"github.com/reshetovitsme/rss-telegram-feed/internal/shared/config"
+	appErrors "github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
 )
 
 // Handler handles Telegram bot interactions
 type Handler struct {
-	cfg            *config.Config
+	cfg            *config.Store
 	channelService *channelService.Service
 	feedService    *feedService.Service
 	userService    *userService.Service
+	authService    *authService.Service
+	searchService  *searchService.Service
+	mediaService   *mediaService.Service
+	searches       *searchSessions
+	albums         *albumBuffer
 }
 
 // New creates a new Telegram handler
-func New(cfg *config.Config, channelService *channelService.Service, feedService *feedService.Service, userService *userService.Service) *Handler {
-	return &Handler{
+func New(cfg *config.Store, channelService *channelService.Service, feedService *feedService.Service, userService *userService.Service, authService *authService.Service, searchService *searchService.Service, mediaService *mediaService.Service) *Handler {
+	h := &Handler{
 		cfg:            cfg,
 		channelService: channelService,
 		feedService:    feedService,
 		userService:    userService,
+		authService:    authService,
+		searchService:  searchService,
+		mediaService:   mediaService,
+		searches:       newSearchSessions(),
 	}
+	h.albums = newAlbumBuffer(albumWindow, h.storePost)
+	return h
 }
 
-// RegisterCommands registers bot commands
+// Close stores the albums still being collected
+func (h *Handler) Close() {
+	h.albums.flushAll()
+}
+
+// RegisterCommands registers bot commands. Commands are matched by name
+// rather than by prefix, so /addfeed does not also catch /addfeedfilter.
 func (h *Handler) RegisterCommands(b *bot.Bot) {
-	b.RegisterHandler(bot.HandlerTypeMessageText, "/start", bot.MatchTypeExact, h.handleStart)
-	b.RegisterHandler(bot.HandlerTypeMessageText, "/help", bot.MatchTypeExact, h.handleHelp)
-	b.RegisterHandler(bot.HandlerTypeMessageText, "/addchannel", bot.MatchTypePrefix, h.handleAddChannel)
-	b.RegisterHandler(bot.HandlerTypeMessageText, "/removechannel", bot.MatchTypePrefix, h.handleRemoveChannel)
-	b.RegisterHandler(bot.HandlerTypeMessageText, "/listchannels", bot.MatchTypeExact, h.handleListChannels)
-	b.RegisterHandler(bot.HandlerTypeMessageText, "/addfilter", bot.MatchTypePrefix, h.handleAddFilter)
-	b.RegisterHandler(bot.HandlerTypeMessageText, "/removefilter", bot.MatchTypePrefix, h.handleRemoveFilter)
-	b.RegisterHandler(bot.HandlerTypeMessageText, "/rsslink", bot.MatchTypePrefix, h.handleRSSLink)
-	b.RegisterHandler(bot.HandlerTypeMessageText, "/status", bot.MatchTypeExact, h.handleStatus)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "start", bot.MatchTypeCommandStartOnly, h.handleStart)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "help", bot.MatchTypeCommandStartOnly, h.handleHelp)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "addchannel", bot.MatchTypeCommandStartOnly, h.handleAddChannel)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "removechannel", bot.MatchTypeCommandStartOnly, h.handleRemoveChannel)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "listchannels", bot.MatchTypeCommandStartOnly, h.handleListChannels)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "sharechannel", bot.MatchTypeCommandStartOnly, h.handleShareChannel)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "unsharechannel", bot.MatchTypeCommandStartOnly, h.handleUnshareChannel)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "addfilter", bot.MatchTypeCommandStartOnly, h.handleAddFilter)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "removefilter", bot.MatchTypeCommandStartOnly, h.handleRemoveFilter)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "rsslink", bot.MatchTypeCommandStartOnly, h.handleRSSLink)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "tagfeed", bot.MatchTypeCommandStartOnly, h.handleTagFeed)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "status", bot.MatchTypeCommandStartOnly, h.handleStatus)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "weblogin", bot.MatchTypeCommandStartOnly, h.handleWebLogin)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "claim", bot.MatchTypeCommandStartOnly, h.handleClaim)
+	h.registerFeedCommands(b)
+	h.registerPodcastCommands(b)
+	h.registerRewriteCommands(b)
+	h.registerUserCommands(b)
+	h.registerSearchCommands(b)
 }
 
 // HandleUpdate processes incoming updates
@@ -67,6 +100,18 @@ func (h *Handler) processChannelPost(ctx context.Context, b *bot.Bot, msg *model
 		return
 	}
 
+	// Album parts arrive as separate posts; they are stored together once
+	// the whole album is in
+	if msg.MediaGroupID != "" {
+		h.albums.add(msg)
+		return
+	}
+	h.storePost(msg)
+}
+
+// storePost saves a post, or the parts of an album merged into one message
+func (h *Handler) storePost(parts ...*models.Message) {
+	msg := parts[0]
 	channelID := fmt.Sprintf("%d", msg.Chat.ID)
 
 	// Check if this channel is being monitored
@@ -81,72 +126,249 @@ func (h *Handler) processChannelPost(ctx context.Context, b *bot.Bot, msg *model
 	}
 
 	// Extract message data
-	text := msg.Text
-	if text == "" && msg.Caption != "" {
-		text = msg.Caption
+	message := &messageDomain.Message{
+		ID:           int64(msg.ID),
+		Text:         postText(msg),
+		Date:         time.Unix(int64(msg.Date), 0),
+		Author:       getAuthorName(msg),
+		Link:         fmt.Sprintf("https://t.me/%s/%d", channel.Username, msg.ID),
+		MediaGroupID: msg.MediaGroupID,
+		Forward:      extractForward(msg.ForwardOrigin),
+		Reply:        extractReply(msg, channel.Username),
+		LinkPreview:  linkPreview(msg),
+	}
+	for _, part := range parts {
+		media := extractMedia(part)
+		caption := postText(part)
+		if message.Text == "" {
+			message.Text = caption
+		} else if caption != "" && caption != message.Text {
+			// Captions other than the album's own stay with their media
+			for i := range media {
+				media[i].Caption = caption
+			}
+		}
+		message.Media = append(message.Media, media...)
+		addTags(message, part)
 	}
 
-	media := extractMedia(msg)
-	author := getAuthorName(msg)
-	link := fmt.Sprintf("https://t.me/%s/%d", channel.Username, msg.ID)
-
 	// Process message through channel service
-	if err := h.channelService.ProcessMessage(channel, text, int64(msg.Date), int64(msg.ID), author, media, link); err != nil {
+	if err := h.channelService.ProcessMessage(channel, message); err != nil {
 		slog.Error("Error processing message", "error", err, "channel_id", channelID, "message_id", msg.ID)
 		return
 	}
+	h.mediaService.Enqueue(channelID, archivableFiles(message.Media)...)
+
+	slog.Info("New message from channel", "channel", channel.Username, "channel_id", channelID, "message_id", msg.ID, "parts", len(parts))
+}
 
-	slog.Info("New message from channel", "channel", channel.Username, "channel_id", channelID, "message_id", msg.ID)
+// archivableFiles lists the file IDs of media and their thumbnails the Bot
+// API still lets the bot download
+func archivableFiles(media []messageDomain.Media) []string {
+	var fileIDs []string
+	for _, m := range media {
+		if m.FileID != "" && m.FileSize <= mediaDomain.MaxDownloadSize {
+			fileIDs = append(fileIDs, m.FileID)
+		}
+		if m.Thumbnail != "" && m.Thumbnail != m.FileID {
+			fileIDs = append(fileIDs, m.Thumbnail)
+		}
+	}
+	return fileIDs
 }
 
-func (h *Handler) checkAuthorization(userID int64) bool {
-	return h.userService.IsAuthorized(userID, h.cfg.AllowedUsers)
+// postText returns the text of a post, or its caption for media posts
+func postText(msg *models.Message) string {
+	if msg.Text != "" {
+		return msg.Text
+	}
+	return msg.Caption
+}
+
+// authorize resolves the calling user and checks that their role grants
+// the permission, replying to the user when access is denied
+func (h *Handler) authorize(ctx context.Context, b *bot.Bot, update *models.Update, permission userDomain.Permission) (*userDomain.User, bool) {
+	user, ok := h.userService.Authorize(update.Message.From.ID, h.cfg.Get().AllowedUsers)
+	if !ok {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "❌ Unauthorized",
+		})
+		return nil, false
+	}
+
+	if !user.Can(permission) {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Permission denied for role %s", user.EffectiveRole()),
+		})
+		return nil, false
+	}
+
+	return user, true
+}
+
+// authorizeChannel checks that the user may change the filters and feeds
+// of the channel, replying to the user when access is denied
+func (h *Handler) authorizeChannel(ctx context.Context, b *bot.Bot, update *models.Update, user *userDomain.User, channel *channelDomain.Channel) bool {
+	return h.checkChannelAccess(ctx, b, update, user, channel, user.CanEdit(channel))
+}
+
+// authorizeChannelOwner checks that the user may remove or share the channel,
+// replying to the user when access is denied
+func (h *Handler) authorizeChannelOwner(ctx context.Context, b *bot.Bot, update *models.Update, user *userDomain.User, channel *channelDomain.Channel) bool {
+	return h.checkChannelAccess(ctx, b, update, user, channel, user.CanAdminister(channel))
+}
+
+// checkChannelAccess replies when access is denied. Channels the user cannot
+// see are reported as not found so other tenants' channels are not revealed.
+func (h *Handler) checkChannelAccess(ctx context.Context, b *bot.Bot, update *models.Update, user *userDomain.User, channel *channelDomain.Channel, allowed bool) bool {
+	if allowed {
+		return true
+	}
+
+	text := fmt.Sprintf("❌ Channel not found: %s", channel.ID)
+	if user.CanView(channel) {
+		text = fmt.Sprintf("❌ Only the user who added channel %s or an admin can do this", channel.ID)
+	}
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   text,
+	})
+	return false
+}
+
+// listableChannels returns the channels the user may see. Admins see every
+// channel when all is set; everyone else sees their own and shared channels.
+func (h *Handler) listableChannels(user *userDomain.User, all bool) ([]*channelDomain.Channel, error) {
+	if all && user.Can(userDomain.PermissionManageAllChannels) {
+		return h.channelService.GetAllChannels()
+	}
+	return h.channelService.GetChannelsAccessibleBy(user.ID)
 }
 
 func (h *Handler) handleStart(ctx context.Context, b *bot.Bot, update *models.Update) {
-	userID := update.Message.From.ID
-
-	if !h.checkAuthorization(userID) {
-		// Auto-add first user as admin if no users configured
-		if len(h.cfg.AllowedUsers) == 0 {
-			user := &userDomain.User{
-				ID:       userID,
-				Username: update.Message.From.Username,
-				AddedAt:  time.Now(),
-				IsAdmin:  true,
-			}
-			if err := h.userService.SaveUser(user); err != nil {
-				slog.Error("Failed to save user", "error", err, "user_id", userID)
-			}
-		} else {
-			b.SendMessage(ctx, &bot.SendMessageParams{
-				ChatID: update.Message.Chat.ID,
-				Text:   "❌ You are not authorized to use this bot.",
-			})
-			return
+	user, ok := h.userService.Authorize(update.Message.From.ID, h.cfg.Get().AllowedUsers)
+	if !ok {
+		text := "❌ You are not authorized to use this bot."
+		if h.userService.ClaimPending() {
+			text += "\n\nIf you run this bot, send /claim <code> with the claim code from the server log to become its owner."
 		}
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   text,
+		})
+		return
 	}
 
-	text := `👋 Welcome to RSS Telegram Feed Bot!
+	var text strings.Builder
+	text.WriteString(`👋 Welcome to RSS Telegram Feed Bot!
 
 I help you create RSS feeds from Telegram channels.
 
 Available commands:
 /help - Show this help message
-/addchannel <channel_username> - Add a channel to monitor
+/listchannels - List your own and shared channels
+/rsslink [channel_id] - Get RSS feed links
+/tagfeed <tag> - Get the RSS link of posts tagged #tag in your channels
+`)
+
+	if user.Can(userDomain.PermissionSearch) {
+		text.WriteString(`/search <query> - Search posts; use "quoted phrases", -word, channel:@name, since:YYYY-MM-DD, until:YYYY-MM-DD
+`)
+	}
+
+	if user.Can(userDomain.PermissionManageChannels) {
+		text.WriteString(`/addchannel <channel_username> - Add a channel to monitor
 /removechannel <channel_id> - Remove a channel
-/listchannels - List all monitored channels
+/sharechannel <channel_id> <user_id> - Give another user access to a channel
+/unsharechannel <channel_id> <user_id> - Revoke shared access
 /addfilter <channel_id> <keyword1,keyword2> - Add keyword filter
 /removefilter <channel_id> <filter_index> - Remove a filter
-/rsslink <channel_id> - Get RSS feed link
 /status - Show bot status
+/weblogin - Get a one-time link to the web dashboard
+
+Named feeds (independently filtered views of a channel):
+/addfeed <channel_id> <feed_name> [title] - Add a named feed
+/removefeed <channel_id> <feed_name> - Remove a named feed
+/listfeeds <channel_id> - List feeds of a channel
+/addfeedfilter <channel_id> <feed_name> <keywords|-keywords> - Add filter to a feed
+/removefeedfilter <channel_id> <feed_name> <filter_index> - Remove a feed filter
+/setfeedlimit <channel_id> <feed_name> <max_items> [max_age_days] - Limit feed size
+/settitle <channel_id> <template> - Format the item titles of a channel
+/setdeleted <channel_id> hide|mark - Hide or mark posts deleted from a channel
+
+Podcasts (audio posts as episodes):
+/setpodcast <channel_id> [on|off|<field> <value>] - Show or change podcast settings
+
+Rewrite rules (clean up the text of feed items):
+/addrewrite <channel_id> <type> [arguments] - Add a rewrite rule
+/removerewrite <channel_id> <rule_index> - Remove a rewrite rule
+/listrewrites <channel_id> - List the rewrite rules of a channel
+`)
+	}
+
+	if user.Can(userDomain.PermissionManageAllChannels) {
+		text.WriteString(`
+Add "all" to /listchannels or /rsslink to include channels of every user.
+`)
+	}
+
+	if user.Can(userDomain.PermissionManageUsers) {
+		text.WriteString(`
+User management:
+/listusers - List users and their roles
+/adduser <user_id> [role] [username] - Add a user (default role: viewer)
+/removeuser <user_id> - Remove a user
+/setrole <user_id> <owner|admin|editor|viewer> - Change a user's role
+`)
+	}
+
+	text.WriteString(fmt.Sprintf(`
+Your role: %s
 
 Example:
-/addchannel @example_channel`
+/addchannel @example_channel`, user.EffectiveRole()))
 
 	b.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID: update.Message.Chat.ID,
-		Text:   text,
+		Text:   text.String(),
+	})
+}
+
+// handleClaim makes the caller owner when they send the bootstrap claim code
+func (h *Handler) handleClaim(ctx context.Context, b *bot.Bot, update *models.Update) {
+	// The claim code must not leak into group chats
+	if update.Message.Chat.Type != models.ChatTypePrivate {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "❌ Send /claim to the bot in a private chat",
+		})
+		return
+	}
+
+	parts := strings.Fields(update.Message.Text)
+	if len(parts) < 2 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "❌ Usage: /claim <code>",
+		})
+		return
+	}
+
+	user, err := h.userService.Claim(update.Message.From.ID, update.Message.From.Username, parts[1])
+	if err != nil {
+		slog.Warn("Rejected claim", "user_id", update.Message.From.ID, "error", err)
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "❌ Invalid or already used claim code",
+		})
+		return
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   fmt.Sprintf("✅ You are now the %s of this bot. Send /help to see available commands.", user.EffectiveRole()),
 	})
 }
 
@@ -155,11 +377,8 @@ func (h *Handler) handleHelp(ctx context.Context, b *bot.Bot, update *models.Upd
 }
 
 func (h *Handler) handleAddChannel(ctx context.Context, b *bot.Bot, update *models.Update) {
-	if !h.checkAuthorization(update.Message.From.ID) {
-		b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: update.Message.Chat.ID,
-			Text:   "❌ Unauthorized",
-		})
+	user, ok := h.authorize(ctx, b, update, userDomain.PermissionManageChannels)
+	if !ok {
 		return
 	}
 
@@ -174,10 +393,8 @@ func (h *Handler) handleAddChannel(ctx context.Context, b *bot.Bot, update *mode
 
 	channelUsername := strings.TrimPrefix(parts[1], "@")
 
-	// Try to get channel info from Telegram
-	chat, err := b.GetChat(ctx, &bot.GetChatParams{
-		ChatID: channelUsername,
-	})
+	// Get channel info from Telegram
+	channel, err := h.channelService.ResolveChannel(ctx, channelUsername, user.ID)
 	if err != nil {
 		b.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID: update.Message.Chat.ID,
@@ -186,15 +403,21 @@ func (h *Handler) handleAddChannel(ctx context.Context, b *bot.Bot, update *mode
 		return
 	}
 
-	channel := &channelDomain.Channel{
-		ID:         fmt.Sprintf("%d", chat.ID),
-		Username:   channelUsername,
-		Title:      chat.Title,
-		AddedBy:    update.Message.From.ID,
-		AddedAt:    time.Now(),
-		Filters:    []channelDomain.Filter{},
-		LastUpdate: time.Now(),
-		IsActive:   true,
+	// A stored channel keeps its owner and settings. Only those who may
+	// administer it can add it again, which refreshes its name and resumes
+	// monitoring.
+	if existing, err := h.channelService.GetChannel(channel.ID); err == nil {
+		if !user.CanAdminister(existing) {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: update.Message.Chat.ID,
+				Text:   fmt.Sprintf("❌ %v: %s", appErrors.ErrChannelExists, channel.ID),
+			})
+			return
+		}
+		existing.Username = channel.Username
+		existing.Title = channel.Title
+		existing.IsActive = true
+		channel = existing
 	}
 
 	if err := h.channelService.SaveChannel(channel); err != nil {
@@ -233,41 +456,178 @@ func extractMedia(msg *models.Message) []messageDomain.Media {
 	if msg.Photo != nil && len(msg.Photo) > 0 {
 		photo := msg.Photo[len(msg.Photo)-1]
 		media = append(media, messageDomain.Media{
-			Type:   messageDomain.MediaTypePhoto,
-			FileID: photo.FileID,
+			Type:      messageDomain.MediaTypePhoto,
+			FileID:    photo.FileID,
+			Thumbnail: photo.FileID,
+			FileSize:  int64(photo.FileSize),
 		})
 	}
 
 	if msg.Video != nil {
 		media = append(media, messageDomain.Media{
-			Type:   messageDomain.MediaTypeVideo,
-			FileID: msg.Video.FileID,
+			Type:      messageDomain.MediaTypeVideo,
+			FileID:    msg.Video.FileID,
+			Thumbnail: thumbnailID(msg.Video.Thumbnail),
+			MimeType:  msg.Video.MimeType,
+			FileSize:  msg.Video.FileSize,
+			Duration:  msg.Video.Duration,
 		})
 	}
 
-	if msg.Document != nil {
+	// Animations are also sent as a document of the same file
+	if msg.Animation != nil {
+		media = append(media, messageDomain.Media{
+			Type:      messageDomain.MediaTypeAnimation,
+			FileID:    msg.Animation.FileID,
+			Thumbnail: thumbnailID(msg.Animation.Thumbnail),
+			MimeType:  msg.Animation.MimeType,
+			FileSize:  msg.Animation.FileSize,
+			Duration:  msg.Animation.Duration,
+		})
+	} else if msg.Document != nil {
 		media = append(media, messageDomain.Media{
-			Type:   messageDomain.MediaTypeDocument,
-			FileID: msg.Document.FileID,
+			Type:      messageDomain.MediaTypeDocument,
+			FileID:    msg.Document.FileID,
+			Thumbnail: thumbnailID(msg.Document.Thumbnail),
+			FileName:  msg.Document.FileName,
+			MimeType:  msg.Document.MimeType,
+			FileSize:  msg.Document.FileSize,
 		})
 	}
 
 	if msg.Audio != nil {
 		media = append(media, messageDomain.Media{
-			Type:   messageDomain.MediaTypeAudio,
-			FileID: msg.Audio.FileID,
+			Type:      messageDomain.MediaTypeAudio,
+			FileID:    msg.Audio.FileID,
+			Thumbnail: thumbnailID(msg.Audio.Thumbnail),
+			FileName:  msg.Audio.FileName,
+			Title:     audioTitle(msg.Audio),
+			MimeType:  msg.Audio.MimeType,
+			FileSize:  msg.Audio.FileSize,
+			Duration:  msg.Audio.Duration,
+		})
+	}
+
+	if msg.Voice != nil {
+		media = append(media, messageDomain.Media{
+			Type:     messageDomain.MediaTypeVoice,
+			FileID:   msg.Voice.FileID,
+			MimeType: msg.Voice.MimeType,
+			FileSize: msg.Voice.FileSize,
+			Duration: msg.Voice.Duration,
+		})
+	}
+
+	if msg.VideoNote != nil {
+		media = append(media, messageDomain.Media{
+			Type:      messageDomain.MediaTypeVideoNote,
+			FileID:    msg.VideoNote.FileID,
+			Thumbnail: thumbnailID(msg.VideoNote.Thumbnail),
+			MimeType:  "video/mp4",
+			FileSize:  int64(msg.VideoNote.FileSize),
+			Duration:  msg.VideoNote.Duration,
+		})
+	}
+
+	if msg.Sticker != nil {
+		media = append(media, messageDomain.Media{
+			Type:     messageDomain.MediaTypeSticker,
+			FileID:   msg.Sticker.FileID,
+			MimeType: stickerMimeType(msg.Sticker),
+			FileSize: int64(msg.Sticker.FileSize),
+			Emoji:    msg.Sticker.Emoji,
+		})
+	}
+
+	if msg.Poll != nil {
+		poll := &messageDomain.Poll{
+			Question: msg.Poll.Question,
+			Quiz:     msg.Poll.Type == "quiz",
+			Closed:   msg.Poll.IsClosed,
+			Voters:   msg.Poll.TotalVoterCount,
+		}
+		for _, option := range msg.Poll.Options {
+			poll.Options = append(poll.Options, messageDomain.PollOption{Text: option.Text, Voters: option.VoterCount})
+		}
+		media = append(media, messageDomain.Media{Type: messageDomain.MediaTypePoll, Poll: poll})
+	}
+
+	// Venues are also sent as a location of the same place
+	if msg.Venue != nil {
+		media = append(media, messageDomain.Media{
+			Type: messageDomain.MediaTypeVenue,
+			Venue: &messageDomain.Venue{
+				Title:    msg.Venue.Title,
+				Address:  msg.Venue.Address,
+				Location: messageDomain.Location{Latitude: msg.Venue.Location.Latitude, Longitude: msg.Venue.Location.Longitude},
+			},
+		})
+	} else if msg.Location != nil {
+		media = append(media, messageDomain.Media{
+			Type:     messageDomain.MediaTypeLocation,
+			Location: &messageDomain.Location{Latitude: msg.Location.Latitude, Longitude: msg.Location.Longitude},
+		})
+	}
+
+	if msg.Contact != nil {
+		media = append(media, messageDomain.Media{
+			Type: messageDomain.MediaTypeContact,
+			Contact: &messageDomain.Contact{
+				FirstName:   msg.Contact.FirstName,
+				LastName:    msg.Contact.LastName,
+				PhoneNumber: msg.Contact.PhoneNumber,
+			},
+		})
+	}
+
+	if msg.Dice != nil {
+		media = append(media, messageDomain.Media{
+			Type: messageDomain.MediaTypeDice,
+			Dice: &messageDomain.Dice{Emoji: msg.Dice.Emoji, Value: msg.Dice.Value},
+		})
+	}
+
+	// The content of paid media is only visible to buyers
+	if msg.PaidMedia != nil {
+		media = append(media, messageDomain.Media{
+			Type:  messageDomain.MediaTypePaidMedia,
+			Stars: msg.PaidMedia.StarCount,
 		})
 	}
 
 	return media
 }
 
+// thumbnailID returns the file ID of the JPEG preview Telegram made of a file
+func thumbnailID(thumbnail *models.PhotoSize) string {
+	if thumbnail == nil {
+		return ""
+	}
+	return thumbnail.FileID
+}
+
+// audioTitle names an audio track as "Performer - Title"
+func audioTitle(audio *models.Audio) string {
+	if audio.Performer != "" && audio.Title != "" {
+		return audio.Performer + " - " + audio.Title
+	}
+	return audio.Title
+}
+
+// stickerMimeType returns the format of a sticker file
+func stickerMimeType(sticker *models.Sticker) string {
+	switch {
+	case sticker.IsAnimated:
+		return "application/x-tgsticker"
+	case sticker.IsVideo:
+		return "video/webm"
+	}
+	return "image/webp"
+}
+
 func (h *Handler) handleRemoveChannel(ctx context.Context, b *bot.Bot, update *models.Update) {
-	if !h.checkAuthorization(update.Message.From.ID) {
-		b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: update.Message.Chat.ID,
-			Text:   "❌ Unauthorized",
-		})
+	user, ok := h.authorize(ctx, b, update, userDomain.PermissionManageChannels)
+	if !ok {
 		return
 	}
 
@@ -281,6 +641,19 @@ func (h *Handler) handleRemoveChannel(ctx context.Context, b *bot.Bot, update *m
 	}
 
 	channelID := parts[1]
+	channel, err := h.channelService.GetChannel(channelID)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Channel not found: %s", channelID),
+		})
+		return
+	}
+
+	if !h.authorizeChannelOwner(ctx, b, update, user, channel) {
+		return
+	}
+
 	if err := h.channelService.DeleteChannel(channelID); err != nil {
 		b.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID: update.Message.Chat.ID,
@@ -296,15 +669,15 @@ func (h *Handler) handleRemoveChannel(ctx context.Context, b *bot.Bot, update *m
 }
 
 func (h *Handler) handleListChannels(ctx context.Context, b *bot.Bot, update *models.Update) {
-	if !h.checkAuthorization(update.Message.From.ID) {
-		b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: update.Message.Chat.ID,
-			Text:   "❌ Unauthorized",
-		})
+	user, ok := h.authorize(ctx, b, update, userDomain.PermissionViewFeeds)
+	if !ok {
 		return
 	}
 
-	channels, err := h.channelService.GetAllChannels()
+	parts := strings.Fields(update.Message.Text)
+	all := len(parts) > 1 && parts[1] == "all"
+
+	channels, err := h.listableChannels(user, all)
 	if err != nil {
 		b.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID: update.Message.Chat.ID,
@@ -328,8 +701,15 @@ func (h *Handler) handleListChannels(ctx context.Context, b *bot.Bot, update *mo
 		if !ch.IsActive {
 			status = "⏸️"
 		}
-		text.WriteString(fmt.Sprintf("%s %d. @%s\n   ID: %s\n   Filters: %d\n\n",
-			status, i+1, ch.Username, ch.ID, len(ch.Filters)))
+		text.WriteString(fmt.Sprintf("%s %d. @%s\n   ID: %s\n   Filters: %d\n   Feeds: %d\n",
+			status, i+1, ch.Username, ch.ID, len(ch.Filters), len(ch.Feeds)+1))
+		switch {
+		case ch.AddedBy != user.ID:
+			text.WriteString(fmt.Sprintf("   Owner: %d\n", ch.AddedBy))
+		case len(ch.SharedWith) > 0:
+			text.WriteString(fmt.Sprintf("   Shared with: %s\n", formatUserIDs(ch.SharedWith)))
+		}
+		text.WriteString("\n")
 	}
 
 	b.SendMessage(ctx, &bot.SendMessageParams{
@@ -339,11 +719,8 @@ func (h *Handler) handleListChannels(ctx context.Context, b *bot.Bot, update *mo
 }
 
 func (h *Handler) handleAddFilter(ctx context.Context, b *bot.Bot, update *models.Update) {
-	if !h.checkAuthorization(update.Message.From.ID) {
-		b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: update.Message.Chat.ID,
-			Text:   "❌ Unauthorized",
-		})
+	user, ok := h.authorize(ctx, b, update, userDomain.PermissionManageChannels)
+	if !ok {
 		return
 	}
 
@@ -351,13 +728,12 @@ func (h *Handler) handleAddFilter(ctx context.Context, b *bot.Bot, update *model
 	if len(parts) < 3 {
 		b.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID: update.Message.Chat.ID,
-			Text:   "Usage: /addfilter <channel_id> <keyword1,keyword2,...>\nExample: /addfilter 123456789 tech,programming",
+			Text:   "Usage: /addfilter <channel_id> <keyword1,keyword2,...>\nPrefix the keywords with '-' to exclude them, use media:photo,video to filter by media type,\ntags:news,$TON to filter by hashtags and cashtags\nand -forwards:all or -forwards:@channel to drop forwarded posts.\nExample: /addfilter 123456789 tech,programming",
 		})
 		return
 	}
 
 	channelID := parts[1]
-	keywords := strings.Split(parts[2], ",")
 
 	channel, err := h.channelService.GetChannel(channelID)
 	if err != nil {
@@ -368,12 +744,18 @@ func (h *Handler) handleAddFilter(ctx context.Context, b *bot.Bot, update *model
 		return
 	}
 
-	filter := channelDomain.Filter{
-		Type:     channelDomain.FilterTypeKeywords,
-		Keywords: keywords,
-		Enabled:  true,
+	if !h.authorizeChannel(ctx, b, update, user, channel) {
+		return
 	}
 
+	filter, err := parseKeywordFilter(parts[2])
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ %v", err),
+		})
+		return
+	}
 	channel.Filters = append(channel.Filters, filter)
 
 	if err := h.channelService.SaveChannel(channel); err != nil {
@@ -386,16 +768,13 @@ func (h *Handler) handleAddFilter(ctx context.Context, b *bot.Bot, update *model
 
 	b.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID: update.Message.Chat.ID,
-		Text:   fmt.Sprintf("✅ Filter added to channel %s\nKeywords: %v", channelID, keywords),
+		Text:   fmt.Sprintf("✅ Filter added to channel %s\nType: %s\nKeywords: %v", channelID, filter.Type, filter.Keywords),
 	})
 }
 
 func (h *Handler) handleRemoveFilter(ctx context.Context, b *bot.Bot, update *models.Update) {
-	if !h.checkAuthorization(update.Message.From.ID) {
-		b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: update.Message.Chat.ID,
-			Text:   "❌ Unauthorized",
-		})
+	user, ok := h.authorize(ctx, b, update, userDomain.PermissionManageChannels)
+	if !ok {
 		return
 	}
 
@@ -427,6 +806,10 @@ func (h *Handler) handleRemoveFilter(ctx context.Context, b *bot.Bot, update *mo
 		return
 	}
 
+	if !h.authorizeChannel(ctx, b, update, user, channel) {
+		return
+	}
+
 	if index > len(channel.Filters) {
 		b.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID: update.Message.Chat.ID,
@@ -452,11 +835,8 @@ func (h *Handler) handleRemoveFilter(ctx context.Context, b *bot.Bot, update *mo
 }
 
 func (h *Handler) handleRSSLink(ctx context.Context, b *bot.Bot, update *models.Update) {
-	if !h.checkAuthorization(update.Message.From.ID) {
-		b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: update.Message.Chat.ID,
-			Text:   "❌ Unauthorized",
-		})
+	user, ok := h.authorize(ctx, b, update, userDomain.PermissionViewFeeds)
+	if !ok {
 		return
 	}
 
@@ -466,9 +846,9 @@ func (h *Handler) handleRSSLink(ctx context.Context, b *bot.Bot, update *models.
 		channelID = parts[1]
 	}
 
-	if channelID == "" {
-		// List all RSS links
-		channels, err := h.channelService.GetAllChannels()
+	if channelID == "" || channelID == "all" {
+		// List RSS links of every channel the user can see
+		channels, err := h.listableChannels(user, channelID == "all")
 		if err != nil {
 			b.SendMessage(ctx, &bot.SendMessageParams{
 				ChatID: update.Message.Chat.ID,
@@ -480,8 +860,23 @@ func (h *Handler) handleRSSLink(ctx context.Context, b *bot.Bot, update *models.
 		var text strings.Builder
 		text.WriteString("🔗 RSS Feed Links:\n\n")
 		for _, ch := range channels {
-			link := fmt.Sprintf("http://localhost:%s/rss/%s", h.cfg.HTTPPort, ch.ID)
-			text.WriteString(fmt.Sprintf("@%s:\n%s\n\n", ch.Username, link))
+			text.WriteString(fmt.Sprintf("@%s:\n%s\n", ch.Username, h.feedLink(ch.ID, "")))
+			for _, feed := range ch.Feeds {
+				text.WriteString(fmt.Sprintf("%s\n", h.feedLink(ch.ID, feed.Name)))
+			}
+			text.WriteString("\n")
+		}
+
+		collections, err := h.channelService.GetAllCollections()
+		if err != nil {
+			slog.Error("Failed to get collections", "error", err)
+		}
+		for _, collection := range collections {
+			visible := collection.AccessibleBy(user.ID) || (channelID == "all" && user.CanView(collection))
+			if !visible {
+				continue
+			}
+			text.WriteString(fmt.Sprintf("Collection %s:\n%s/rss/collection/%s\n\n", collection.Name, h.cfg.Get().BaseURL(), collection.Name))
 		}
 
 		b.SendMessage(ctx, &bot.SendMessageParams{
@@ -493,7 +888,7 @@ func (h *Handler) handleRSSLink(ctx context.Context, b *bot.Bot, update *models.
 
 	// Get specific channel RSS link
 	channel, err := h.channelService.GetChannel(channelID)
-	if err != nil {
+	if err != nil || !user.CanView(channel) {
 		b.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID: update.Message.Chat.ID,
 			Text:   fmt.Sprintf("❌ Channel not found: %s", channelID),
@@ -501,22 +896,50 @@ func (h *Handler) handleRSSLink(ctx context.Context, b *bot.Bot, update *models.
 		return
 	}
 
-	link := fmt.Sprintf("http://localhost:%s/rss/%s", h.cfg.HTTPPort, channel.ID)
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("🔗 RSS Feed for @%s:\n%s", channel.Username, h.feedLink(channel.ID, "")))
+	for _, feed := range channel.Feeds {
+		text.WriteString(fmt.Sprintf("\n%s: %s", feed.Name, h.feedLink(channel.ID, feed.Name)))
+	}
+
 	b.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID: update.Message.Chat.ID,
-		Text:   fmt.Sprintf("🔗 RSS Feed for @%s:\n%s", channel.Username, link),
+		Text:   text.String(),
 	})
 }
 
-func (h *Handler) handleStatus(ctx context.Context, b *bot.Bot, update *models.Update) {
-	if !h.checkAuthorization(update.Message.From.ID) {
+// handleTagFeed replies with the link of the feed of posts tagged with a
+// hashtag or cashtag across the channels the user may see
+func (h *Handler) handleTagFeed(ctx context.Context, b *bot.Bot, update *models.Update) {
+	user, ok := h.authorize(ctx, b, update, userDomain.PermissionViewFeeds)
+	if !ok {
+		return
+	}
+
+	parts := strings.Fields(update.Message.Text)
+	if len(parts) < 2 || messageDomain.NormalizeTag(parts[1]) == "" {
 		b.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID: update.Message.Chat.ID,
-			Text:   "❌ Unauthorized",
+			Text:   "Usage: /tagfeed <tag>\nExample: /tagfeed #news or /tagfeed $TON",
 		})
 		return
 	}
 
+	tag := strings.TrimPrefix(parts[1], "#")
+	// The token scopes the feed to the channels this user may see
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text: fmt.Sprintf("🔗 RSS Feed for %s:\n%s/rss/tag/%s?token=%s",
+			parts[1], h.cfg.Get().BaseURL(), url.PathEscape(tag), url.QueryEscape(h.authService.FeedToken(user.ID))),
+	})
+}
+
+func (h *Handler) handleStatus(ctx context.Context, b *bot.Bot, update *models.Update) {
+	_, ok := h.authorize(ctx, b, update, userDomain.PermissionViewStatus)
+	if !ok {
+		return
+	}
+
 	channels, err := h.channelService.GetAllChannels()
 	if err != nil {
 		b.SendMessage(ctx, &bot.SendMessageParams{
@@ -533,16 +956,49 @@ func (h *Handler) handleStatus(ctx context.Context, b *bot.Bot, update *models.U
 		}
 	}
 
+	cfg := h.cfg.Get()
 	text := fmt.Sprintf(`📊 Bot Status:
 
 Channels: %d (Active: %d)
 Update Interval: %d seconds
 HTTP Port: %s
 Storage: %s`,
-		len(channels), activeCount, h.cfg.UpdateInterval, h.cfg.HTTPPort, h.cfg.StoragePath)
+		len(channels), activeCount, cfg.UpdateInterval, cfg.HTTPPort, cfg.StoragePath)
 
 	b.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID: update.Message.Chat.ID,
 		Text:   text,
 	})
 }
+
+func (h *Handler) handleWebLogin(ctx context.Context, b *bot.Bot, update *models.Update) {
+	_, ok := h.authorize(ctx, b, update, userDomain.PermissionUseDashboard)
+	if !ok {
+		return
+	}
+
+	// Login links must not leak into group chats
+	if update.Message.Chat.Type != models.ChatTypePrivate {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "❌ Use /weblogin in a private chat with the bot.",
+		})
+		return
+	}
+
+	token, err := h.authService.IssueLoginToken(update.Message.From.ID)
+	if err != nil {
+		slog.Error("Failed to issue login token", "error", err, "user_id", update.Message.From.ID)
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "❌ Failed to create login link",
+		})
+		return
+	}
+
+	link := fmt.Sprintf("%s/dashboard/login?token=%s", h.cfg.Get().BaseURL(), token.Token)
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   fmt.Sprintf("🔐 Dashboard login link (valid for 10 minutes, single use):\n%s", link),
+	})
+}
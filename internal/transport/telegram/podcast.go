@@ -0,0 +1,165 @@
+package telegram
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	channelDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/domain"
+	userDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/user/domain"
+	"github.com/samber/oops"
+)
+
+const setPodcastUsage = `Usage:
+/setpodcast <channel_id> - Show the podcast settings
+/setpodcast <channel_id> on|off - Publish the channel feeds as a podcast
+/setpodcast <channel_id> <field> <value> - Set author, image, category, explicit, language or email; "-" clears a field
+Example: /setpodcast 123456789 category Technology > Tech News`
+
+// registerPodcastCommands registers commands that manage podcast feeds
+func (h *Handler) registerPodcastCommands(b *bot.Bot) {
+	b.RegisterHandler(bot.HandlerTypeMessageText, "setpodcast", bot.MatchTypeCommandStartOnly, h.handleSetPodcast)
+}
+
+func (h *Handler) handleSetPodcast(ctx context.Context, b *bot.Bot, update *models.Update) {
+	user, ok := h.authorize(ctx, b, update, userDomain.PermissionManageChannels)
+	if !ok {
+		return
+	}
+
+	parts := strings.Fields(update.Message.Text)
+	if len(parts) < 2 || len(parts) == 3 && !isToggle(parts[2]) {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   setPodcastUsage,
+		})
+		return
+	}
+
+	channelID := parts[1]
+	channel, err := h.channelService.GetChannel(channelID)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Channel not found: %s", channelID),
+		})
+		return
+	}
+
+	if !h.authorizeChannel(ctx, b, update, user, channel) {
+		return
+	}
+
+	if len(parts) == 2 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   describePodcast(channel),
+		})
+		return
+	}
+
+	podcast := channelDomain.Podcast{}
+	if channel.Podcast != nil {
+		podcast = *channel.Podcast
+	}
+
+	if len(parts) == 3 {
+		podcast.Enabled = strings.EqualFold(parts[2], "on")
+	} else if err := setPodcastField(&podcast, strings.ToLower(parts[2]), strings.Join(parts[3:], " ")); err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ %v", err),
+		})
+		return
+	}
+
+	if err := podcast.Validate(); err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ %v", err),
+		})
+		return
+	}
+
+	channel.Podcast = &podcast
+	if err := h.channelService.SaveChannel(channel); err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Failed to save podcast settings: %v", err),
+		})
+		return
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   "✅ Podcast settings saved\n\n" + describePodcast(channel),
+	})
+}
+
+func isToggle(arg string) bool {
+	return strings.EqualFold(arg, "on") || strings.EqualFold(arg, "off")
+}
+
+// setPodcastField sets one podcast field from its bot argument; "-" clears it
+func setPodcastField(podcast *channelDomain.Podcast, field string, value string) error {
+	if value == "-" {
+		value = ""
+	}
+
+	switch field {
+	case "author":
+		podcast.Author = value
+	case "image":
+		podcast.Image = value
+	case "category":
+		podcast.Category = value
+	case "language":
+		podcast.Language = value
+	case "email":
+		podcast.Email = value
+	case "explicit":
+		switch strings.ToLower(value) {
+		case "yes", "true", "on":
+			podcast.Explicit = true
+		case "no", "false", "off", "":
+			podcast.Explicit = false
+		default:
+			return oops.Errorf("explicit must be yes or no")
+		}
+	default:
+		return oops.Errorf("unknown podcast field %q, expected author, image, category, explicit, language or email", field)
+	}
+	return nil
+}
+
+// describePodcast lists the podcast settings of a channel
+func describePodcast(channel *channelDomain.Channel) string {
+	if channel.Podcast == nil {
+		return fmt.Sprintf("🎙 Podcast mode is off for %s.\n\n%s", channel.ID, setPodcastUsage)
+	}
+
+	podcast := channel.Podcast
+	state := "off"
+	if podcast.Enabled {
+		state = "on"
+	}
+	return fmt.Sprintf(`🎙 Podcast mode is %s for %s
+
+Author: %s
+Image: %s
+Category: %s
+Explicit: %s
+Language: %s
+Email: %s`,
+		state, channel.ID,
+		cmp.Or(podcast.Author, "-"),
+		cmp.Or(podcast.Image, "-"),
+		cmp.Or(podcast.Category, "-"),
+		strconv.FormatBool(podcast.Explicit),
+		cmp.Or(podcast.Language, "-"),
+		cmp.Or(podcast.Email, "-"))
+}
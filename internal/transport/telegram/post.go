@@ -0,0 +1,156 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/go-telegram/bot/models"
+	messageDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/message/domain"
+)
+
+// replyExcerptLength is how many runes of a replied-to message are kept
+const replyExcerptLength = 200
+
+// extractForward returns where a forwarded post comes from, or nil for
+// posts that were not forwarded
+func extractForward(origin *models.MessageOrigin) *messageDomain.ForwardOrigin {
+	if origin == nil {
+		return nil
+	}
+
+	switch origin.Type {
+	case models.MessageOriginTypeUser:
+		user := origin.MessageOriginUser.SenderUser
+		return &messageDomain.ForwardOrigin{
+			Type:     messageDomain.OriginTypeUser,
+			Name:     strings.TrimSpace(user.FirstName + " " + user.LastName),
+			Username: user.Username,
+			Date:     time.Unix(int64(origin.MessageOriginUser.Date), 0),
+		}
+	case models.MessageOriginTypeHiddenUser:
+		return &messageDomain.ForwardOrigin{
+			Type: messageDomain.OriginTypeHiddenUser,
+			Name: origin.MessageOriginHiddenUser.SenderUserName,
+			Date: time.Unix(int64(origin.MessageOriginHiddenUser.Date), 0),
+		}
+	case models.MessageOriginTypeChat:
+		chat := origin.MessageOriginChat.SenderChat
+		return &messageDomain.ForwardOrigin{
+			Type:     messageDomain.OriginTypeChat,
+			Name:     chat.Title,
+			Username: chat.Username,
+			Date:     time.Unix(int64(origin.MessageOriginChat.Date), 0),
+		}
+	case models.MessageOriginTypeChannel:
+		chat := origin.MessageOriginChannel.Chat
+		return &messageDomain.ForwardOrigin{
+			Type:      messageDomain.OriginTypeChannel,
+			Name:      chat.Title,
+			Username:  chat.Username,
+			MessageID: int64(origin.MessageOriginChannel.MessageID),
+			Date:      time.Unix(int64(origin.MessageOriginChannel.Date), 0),
+		}
+	}
+	return nil
+}
+
+// extractReply returns the message a post replies to, in the same channel
+// or in another chat, or nil when the post is not a reply
+func extractReply(msg *models.Message, channelUsername string) *messageDomain.Reply {
+	var reply *messageDomain.Reply
+	switch {
+	case msg.ReplyToMessage != nil:
+		target := msg.ReplyToMessage
+		reply = &messageDomain.Reply{
+			MessageID: int64(target.ID),
+			Text:      excerpt(postText(target), replyExcerptLength),
+			Link:      fmt.Sprintf("https://t.me/%s/%d", channelUsername, target.ID),
+		}
+	case msg.ExternalReply != nil:
+		target := msg.ExternalReply
+		reply = &messageDomain.Reply{MessageID: int64(target.MessageID)}
+		if origin := extractForward(&target.Origin); origin != nil {
+			reply.Source = origin.Name
+			reply.Link = origin.Link()
+		}
+		if target.Chat != nil && target.Chat.Username != "" && target.MessageID != 0 {
+			reply.Link = fmt.Sprintf("https://t.me/%s/%d", target.Chat.Username, target.MessageID)
+		}
+	default:
+		return nil
+	}
+
+	if msg.Quote != nil {
+		reply.Quote = msg.Quote.Text
+	}
+	return reply
+}
+
+// linkPreview returns the URL Telegram previews under a text post: the one
+// chosen by the author, or else the first link of the text. Links with a
+// scheme other than http(s) or tg are dropped.
+func linkPreview(msg *models.Message) string {
+	if options := msg.LinkPreviewOptions; options != nil {
+		if options.IsDisabled != nil && *options.IsDisabled {
+			return ""
+		}
+		if options.URL != nil && messageDomain.SafeLink(*options.URL) {
+			return *options.URL
+		}
+	}
+
+	for _, entity := range msg.Entities {
+		var link string
+		switch entity.Type {
+		case models.MessageEntityTypeURL:
+			link = entityText(msg.Text, entity)
+			if !strings.Contains(link, "://") {
+				link = "https://" + link
+			}
+		case models.MessageEntityTypeTextLink:
+			link = entity.URL
+		default:
+			continue
+		}
+		if messageDomain.SafeLink(link) {
+			return link
+		}
+	}
+	return ""
+}
+
+// addTags records the hashtags and cashtags Telegram marked in a post or
+// in the caption of its media
+func addTags(message *messageDomain.Message, msg *models.Message) {
+	text, entities := msg.Text, msg.Entities
+	if text == "" {
+		text, entities = msg.Caption, msg.CaptionEntities
+	}
+	for _, entity := range entities {
+		if entity.Type == models.MessageEntityTypeHashtag || entity.Type == models.MessageEntityTypeCashtag {
+			message.AddTag(entityText(text, entity))
+		}
+	}
+}
+
+// entityText returns the part of text an entity covers. Entity offsets
+// count UTF-16 code units, not bytes or runes.
+func entityText(text string, entity models.MessageEntity) string {
+	units := utf16.Encode([]rune(text))
+	end := entity.Offset + entity.Length
+	if entity.Offset < 0 || entity.Length < 0 || end > len(units) {
+		return ""
+	}
+	return string(utf16.Decode(units[entity.Offset:end]))
+}
+
+// excerpt shortens text to at most length runes
+func excerpt(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:length])) + "…"
+}
@@ -0,0 +1,209 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	channelDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/channel/domain"
+	userDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/user/domain"
+)
+
+const addRewriteUsage = `Usage: /addrewrite <channel_id> <type> [arguments]
+replace <pattern> => <replacement> - Replace matches of a regular expression, $1 refers to a group
+strip_signature [pattern] - Strip trailing lines matching the pattern, by default those mentioning the channel
+strip_utm - Remove utm_* tracking parameters from links
+expand_links - Turn t.me shortlinks into full links
+Example: /addrewrite 123456789 strip_signature (?i)subscribe|boost`
+
+// registerRewriteCommands registers commands that manage the rewrite rules
+// applied to the feed items of a channel
+func (h *Handler) registerRewriteCommands(b *bot.Bot) {
+	b.RegisterHandler(bot.HandlerTypeMessageText, "addrewrite", bot.MatchTypeCommandStartOnly, h.handleAddRewrite)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "removerewrite", bot.MatchTypeCommandStartOnly, h.handleRemoveRewrite)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "listrewrites", bot.MatchTypeCommandStartOnly, h.handleListRewrites)
+}
+
+func (h *Handler) handleAddRewrite(ctx context.Context, b *bot.Bot, update *models.Update) {
+	user, ok := h.authorize(ctx, b, update, userDomain.PermissionManageChannels)
+	if !ok {
+		return
+	}
+
+	parts := strings.Fields(update.Message.Text)
+	if len(parts) < 3 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   addRewriteUsage,
+		})
+		return
+	}
+
+	channelID := parts[1]
+	rule, err := parseRewriteRule(parts[2], strings.Join(parts[3:], " "))
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ %v", err),
+		})
+		return
+	}
+
+	channel, err := h.channelService.GetChannel(channelID)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Channel not found: %s", channelID),
+		})
+		return
+	}
+
+	if !h.authorizeChannel(ctx, b, update, user, channel) {
+		return
+	}
+
+	channel.Rewrites = append(channel.Rewrites, rule)
+	if err := h.channelService.SaveChannel(channel); err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Failed to save rewrite rule: %v", err),
+		})
+		return
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   fmt.Sprintf("✅ Rewrite rule %d added to channel %s: %s", len(channel.Rewrites), channel.ID, rule),
+	})
+}
+
+func (h *Handler) handleRemoveRewrite(ctx context.Context, b *bot.Bot, update *models.Update) {
+	user, ok := h.authorize(ctx, b, update, userDomain.PermissionManageChannels)
+	if !ok {
+		return
+	}
+
+	parts := strings.Fields(update.Message.Text)
+	if len(parts) < 3 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Usage: /removerewrite <channel_id> <rule_index>",
+		})
+		return
+	}
+
+	channelID := parts[1]
+	index, err := strconv.Atoi(parts[2])
+	if err != nil || index < 1 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "❌ Invalid rule index",
+		})
+		return
+	}
+
+	channel, err := h.channelService.GetChannel(channelID)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Channel not found: %s", channelID),
+		})
+		return
+	}
+
+	if !h.authorizeChannel(ctx, b, update, user, channel) {
+		return
+	}
+
+	if index > len(channel.Rewrites) {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "❌ Rule index out of range",
+		})
+		return
+	}
+
+	channel.Rewrites = append(channel.Rewrites[:index-1], channel.Rewrites[index:]...)
+	if err := h.channelService.SaveChannel(channel); err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Failed to remove rewrite rule: %v", err),
+		})
+		return
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   fmt.Sprintf("✅ Rewrite rule %d removed from channel %s", index, channel.ID),
+	})
+}
+
+func (h *Handler) handleListRewrites(ctx context.Context, b *bot.Bot, update *models.Update) {
+	user, ok := h.authorize(ctx, b, update, userDomain.PermissionManageChannels)
+	if !ok {
+		return
+	}
+
+	parts := strings.Fields(update.Message.Text)
+	if len(parts) < 2 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Usage: /listrewrites <channel_id>",
+		})
+		return
+	}
+
+	channelID := parts[1]
+	channel, err := h.channelService.GetChannel(channelID)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Channel not found: %s", channelID),
+		})
+		return
+	}
+
+	if !h.authorizeChannel(ctx, b, update, user, channel) {
+		return
+	}
+
+	if len(channel.Rewrites) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("Channel %s has no rewrite rules. Add one with /addrewrite.", channel.ID),
+		})
+		return
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "✏️ Rewrite rules of %s, applied in order:\n", channel.ID)
+	for i, rule := range channel.Rewrites {
+		fmt.Fprintf(&text, "%d. %s\n", i+1, rule)
+	}
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   text.String(),
+	})
+}
+
+// parseRewriteRule builds a rewrite rule from its type and the rest of the
+// command: the pattern, followed by " => " and the replacement for replace
+// rules. A replace rule without a replacement deletes the matches.
+func parseRewriteRule(ruleType string, args string) (channelDomain.RewriteRule, error) {
+	// An unknown type is reported by Validate
+	parsedType, err := channelDomain.ParseRewriteType(ruleType)
+	if err != nil {
+		parsedType = channelDomain.RewriteType(ruleType)
+	}
+
+	rule := channelDomain.RewriteRule{Type: parsedType, Pattern: args}
+	if parsedType == channelDomain.RewriteTypeReplace {
+		pattern, replacement, _ := strings.Cut(args, "=>")
+		rule.Pattern = strings.TrimSpace(pattern)
+		rule.Replacement = strings.TrimSpace(replacement)
+	}
+	return rule, rule.Validate()
+}
@@ -0,0 +1,197 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	searchDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/search/domain"
+	userDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/user/domain"
+)
+
+const (
+	// searchPageSize is the number of hits per page of bot search results
+	searchPageSize = 5
+	// searchSnippetWidth shortens hit snippets to fit a page in one message
+	searchSnippetWidth = 160
+	// maxSearchSessions bounds how many result messages can still be paged
+	maxSearchSessions = 256
+	// searchCallbackPrefix starts the callback data of page buttons
+	searchCallbackPrefix = "search:"
+)
+
+func (h *Handler) registerSearchCommands(b *bot.Bot) {
+	b.RegisterHandler(bot.HandlerTypeMessageText, "search", bot.MatchTypeCommandStartOnly, h.handleSearch)
+	b.RegisterHandler(bot.HandlerTypeCallbackQueryData, searchCallbackPrefix, bot.MatchTypePrefix, h.handleSearchPage)
+}
+
+func (h *Handler) handleSearch(ctx context.Context, b *bot.Bot, update *models.Update) {
+	user, ok := h.authorize(ctx, b, update, userDomain.PermissionSearch)
+	if !ok {
+		return
+	}
+
+	_, raw, _ := strings.Cut(update.Message.Text, " ")
+	query, err := searchDomain.ParseQuery(raw)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text: `Usage: /search <query>
+
+Words must all appear; "quoted phrases" must appear as written.
+-word excludes posts containing it.
+channel:@name limits the search to a channel.
+since:2025-01-31 and until:2025-02-28 limit the post date.`,
+		})
+		return
+	}
+
+	text, markup, err := h.searchPage(user, query, 0)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Search failed: %v", err),
+		})
+		return
+	}
+
+	sent, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:             update.Message.Chat.ID,
+		Text:               text,
+		ReplyMarkup:        markup,
+		LinkPreviewOptions: &models.LinkPreviewOptions{IsDisabled: bot.True()},
+	})
+	if err == nil && markup != nil {
+		h.searches.put(sent.Chat.ID, sent.ID, query.Raw)
+	}
+}
+
+// handleSearchPage shows another page of results when a page button is pressed
+func (h *Handler) handleSearchPage(ctx context.Context, b *bot.Bot, update *models.Update) {
+	callback := update.CallbackQuery
+	answer := func(text string) {
+		b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: callback.ID, Text: text})
+	}
+
+	message := callback.Message.Message
+	if message == nil {
+		answer("This search is no longer available")
+		return
+	}
+
+	user, ok := h.userService.Authorize(callback.From.ID, h.cfg.Get().AllowedUsers)
+	if !ok || !user.Can(userDomain.PermissionSearch) {
+		answer("❌ Unauthorized")
+		return
+	}
+
+	offset, err := strconv.Atoi(strings.TrimPrefix(callback.Data, searchCallbackPrefix))
+	raw, found := h.searches.get(message.Chat.ID, message.ID)
+	if err != nil || !found {
+		answer("This search has expired, please search again")
+		return
+	}
+	query, err := searchDomain.ParseQuery(raw)
+	if err != nil {
+		answer("This search has expired, please search again")
+		return
+	}
+
+	text, markup, err := h.searchPage(user, query, offset)
+	if err != nil {
+		answer(fmt.Sprintf("❌ Search failed: %v", err))
+		return
+	}
+
+	b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:             message.Chat.ID,
+		MessageID:          message.ID,
+		Text:               text,
+		ReplyMarkup:        markup,
+		LinkPreviewOptions: &models.LinkPreviewOptions{IsDisabled: bot.True()},
+	})
+	answer("")
+}
+
+// searchPage renders one page of results over the channels the user may see,
+// with buttons to the neighbouring pages
+func (h *Handler) searchPage(user *userDomain.User, query *searchDomain.Query, offset int) (string, models.ReplyMarkup, error) {
+	channels, err := h.listableChannels(user, true)
+	if err != nil {
+		return "", nil, err
+	}
+	results, err := h.searchService.Search(query, channels, offset, searchPageSize)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if results.Total == 0 {
+		return fmt.Sprintf("🔍 Nothing found for: %s", query.Raw), nil, nil
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("🔍 %d results for: %s\n\n", results.Total, query.Raw))
+	for i, hit := range results.Hits {
+		text.WriteString(fmt.Sprintf("%d. %s · %s\n%s\n%s\n\n",
+			offset+i+1, hit.Message.ChannelName, hit.Message.Date.Format("2006-01-02"),
+			searchDomain.Snippet(hit.Snippet, query.Required(), searchSnippetWidth), hit.Message.Link))
+	}
+	// The token scopes the feed to the channels this user may see
+	text.WriteString(fmt.Sprintf("RSS: %s/rss/search?q=%s&token=%s", h.cfg.Get().BaseURL(), url.QueryEscape(query.Raw), url.QueryEscape(h.authService.FeedToken(user.ID))))
+
+	var buttons []models.InlineKeyboardButton
+	if offset > 0 {
+		buttons = append(buttons, models.InlineKeyboardButton{
+			Text:         "◀️ Previous",
+			CallbackData: fmt.Sprintf("%s%d", searchCallbackPrefix, max(0, offset-searchPageSize)),
+		})
+	}
+	if offset+searchPageSize < results.Total {
+		buttons = append(buttons, models.InlineKeyboardButton{
+			Text:         "Next ▶️",
+			CallbackData: fmt.Sprintf("%s%d", searchCallbackPrefix, offset+searchPageSize),
+		})
+	}
+	if len(buttons) == 0 {
+		return text.String(), nil, nil
+	}
+	return text.String(), &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{buttons}}, nil
+}
+
+// searchSessions remembers the query behind each paged results message, since
+// callback data is too short to carry it. The oldest are forgotten first.
+type searchSessions struct {
+	mu      sync.Mutex
+	queries map[string]string
+	order   []string
+}
+
+func newSearchSessions() *searchSessions {
+	return &searchSessions{queries: map[string]string{}}
+}
+
+func (s *searchSessions) put(chatID int64, messageID int, query string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := fmt.Sprintf("%d:%d", chatID, messageID)
+	s.queries[key] = query
+	s.order = append(s.order, key)
+	if len(s.order) > maxSearchSessions {
+		delete(s.queries, s.order[0])
+		s.order = s.order[1:]
+	}
+}
+
+func (s *searchSessions) get(chatID int64, messageID int) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query, ok := s.queries[fmt.Sprintf("%d:%d", chatID, messageID)]
+	return query, ok
+}
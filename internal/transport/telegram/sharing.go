@@ -0,0 +1,114 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	userDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/user/domain"
+)
+
+func (h *Handler) handleShareChannel(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.updateChannelSharing(ctx, b, update, true)
+}
+
+func (h *Handler) handleUnshareChannel(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.updateChannelSharing(ctx, b, update, false)
+}
+
+// updateChannelSharing grants or revokes another user's access to a channel
+func (h *Handler) updateChannelSharing(ctx context.Context, b *bot.Bot, update *models.Update, share bool) {
+	user, ok := h.authorize(ctx, b, update, userDomain.PermissionManageChannels)
+	if !ok {
+		return
+	}
+
+	command := "/unsharechannel"
+	if share {
+		command = "/sharechannel"
+	}
+
+	parts := strings.Fields(update.Message.Text)
+	if len(parts) < 3 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Usage: %s <channel_id> <user_id>", command),
+		})
+		return
+	}
+
+	channelID := parts[1]
+	targetID, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "❌ Invalid user ID",
+		})
+		return
+	}
+
+	channel, err := h.channelService.GetChannel(channelID)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Channel not found: %s", channelID),
+		})
+		return
+	}
+
+	if !h.authorizeChannelOwner(ctx, b, update, user, channel) {
+		return
+	}
+
+	if share {
+		if _, ok := h.userService.Authorize(targetID, h.cfg.Get().AllowedUsers); !ok {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: update.Message.Chat.ID,
+				Text:   fmt.Sprintf("❌ User %d is not authorized to use this bot. Add them with /adduser first.", targetID),
+			})
+			return
+		}
+		if !channel.Share(targetID) {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: update.Message.Chat.ID,
+				Text:   fmt.Sprintf("❌ User %d already has access to channel %s", targetID, channelID),
+			})
+			return
+		}
+	} else if !channel.Unshare(targetID) {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Channel %s is not shared with user %d", channelID, targetID),
+		})
+		return
+	}
+
+	if err := h.channelService.SaveChannel(channel); err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Failed to save channel: %v", err),
+		})
+		return
+	}
+
+	text := fmt.Sprintf("✅ Channel %s shared with user %d", channelID, targetID)
+	if !share {
+		text = fmt.Sprintf("✅ Channel %s is no longer shared with user %d", channelID, targetID)
+	}
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   text,
+	})
+}
+
+// formatUserIDs joins user IDs for display
+func formatUserIDs(ids []int64) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,217 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	userDomain "github.com/reshetovitsme/rss-telegram-feed/internal/modules/user/domain"
+	appErrors "github.com/reshetovitsme/rss-telegram-feed/internal/shared/errors"
+)
+
+// registerUserCommands registers commands that manage users and roles
+func (h *Handler) registerUserCommands(b *bot.Bot) {
+	b.RegisterHandler(bot.HandlerTypeMessageText, "listusers", bot.MatchTypeCommandStartOnly, h.handleListUsers)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "adduser", bot.MatchTypeCommandStartOnly, h.handleAddUser)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "removeuser", bot.MatchTypeCommandStartOnly, h.handleRemoveUser)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "setrole", bot.MatchTypeCommandStartOnly, h.handleSetRole)
+}
+
+func (h *Handler) handleListUsers(ctx context.Context, b *bot.Bot, update *models.Update) {
+	_, ok := h.authorize(ctx, b, update, userDomain.PermissionManageUsers)
+	if !ok {
+		return
+	}
+
+	users, err := h.userService.GetAllUsers()
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("❌ Failed to list users: %v", err),
+		})
+		return
+	}
+
+	if len(users) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "📋 No users stored. Users from allowed_users act as admins.",
+		})
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString("📋 Users:\n\n")
+	for _, user := range users {
+		text.WriteString(fmt.Sprintf("• %d", user.ID))
+		if user.Username != "" {
+			text.WriteString(fmt.Sprintf(" (@%s)", user.Username))
+		}
+		text.WriteString(fmt.Sprintf(" - %s\n", user.EffectiveRole()))
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   text.String(),
+	})
+}
+
+func (h *Handler) handleAddUser(ctx context.Context, b *bot.Bot, update *models.Update) {
+	actor, ok := h.authorize(ctx, b, update, userDomain.PermissionManageUsers)
+	if !ok {
+		return
+	}
+
+	parts := strings.Fields(update.Message.Text)
+	if len(parts) < 2 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "❌ Usage: /adduser <user_id> [role] [username]",
+		})
+		return
+	}
+
+	userID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "❌ Invalid user ID",
+		})
+		return
+	}
+
+	role := userDomain.RoleViewer
+	if len(parts) > 2 {
+		role, err = userDomain.ParseRole(parts[2])
+		if err != nil {
+			h.sendRoleError(ctx, b, update)
+			return
+		}
+	}
+
+	var username string
+	if len(parts) > 3 {
+		username = strings.TrimPrefix(parts[3], "@")
+	}
+
+	user, err := h.userService.AddUser(actor, userID, username, role)
+	if err != nil {
+		h.sendUserError(ctx, b, update, err)
+		return
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   fmt.Sprintf("✅ User %d added with role %s", user.ID, user.EffectiveRole()),
+	})
+}
+
+func (h *Handler) handleRemoveUser(ctx context.Context, b *bot.Bot, update *models.Update) {
+	actor, ok := h.authorize(ctx, b, update, userDomain.PermissionManageUsers)
+	if !ok {
+		return
+	}
+
+	parts := strings.Fields(update.Message.Text)
+	if len(parts) < 2 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "❌ Usage: /removeuser <user_id>",
+		})
+		return
+	}
+
+	userID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "❌ Invalid user ID",
+		})
+		return
+	}
+
+	if err := h.userService.RemoveUser(actor, userID); err != nil {
+		h.sendUserError(ctx, b, update, err)
+		return
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   fmt.Sprintf("✅ User %d removed", userID),
+	})
+}
+
+func (h *Handler) handleSetRole(ctx context.Context, b *bot.Bot, update *models.Update) {
+	actor, ok := h.authorize(ctx, b, update, userDomain.PermissionManageUsers)
+	if !ok {
+		return
+	}
+
+	parts := strings.Fields(update.Message.Text)
+	if len(parts) < 3 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "❌ Usage: /setrole <user_id> <owner|admin|editor|viewer>",
+		})
+		return
+	}
+
+	userID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "❌ Invalid user ID",
+		})
+		return
+	}
+
+	role, err := userDomain.ParseRole(parts[2])
+	if err != nil {
+		h.sendRoleError(ctx, b, update)
+		return
+	}
+
+	user, err := h.userService.SetRole(actor, userID, role)
+	if err != nil {
+		h.sendUserError(ctx, b, update, err)
+		return
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   fmt.Sprintf("✅ User %d now has role %s", user.ID, user.EffectiveRole()),
+	})
+}
+
+// sendRoleError replies with the list of valid roles
+func (h *Handler) sendRoleError(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   fmt.Sprintf("❌ Invalid role. Valid roles: %s", strings.Join(userDomain.RoleNames(), ", ")),
+	})
+}
+
+// sendUserError maps user service errors to replies
+func (h *Handler) sendUserError(ctx context.Context, b *bot.Bot, update *models.Update, err error) {
+	text := fmt.Sprintf("❌ Failed: %v", err)
+	switch {
+	case errors.Is(err, appErrors.ErrUserNotFound):
+		text = "❌ User not found"
+	case errors.Is(err, appErrors.ErrInvalidRole):
+		h.sendRoleError(ctx, b, update)
+		return
+	case errors.Is(err, appErrors.ErrLastOwner):
+		text = "❌ Cannot remove or demote the last owner"
+	case errors.Is(err, appErrors.ErrForbidden):
+		text = "❌ You cannot manage users with this role"
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   text,
+	})
+}